@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Kev2406/PEA/internal/service"
+)
+
+// runLedgerCheck คำสั่ง ledger-check: เล่นซ้ำสมุดบัญชีเทียบกับแถวซีล พิมพ์รายงาน JSON แล้วคืน exit code
+// 0 = ตรงทั้งหมด (หรือปรับแก้ครบแล้ว), 1 = พบ drift ที่ยังไม่ได้แก้, 2 = ใช้งานผิด/ผิดพลาด
+//
+//	go run ./cmd/server ledger-check [-repair] [-seals "F0001001-F0001500, F0002001"]
+func runLedgerCheck(sealService *service.SealService, args []string) int {
+	flags := flag.NewFlagSet("ledger-check", flag.ContinueOnError)
+	repair := flags.Bool("repair", false, "ปรับแถวซีลที่ไม่ตรงให้ตรงกับสมุดบัญชี")
+	seals := flags.String("seals", "", "ช่วงเลขซีลที่จะตรวจ (ว่าง = ทุกเส้น)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var sealNumbers []string
+	if strings.TrimSpace(*seals) != "" {
+		expanded, err := sealService.ExpandSealNumbers(*seals)
+		if err != nil {
+			log.Printf("❌ ledger-check: %v", err)
+			return 2
+		}
+		sealNumbers = expanded
+	}
+
+	// ✅ งานจากบรรทัดคำสั่งไม่มีผู้ใช้ที่ล็อกอิน บันทึกเป็น system
+	report, err := sealService.CheckSealLedger(sealNumbers, *repair, service.SystemActor())
+	if err != nil {
+		log.Printf("❌ ledger-check: %v", err)
+		return 2
+	}
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Printf("❌ ledger-check: %v", err)
+		return 2
+	}
+	fmt.Println(string(output))
+
+	log.Printf("✅ ledger-check: ตรวจ %d เส้น ตรง %d ไม่ตรง %d ปรับแก้ %d ยังไม่มีสมุดบัญชี %d",
+		report.Checked, report.Consistent, report.Drifted, report.Repaired, len(report.Untracked))
+	if report.Drifted > report.Repaired {
+		return 1
+	}
+	return 0
+}
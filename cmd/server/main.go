@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -80,9 +81,9 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://192.168.2.19:5173, https://192.168.2.19:5173",
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Content-Type, Authorization, Accept",
+		AllowHeaders:     "Content-Type, Authorization, Accept, Idempotency-Key",
 		AllowCredentials: true,
-		ExposeHeaders:    "Content-Length, Content-Type",
+		ExposeHeaders:    "Content-Length, Content-Type, Idempotent-Replayed",
 		MaxAge:           12 * 3600,
 	}))
 
@@ -91,7 +92,7 @@ func main() {
 			c.Set("Access-Control-Allow-Origin", c.Get("Origin"))
 		}
 		c.Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept")
+		c.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, Idempotency-Key")
 		c.Set("Access-Control-Allow-Credentials", "true")
 		return c.SendStatus(fiber.StatusOK)
 	})
@@ -101,8 +102,26 @@ func main() {
 	transactionRepo := repository.NewTransactionRepository(config.DB)
 	logRepo := repository.NewLogRepository(config.DB)
 	technicianRepo := repository.NewTechnicianRepository(config.DB)
+	incidentRepo := repository.NewSealIncidentRepository(config.DB)
+	meterRepo := repository.NewMeterRepository(config.DB)
+	transferRepo := repository.NewSealTransferRepository(config.DB)
+	storeReturnRepo := repository.NewSealStoreReturnRepository(config.DB)
+	workOrderRepo := repository.NewWorkOrderRepository(config.DB)
+	sealLotRepo := repository.NewSealLotRepository(config.DB)
+	officeTransferRepo := repository.NewOfficeTransferRepository(config.DB)
+	officeRepo := repository.NewOfficeRepository(config.DB)
+	sealStockRepo := repository.NewSealStockRepository(config.DB)
+	notificationRepo := repository.NewNotificationRepository(config.DB)
+	sealAgingRepo := repository.NewSealAgingRepository(config.DB)
+	jobRunRepo := repository.NewJobRunRepository(config.DB)
+	stocktakeRepo := repository.NewStocktakeRepository(config.DB)
+	approvalRepo := repository.NewApprovalRepository(config.DB)
+	sealNumberFormatRepo := repository.NewSealNumberFormatRepository(config.DB)
+	idempotencyRepo := repository.NewIdempotencyRepository(config.DB)
 
 	userService := service.NewUserService(userRepo)
+	sealStockService := service.NewSealStockService(sealStockRepo, config.DB)
+	sealNumberFormatService := service.NewSealNumberFormatService(sealNumberFormatRepo)
 
 	sealService := service.NewSealService(
 		sealRepo,
@@ -110,30 +129,109 @@ func main() {
 		logRepo,
 		config.DB,
 		technicianRepo,
+		incidentRepo,
+		transferRepo,
+		storeReturnRepo,
+		workOrderRepo,
+		officeTransferRepo,
+		sealStockService,
+		stocktakeRepo,
+		sealNumberFormatService,
 	)
 
+	// ✅ CLI: go run ./cmd/server ledger-check [-repair] [-seals "..."] ตรวจซีลเทียบสมุดบัญชีแล้วจบการทำงาน (ไม่เปิด server)
+	if len(os.Args) > 1 && os.Args[1] == "ledger-check" {
+		wg.Wait()
+		os.Exit(runLedgerCheck(sealService, os.Args[2:]))
+	}
+
 	logService := service.NewLogService(logRepo)
 	technicianService := service.NewTechnicianService(technicianRepo)
+	meterService := service.NewMeterService(meterRepo)
+	workOrderService := service.NewWorkOrderService(workOrderRepo)
+	sealLotService := service.NewSealLotService(sealLotRepo)
+	officeService := service.NewOfficeService(officeRepo)
+	notificationService := service.NewNotificationService(notificationRepo)
+	sealAgingService := service.NewSealAgingService(sealAgingRepo, config.DB)
+	jobScheduler := service.NewJobScheduler(jobRunRepo, config.DB)
+	approvalService := service.NewApprovalService(approvalRepo, config.DB)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo)
+	transactionService := service.NewTransactionService(transactionRepo)
+	if err := service.RegisterApprovalOperations(approvalService, sealService, technicianService, logService); err != nil {
+		log.Fatal(err)
+	}
 
-	technicianController := controller.NewTechnicianController(technicianService, sealService)
+	technicianController := controller.NewTechnicianController(technicianService, sealService, workOrderService, approvalService)
 	userController := controller.NewUserController(userService)
-	sealController := controller.NewSealController(sealService)
-	logController := controller.NewLogController(logService)
+	sealController := controller.NewSealController(sealService, officeService, approvalService)
+	logController := controller.NewLogController(logService, officeService, approvalService)
+	meterController := controller.NewMeterController(meterService)
+	workOrderController := controller.NewWorkOrderController(workOrderService)
+	sealLotController := controller.NewSealLotController(sealLotService)
+	officeController := controller.NewOfficeController(officeService)
+	sealStockController := controller.NewSealStockController(sealStockService, officeService)
+	notificationController := controller.NewNotificationController(notificationService, officeService)
+	sealAgingController := controller.NewSealAgingController(sealAgingService, officeService)
+	jobController := controller.NewJobController(jobScheduler)
+	approvalController := controller.NewApprovalController(approvalService)
+	sealNumberFormatController := controller.NewSealNumberFormatController(sealNumberFormatService)
+	transactionController := controller.NewTransactionController(transactionService, officeService)
+
+	// ✅ Idempotency-Key: คำขอที่ส่งซ้ำ (เช่น สัญญาณหลุด) ได้ผลลัพธ์เดิม ไม่ทำงานซ้ำ
+	// วางต่อจาก JWT middleware ของแต่ละกลุ่ม เพื่อแยกคีย์ตามผู้เรียกที่ยืนยันตัวตนแล้ว
+	idempotency := middleware.IdempotencyMiddleware(idempotencyService)
 
 	publicGroup := app.Group("")
-	route.SetupTechnicianRoutes(publicGroup, technicianController)
+	route.SetupTechnicianRoutes(publicGroup, technicianController, idempotency)
 
-	secureGroup := app.Group("", middleware.JWTMiddleware())
+	secureGroup := app.Group("", middleware.JWTMiddleware(), idempotency)
 
 	route.SetupUserRoutes(secureGroup, userController)
 
 	route.SetupSealRoutes(secureGroup, sealController)
 
+	route.SetupMeterRoutes(secureGroup, meterController)
+
+	route.SetupWorkOrderRoutes(secureGroup, workOrderController)
+
+	route.SetupSealLotRoutes(secureGroup, sealLotController)
+
+	route.SetupOfficeRoutes(secureGroup, officeController)
+
+	route.SetupSealStockRoutes(secureGroup, sealStockController)
+
+	route.SetupNotificationRoutes(secureGroup, notificationController)
+
+	route.SetupSealAgingRoutes(secureGroup, sealAgingController)
+
+	route.SetupJobRoutes(secureGroup, jobController)
+
+	route.SetupApprovalRoutes(secureGroup, approvalController)
+
+	route.SetupSealNumberFormatRoutes(secureGroup, sealNumberFormatController)
+
+	route.SetupTransactionRoutes(secureGroup, transactionController)
+
 	secureGroup.Use("/logs", middleware.AdminOnlyMiddleware)
 	route.SetupLogRoutes(secureGroup, logController)
 
 	wg.Wait()
 
+	// ✅ งานเบื้องหลังตามกำหนดเวลา (เริ่มหลัง migration เสร็จ)
+	if err := jobScheduler.Register("reservation-expiry", "* * * * *",
+		"ปล่อยการจองซีลที่หมดเวลา", sealService.ReleaseExpiredReservations); err != nil {
+		log.Fatal(err)
+	}
+	if err := jobScheduler.Register("seal-aging", "0 * * * *",
+		"แจ้งสำนักงานเมื่อซีลค้างกับช่างเกินกำหนด", sealAgingService.EscalateAgedSeals); err != nil {
+		log.Fatal(err)
+	}
+	if err := jobScheduler.Register("idempotency-cleanup", "30 * * * *",
+		"ลบ Idempotency-Key ที่หมดอายุ", idempotencyService.PurgeExpiredKeys); err != nil {
+		log.Fatal(err)
+	}
+	jobScheduler.Start(context.Background())
+
 	// log.Fatal(app.ListenTLS(":443", "cert.pem", "key.pem"))
 
 	// หรือ ใช้ HTTP ธรรมดา (ถ้ามี Reverse Proxy หรือใช้ Local)
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ApprovalController struct {
+	approvalService *service.ApprovalService
+}
+
+func NewApprovalController(approvalService *service.ApprovalService) *ApprovalController {
+	return &ApprovalController{approvalService: approvalService}
+}
+
+// submitForApproval ส่งคำขอเข้าคิวอนุมัติเมื่อ operation ต้องอนุมัติ
+// คืน false เมื่อตอบ client ไปแล้ว (202 เข้าคิว / error) handler ต้อง return ค่า error ที่ได้ทันที
+func submitForApproval(
+	c *fiber.Ctx,
+	approvals *service.ApprovalService,
+	operation string,
+	payload interface{},
+	quantity int,
+	summary string,
+) (bool, error) {
+	required, err := approvals.RequiresApproval(operation, quantity)
+	if err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !required {
+		return true, nil
+	}
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	request, err := approvals.Submit(operation, payload, quantity, summary, actor)
+	if err != nil {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return false, c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":  "ส่งคำขอแล้ว รอผู้ใช้อีกคนอนุมัติก่อนดำเนินการ",
+		"approval": request,
+	})
+}
+
+// ✅ คำขออนุมัติ (?status=pending|approved|executed|failed|rejected|cancelled|all&operation=)
+// admin เห็นทั้งหมด ผู้ใช้อื่นเห็นเฉพาะคำขอของตัวเอง
+// GET /api/approvals
+func (ac *ApprovalController) GetApprovalsHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	status := model.ApprovalStatus(c.Query("status", string(model.ApprovalPending)))
+	if status == "all" {
+		status = ""
+	}
+	requests, err := ac.approvalService.GetApprovals(status, c.Query("operation"), actor)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch approvals"})
+	}
+	return c.JSON(requests)
+}
+
+// ✅ รายละเอียดคำขอ พร้อม payload เต็มและผลการดำเนินการ
+// GET /api/approvals/:id
+func (ac *ApprovalController) GetApprovalHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	approvalID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid approval ID"})
+	}
+	request, err := ac.approvalService.GetApproval(uint(approvalID), actor)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(request)
+}
+
+// ✅ อนุมัติแล้วดำเนินการทันที (ผู้อนุมัติต้องไม่ใช่ผู้ขอ)
+// PUT /api/approvals/:id/approve
+// Body: { "remark": "..." }
+func (ac *ApprovalController) ApproveApprovalHandler(c *fiber.Ctx) error {
+	return ac.reviewApproval(c, ac.approvalService.ApproveApproval, "อนุมัติคำขอแล้ว")
+}
+
+// ✅ ไม่อนุมัติ
+// PUT /api/approvals/:id/reject
+// Body: { "remark": "..." }
+func (ac *ApprovalController) RejectApprovalHandler(c *fiber.Ctx) error {
+	return ac.reviewApproval(c, ac.approvalService.RejectApproval, "ไม่อนุมัติคำขอแล้ว")
+}
+
+func (ac *ApprovalController) reviewApproval(
+	c *fiber.Ctx,
+	review func(approvalID uint, actor service.Actor, remark string) (*model.ApprovalRequest, error),
+	message string,
+) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	approvalID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid approval ID"})
+	}
+
+	var request struct {
+		Remark string `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	approval, err := review(uint(approvalID), actor, request.Remark)
+	if err != nil {
+		if errors.Is(err, service.ErrApprovalNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if approval.Status == model.ApprovalFailed {
+		message = "อนุมัติแล้ว แต่ดำเนินการไม่สำเร็จ: " + approval.Error
+	}
+	return c.JSON(fiber.Map{
+		"message":  message,
+		"approval": approval,
+	})
+}
+
+// ✅ ผู้ขอยกเลิกคำขอของตัวเองที่ยังรออนุมัติ
+// PUT /api/approvals/:id/cancel
+func (ac *ApprovalController) CancelApprovalHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	approvalID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid approval ID"})
+	}
+	approval, err := ac.approvalService.CancelApproval(uint(approvalID), actor)
+	if err != nil {
+		if errors.Is(err, service.ErrApprovalNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":  "ยกเลิกคำขอแล้ว",
+		"approval": approval,
+	})
+}
+
+// ✅ operation ที่ต้องอนุมัติ พร้อมการตั้งค่าปัจจุบัน (admin)
+// GET /api/approvals/policies
+func (ac *ApprovalController) GetApprovalPoliciesHandler(c *fiber.Ctx) error {
+	policies, err := ac.approvalService.GetPolicies()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch approval policies"})
+	}
+	return c.JSON(policies)
+}
+
+// ✅ แก้ไขการตั้งค่าของ operation (admin) การแก้ไขนี้ต้องผ่านการอนุมัติเช่นกัน
+// PUT /api/approvals/policies/:operation
+// Body: { "enabled": true, "approver_role": "admin", "min_quantity": 1000 }
+func (ac *ApprovalController) UpdateApprovalPolicyHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request service.ApprovalPolicyRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	request.Operation = c.Params("operation")
+	if err := ac.approvalService.ValidatePolicy(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if proceed, err := submitForApproval(c, ac.approvalService, service.ApprovalOpUpdatePolicy, request, 1,
+		"ตั้งค่าการอนุมัติของ "+request.Operation); !proceed {
+		return err
+	}
+
+	policy, err := ac.approvalService.UpdatePolicy(request, actor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message": "บันทึกการตั้งค่าแล้ว",
+		"policy":  policy,
+	})
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type JobController struct {
+	scheduler *service.JobScheduler
+}
+
+func NewJobController(scheduler *service.JobScheduler) *JobController {
+	return &JobController{scheduler: scheduler}
+}
+
+// ✅ งานเบื้องหลังทั้งหมด พร้อมรอบถัดไปและผลครั้งล่าสุด (admin)
+// GET /api/jobs
+func (jc *JobController) GetJobsHandler(c *fiber.Ctx) error {
+	jobs, err := jc.scheduler.GetJobs()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch jobs"})
+	}
+	return c.JSON(jobs)
+}
+
+// ✅ ประวัติการทำงานของงาน (?limit=50) (admin)
+// GET /api/jobs/:name/runs
+func (jc *JobController) GetJobRunsHandler(c *fiber.Ctx) error {
+	runs, err := jc.scheduler.GetJobRuns(c.Params("name"), c.QueryInt("limit", 50))
+	if err != nil {
+		if errors.Is(err, service.ErrJobNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch job runs"})
+	}
+	return c.JSON(runs)
+}
+
+// ✅ สั่งงานทันที งานทำต่อเบื้องหลัง ดูผลได้จากประวัติการทำงาน (admin)
+// POST /api/jobs/:name/run
+func (jc *JobController) TriggerJobHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	run, err := jc.scheduler.TriggerJob(c.Params("name"), actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrJobNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrJobRunning):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "เริ่มงานแล้ว",
+		"run":     run,
+	})
+}
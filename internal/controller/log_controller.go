@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 
@@ -14,11 +15,36 @@ func contains(str, substr string) bool {
 }
 
 type LogController struct {
-	logService *service.LogService
+	logService      *service.LogService
+	officeService   *service.OfficeService
+	approvalService *service.ApprovalService
 }
 
-func NewLogController(logService *service.LogService) *LogController {
-	return &LogController{logService: logService}
+func NewLogController(
+	logService *service.LogService,
+	officeService *service.OfficeService,
+	approvalService *service.ApprovalService,
+) *LogController {
+	return &LogController{logService: logService, officeService: officeService, approvalService: approvalService}
+}
+
+// ✅ ขอบเขตสำนักงานของผู้เรียก (admin เห็นทุกสำนักงาน)
+func (lc *LogController) scope(c *fiber.Ctx) (service.OfficeScope, error) {
+	return officeScopeFromContext(c, lc.officeService)
+}
+
+// ✅ ตอบ 403 เมื่อเข้าถึง Log นอกสำนักงาน ที่เหลือเป็น 500
+func logScopeError(c *fiber.Ctx, err error, message string) error {
+	if errors.Is(err, service.ErrOutsideOffice) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   "Forbidden",
+			"message": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   message,
+		"message": err.Error(),
+	})
 }
 
 func (lc *LogController) CreateLogHandler(c *fiber.Ctx) error {
@@ -34,7 +60,16 @@ func (lc *LogController) CreateLogHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	err := lc.logService.CreateLog(request.UserID, request.Action)
+	// ✅ พนักงานที่ไม่ใช่ admin บันทึก Log ได้เฉพาะผู้ใช้ในสำนักงานของตัวเอง
+	scope, err := lc.scope(c)
+	if err != nil {
+		return logScopeError(c, err, "Failed to create log")
+	}
+	if err := lc.logService.CheckUserInScope(request.UserID, scope); err != nil {
+		return logScopeError(c, err, "Failed to create log")
+	}
+
+	err = lc.logService.CreateLog(request.UserID, request.Action)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "Failed to create log",
@@ -49,7 +84,11 @@ func (lc *LogController) CreateLogHandler(c *fiber.Ctx) error {
 }
 
 func (lc *LogController) GetAllLogsHandler(c *fiber.Ctx) error {
-	logs, err := lc.logService.GetAllLogs()
+	scope, err := lc.scope(c)
+	if err != nil {
+		return logScopeError(c, err, "Failed to fetch logs")
+	}
+	logs, err := lc.logService.GetAllLogs(scope)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "Failed to fetch logs",
@@ -96,7 +135,14 @@ func (lc *LogController) GetLogByIDHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	log, err := lc.logService.GetLogByID(uint(logID))
+	scope, err := lc.scope(c)
+	if err != nil {
+		return logScopeError(c, err, "Failed to fetch log")
+	}
+	log, err := lc.logService.GetLogByID(uint(logID), scope)
+	if errors.Is(err, service.ErrOutsideOffice) {
+		return logScopeError(c, err, "Failed to fetch log")
+	}
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   "Log not found",
@@ -113,7 +159,11 @@ func (lc *LogController) GetLogByIDHandler(c *fiber.Ctx) error {
 // ✅ Get logs by type
 func (lc *LogController) GetLogsByTypeHandler(c *fiber.Ctx) error {
 	logType := c.Params("log_type")
-	logs, err := lc.logService.GetLogsByType(logType)
+	scope, err := lc.scope(c)
+	if err != nil {
+		return logScopeError(c, err, "Failed to fetch logs")
+	}
+	logs, err := lc.logService.GetLogsByType(logType, scope)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "Failed to fetch logs",
@@ -137,12 +187,13 @@ func (lc *LogController) GetLogsByUserHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	logs, err := lc.logService.GetLogsByUser(uint(userID))
+	scope, err := lc.scope(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to fetch logs",
-			"message": err.Error(),
-		})
+		return logScopeError(c, err, "Failed to fetch logs")
+	}
+	logs, err := lc.logService.GetLogsByUser(uint(userID), scope)
+	if err != nil {
+		return logScopeError(c, err, "Failed to fetch logs")
 	}
 
 	return c.JSON(fiber.Map{
@@ -163,7 +214,11 @@ func (lc *LogController) GetLogsByDateRangeHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	logs, err := lc.logService.GetLogsByDateRange(startDate, endDate)
+	scope, err := lc.scope(c)
+	if err != nil {
+		return logScopeError(c, err, "Failed to fetch logs")
+	}
+	logs, err := lc.logService.GetLogsByDateRange(startDate, endDate, scope)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   "Failed to fetch logs",
@@ -177,8 +232,15 @@ func (lc *LogController) GetLogsByDateRangeHandler(c *fiber.Ctx) error {
 	})
 }
 
-// ✅ Delete log by ID
+// ✅ Delete log by ID (Admin เท่านั้น)
 func (lc *LogController) DeleteLogHandler(c *fiber.Ctx) error {
+	if role, _ := c.Locals("role").(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   "Forbidden",
+			"message": "Access denied, admin only",
+		})
+	}
+
 	logID, err := strconv.Atoi(c.Params("log_id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -187,6 +249,13 @@ func (lc *LogController) DeleteLogHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	// ✅ ลบ log ต้องผ่านการอนุมัติ (ถ้าตั้งค่าไว้)
+	request := service.DeleteLogRequest{LogID: uint(logID)}
+	if proceed, err := submitForApproval(c, lc.approvalService, service.ApprovalOpDeleteLog, request, 1,
+		"ลบ log #"+strconv.Itoa(logID)); !proceed {
+		return err
+	}
+
 	err = lc.logService.DeleteLog(uint(logID))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -203,46 +272,33 @@ func (lc *LogController) DeleteLogHandler(c *fiber.Ctx) error {
 
 // ✅ Get logs where action contains "Created seal"
 func (lc *LogController) GetCreatedLogsHandler(c *fiber.Ctx) error {
-	logs, err := lc.logService.GetLogsByAction("Created seal")
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to fetch created logs",
-			"message": err.Error(),
-		})
-	}
-	return c.JSON(fiber.Map{"success": true, "logs": logs})
+	return lc.getLogsByAction(c, "Created seal", "Failed to fetch created logs")
 }
 
 // ✅ Get logs where action contains "Issued seal"
 func (lc *LogController) GetIssuedLogsHandler(c *fiber.Ctx) error {
-	logs, err := lc.logService.GetLogsByAction("Issued seal")
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to fetch issued logs",
-			"message": err.Error(),
-		})
-	}
-	return c.JSON(fiber.Map{"success": true, "logs": logs})
+	return lc.getLogsByAction(c, "Issued seal", "Failed to fetch issued logs")
 }
 
 // ✅ Get logs where action contains "Used seal"
 func (lc *LogController) GetUsedLogsHandler(c *fiber.Ctx) error {
-	logs, err := lc.logService.GetLogsByAction("Used seal")
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to fetch used logs",
-			"message": err.Error(),
-		})
-	}
-	return c.JSON(fiber.Map{"success": true, "logs": logs})
+	return lc.getLogsByAction(c, "Used seal", "Failed to fetch used logs")
 }
 
 // ✅ Get logs where action contains "Returned seal"
 func (lc *LogController) GetReturnedLogsHandler(c *fiber.Ctx) error {
-	logs, err := lc.logService.GetLogsByAction("Returned seal")
+	return lc.getLogsByAction(c, "Returned seal", "Failed to fetch returned logs")
+}
+
+func (lc *LogController) getLogsByAction(c *fiber.Ctx, action, message string) error {
+	scope, err := lc.scope(c)
+	if err != nil {
+		return logScopeError(c, err, message)
+	}
+	logs, err := lc.logService.GetLogsByAction(action, scope)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to fetch returned logs",
+			"error":   message,
 			"message": err.Error(),
 		})
 	}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MeterController struct {
+	meterService *service.MeterService
+}
+
+func NewMeterController(meterService *service.MeterService) *MeterController {
+	return &MeterController{meterService: meterService}
+}
+
+// ✅ ลงทะเบียน / อัปเดตข้อมูลมิเตอร์
+// POST /api/meters
+// Body: { "serial_number": "...", "manufacturer": "...", "customer_account": "...", "location": "..." }
+func (mc *MeterController) RegisterMeterHandler(c *fiber.Ctx) error {
+	var request struct {
+		SerialNumber    string `json:"serial_number"`
+		Manufacturer    string `json:"manufacturer"`
+		CustomerAccount string `json:"customer_account"`
+		Location        string `json:"location"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	meter, err := mc.meterService.RegisterMeter(&model.Meter{
+		SerialNumber:    request.SerialNumber,
+		Manufacturer:    request.Manufacturer,
+		CustomerAccount: request.CustomerAccount,
+		Location:        request.Location,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Meter saved successfully", "meter": meter})
+}
+
+// ✅ ค้นหามิเตอร์ (?q=serial หรือหมายเลขผู้ใช้ไฟ)
+// GET /api/meters
+func (mc *MeterController) GetMetersHandler(c *fiber.Ctx) error {
+	meters, err := mc.meterService.GetMeters(c.Query("q"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch meters"})
+	}
+	return c.JSON(meters)
+}
+
+// ✅ ดูข้อมูลมิเตอร์
+// GET /api/meters/:serial
+func (mc *MeterController) GetMeterHandler(c *fiber.Ctx) error {
+	meter, err := mc.meterService.GetMeterBySerial(c.Params("serial"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(meter)
+}
+
+// ✅ ซีลปัจจุบัน + ประวัติซีลทั้งหมดของมิเตอร์
+// GET /api/meters/:serial/seals
+func (mc *MeterController) GetMeterSealsHandler(c *fiber.Ctx) error {
+	history, err := mc.meterService.GetMeterSeals(c.Params("serial"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(history)
+}
+
+// ✅ รายการติดตั้งที่มิเตอร์มีซีลซ้อนกัน
+// GET /api/meters/flagged
+func (mc *MeterController) GetFlaggedInstallationsHandler(c *fiber.Ctx) error {
+	links, err := mc.meterService.GetFlaggedInstallations()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch flagged installations"})
+	}
+	return c.JSON(links)
+}
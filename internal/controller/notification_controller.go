@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationController struct {
+	notificationService *service.NotificationService
+	officeService       *service.OfficeService
+}
+
+func NewNotificationController(notificationService *service.NotificationService, officeService *service.OfficeService) *NotificationController {
+	return &NotificationController{notificationService: notificationService, officeService: officeService}
+}
+
+// ✅ การแจ้งเตือนของสำนักงานผู้เรียก (?unread=true เฉพาะที่ยังไม่อ่าน)
+// GET /api/notifications
+func (nc *NotificationController) GetNotificationsHandler(c *fiber.Ctx) error {
+	scope, err := officeScopeFromContext(c, nc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	notifications, err := nc.notificationService.GetNotifications(scope, c.QueryBool("unread"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch notifications"})
+	}
+	return c.JSON(notifications)
+}
+
+// ✅ ทำเครื่องหมายว่าอ่านแล้ว
+// PUT /api/notifications/:id/read
+func (nc *NotificationController) MarkNotificationReadHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid notification ID"})
+	}
+	scope, err := officeScopeFromContext(c, nc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+
+	notification, err := nc.notificationService.MarkNotificationRead(uint(id), actor, scope)
+	if errors.Is(err, service.ErrOutsideOffice) {
+		return officeScopeErrorResponse(c, err)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(notification)
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OfficeController struct {
+	officeService *service.OfficeService
+}
+
+func NewOfficeController(officeService *service.OfficeService) *OfficeController {
+	return &OfficeController{officeService: officeService}
+}
+
+// ✅ ทะเบียนสำนักงานทั้งหมด
+// GET /api/offices
+func (oc *OfficeController) GetOfficesHandler(c *fiber.Ctx) error {
+	offices, err := oc.officeService.GetOffices()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch offices"})
+	}
+	return c.JSON(offices)
+}
+
+// ✅ เพิ่ม/แก้ไขสำนักงานและสำนักงานแม่ (admin)
+// PUT /api/offices
+// Body: { "code": "E12345", "parent_code": "E10000", "short_name": "กฟอ.xx", "name": "..." }
+func (oc *OfficeController) SaveOfficeHandler(c *fiber.Ctx) error {
+	if role, _ := c.Locals("role").(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+
+	var office model.Office
+	if err := c.BodyParser(&office); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := oc.officeService.SaveOffice(&office); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message": "บันทึกสำนักงานเรียบร้อย",
+		"office":  office,
+	})
+}
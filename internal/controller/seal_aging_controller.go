@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SealAgingController struct {
+	agingService  *service.SealAgingService
+	officeService *service.OfficeService
+}
+
+func NewSealAgingController(agingService *service.SealAgingService, officeService *service.OfficeService) *SealAgingController {
+	return &SealAgingController{agingService: agingService, officeService: officeService}
+}
+
+// ✅ เกณฑ์อายุซีลที่จ่ายแล้วแต่ยังไม่ติดตั้ง
+// GET /api/seal-aging/policy
+func (ac *SealAgingController) GetPolicyHandler(c *fiber.Ctx) error {
+	policy, err := ac.agingService.GetPolicy()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch aging policy"})
+	}
+	return c.JSON(policy)
+}
+
+// ✅ ตั้งเกณฑ์อายุซีล (admin)
+// PUT /api/seal-aging/policy
+// Body: { "warn_days": 14, "escalate_days": 30 }
+func (ac *SealAgingController) SavePolicyHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if !actor.IsAdmin() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+
+	var request struct {
+		WarnDays     int `json:"warn_days"`
+		EscalateDays int `json:"escalate_days"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	policy, err := ac.agingService.SavePolicy(request.WarnDays, request.EscalateDays, actor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message": "บันทึกเกณฑ์อายุซีลเรียบร้อย",
+		"policy":  policy,
+	})
+}
+
+// ✅ ซีลที่จ่ายแล้วค้างเกินเกณฑ์ รายช่างและรายบริษัท (?company=ชื่อบริษัท)
+// GET /api/seal-aging/overdue
+func (ac *SealAgingController) GetOverdueSealsHandler(c *fiber.Ctx) error {
+	scope, err := officeScopeFromContext(c, ac.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	report, err := ac.agingService.GetOverdueSeals(scope, c.Query("company"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch overdue seals"})
+	}
+	return c.JSON(report)
+}
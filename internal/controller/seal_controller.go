@@ -1,23 +1,31 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
-	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Kev2406/PEA/internal/domain/model"
 	"github.com/Kev2406/PEA/internal/service"
 	"github.com/gofiber/fiber/v2"
 )
 
 type SealController struct {
-	sealService *service.SealService
+	sealService     *service.SealService
+	officeService   *service.OfficeService
+	approvalService *service.ApprovalService
 }
 
-func NewSealController(sealService *service.SealService) *SealController {
-	return &SealController{sealService: sealService}
+func NewSealController(
+	sealService *service.SealService,
+	officeService *service.OfficeService,
+	approvalService *service.ApprovalService,
+) *SealController {
+	return &SealController{sealService: sealService, officeService: officeService, approvalService: approvalService}
 }
 
 // -------------------------------------------------------------------
@@ -26,14 +34,24 @@ func NewSealController(sealService *service.SealService) *SealController {
 func (sc *SealController) GetSealsByStatusHandler(c *fiber.Ctx) error {
 	// /api/seals/status/:status เช่น /api/seals/status/พร้อมใช้งาน
 	rawStatus := c.Params("status")
-	status, err := url.QueryUnescape(rawStatus)
+	unescaped, err := url.QueryUnescape(rawStatus)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid status parameter: " + err.Error(),
 		})
 	}
+	status, ok := model.ParseSealStatus(unescaped)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown status: " + unescaped,
+		})
+	}
 
-	seals, err := sc.sealService.GetSealsByStatus(status)
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	seals, err := sc.sealService.GetSealsByStatus(status, scope)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -49,12 +67,18 @@ func (sc *SealController) GetSealByIDAndStatusHandler(c *fiber.Ctx) error {
 	rawID := c.Params("id")
 	rawStatus := c.Params("status")
 
-	status, err := url.QueryUnescape(rawStatus)
+	unescaped, err := url.QueryUnescape(rawStatus)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid status parameter: " + err.Error(),
 		})
 	}
+	status, ok := model.ParseSealStatus(unescaped)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown status: " + unescaped,
+		})
+	}
 
 	sealID, err := strconv.Atoi(rawID)
 	if err != nil {
@@ -69,6 +93,9 @@ func (sc *SealController) GetSealByIDAndStatusHandler(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Seal not found"})
 	}
+	if ok, err := sc.requireSealsInScope(c, seal.SealNumber); !ok {
+		return err
+	}
 	return c.JSON(seal)
 }
 
@@ -78,12 +105,18 @@ func (sc *SealController) GetSealByIDAndStatusHandler(c *fiber.Ctx) error {
 // Body:
 //
 //	{
+//	  "supplier": "บริษัท ซีลไทย จำกัด",      // ถ้าระบุ จะบันทึกล็อตผู้ผลิตให้ทุก batch
+//	  "purchase_order": "PO-6701-015",
+//	  "lot_number": "L2401",
+//	  "delivery_date": "2025-01-15",
 //	  "batches": [
 //	    { "seal_number": "F2499", "count": 3 },
-//	    { "seal_number": "PEA000002", "count": 2 }
-//	  ]
+//	    { "seal_number": "PEA000002", "count": 2, "lot_number": "L2402" }
+//	  ],
+//	  "dry_run": true                         // แสดงเลขที่จะสร้างและเลขที่ชน โดยไม่สร้างจริง (หรือ ?dry_run=true)
 //	}
 //
+// สร้างทุกชุดในธุรกรรมเดียว ถ้ามีเลขชนจะไม่สร้างเลยและตอบ 409 พร้อมรายละเอียด
 // -------------------------------------------------------------------
 func (sc *SealController) GenerateSealsMultipleBatchesHandler(c *fiber.Ctx) error {
 	userID, ok := c.Locals("user_id").(uint)
@@ -94,48 +127,63 @@ func (sc *SealController) GenerateSealsMultipleBatchesHandler(c *fiber.Ctx) erro
 		})
 	}
 
-	var request struct {
-		Batches []struct {
-			SealNumber string `json:"seal_number"`
-			Count      int    `json:"count"`
-		} `json:"batches"`
-	}
-
+	var request service.GenerateSealBatchesRequest
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid request body",
 		})
 	}
-
-	if len(request.Batches) == 0 {
+	if err := request.Validate(); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "No batches provided",
+			"error": err.Error(),
 		})
 	}
 
-	var allCreatedSeals []interface{}
+	starts := make([]string, 0, len(request.Batches))
 	for _, batch := range request.Batches {
-		if batch.SealNumber == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Seal number is required in each batch",
-			})
-		}
-		if batch.Count <= 0 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": fmt.Sprintf("Invalid count (%d) in batch for seal_number=%s", batch.Count, batch.SealNumber),
-			})
-		}
+		starts = append(starts, batch.SealNumber)
+	}
+	if ok, err := requireValidSealNumbers(c, sc.sealService, starts...); !ok {
+		return err
+	}
 
-		seals, err := sc.sealService.GenerateAndCreateSealsFromNumber(batch.SealNumber, batch.Count, userID)
+	// ✅ dry run: ตอบผลตรวจโดยไม่สร้าง ไม่ต้องผ่านการอนุมัติ
+	if request.DryRun || c.QueryBool("dry_run") {
+		plan, err := sc.sealService.PlanSealBatches(request)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{
+			"message": fmt.Sprintf("ตัวอย่างการสร้างซีล %d ชุด รวม %d อัน (ยังไม่ได้สร้าง)", len(plan.Batches), plan.Total),
+			"dry_run": true,
+			"plan":    plan,
+		})
+	}
+	request.DryRun = false
+
+	// ✅ สร้างซีลจำนวนมากต้องผ่านการอนุมัติ (ถ้าตั้งค่าไว้)
+	if proceed, err := submitForApproval(c, sc.approvalService, service.ApprovalOpGenerateSealBatches, request,
+		request.TotalCount(), fmt.Sprintf("สร้างซีล %d ชุด รวม %d อัน", len(request.Batches), request.TotalCount())); !proceed {
+		return err
+	}
+
+	result, err := sc.sealService.GenerateSealBatches(request, userID, officeFromContext(c))
+	if errors.Is(err, service.ErrSealBatchConflict) {
+		response := fiber.Map{"error": err.Error()}
+		if plan, planErr := sc.sealService.PlanSealBatches(request); planErr == nil {
+			response["plan"] = plan
 		}
-		allCreatedSeals = append(allCreatedSeals, seals)
+		return c.Status(fiber.StatusConflict).JSON(response)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
 		"message": "All batches generated successfully",
-		"results": allCreatedSeals,
+		"ranges":  result.Ranges,
+		"results": result.Results,
+		"lots":    result.Lots,
 	})
 }
 
@@ -152,10 +200,16 @@ func (sc *SealController) ScanSealHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
+	if ok, err := requireValidSealNumbers(c, sc.sealService, request.SealNumber); !ok {
+		return err
+	}
 	seal, err := sc.sealService.GetSealByNumber(request.SealNumber)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Seal not found"})
 	}
+	if ok, err := sc.requireSealsInScope(c, seal.SealNumber); !ok {
+		return err
+	}
 	return c.JSON(fiber.Map{
 		"message": "Seal scanned successfully",
 		"seal":    seal,
@@ -167,7 +221,11 @@ func (sc *SealController) ScanSealHandler(c *fiber.Ctx) error {
 // GET /api/seals/report
 // -------------------------------------------------------------------
 func (sc *SealController) GetSealReportHandler(c *fiber.Ctx) error {
-	report, err := sc.sealService.GetSealReport()
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	report, err := sc.sealService.GetSealReport(scope)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate report"})
 	}
@@ -184,6 +242,9 @@ func (sc *SealController) GetSealHandler(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Seal not found"})
 	}
+	if ok, err := sc.requireSealsInScope(c, seal.SealNumber); !ok {
+		return err
+	}
 	return c.JSON(seal)
 }
 
@@ -192,6 +253,10 @@ func (sc *SealController) GetSealHandler(c *fiber.Ctx) error {
 // PUT /api/seals/:seal_number/issue?issued_to=?&employee_code=?&remark=?
 // -------------------------------------------------------------------
 func (sc *SealController) IssueSealHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
 	sealNumber := c.Params("seal_number")
 
 	issuedToParam := c.Query("issued_to", "3")
@@ -203,6 +268,9 @@ func (sc *SealController) IssueSealHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid issued_to parameter"})
 	}
 
+	if ok, err := requireValidSealNumbers(c, sc.sealService, sealNumber); !ok {
+		return err
+	}
 	seal, err := sc.sealService.GetSealByNumber(sealNumber)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Database query failed"})
@@ -210,11 +278,11 @@ func (sc *SealController) IssueSealHandler(c *fiber.Ctx) error {
 	if seal == nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Seal not found"})
 	}
-	if seal.Status != "พร้อมใช้งาน" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Seal is not available for issuing"})
+	if ok, err := sc.requireSealsInScope(c, seal.SealNumber); !ok {
+		return err
 	}
 
-	if err := sc.sealService.IssueSealWithDetails(sealNumber, uint(issuedTo), employeeCode, remark); err != nil {
+	if err := sc.sealService.IssueSealWithDetails(sealNumber, uint(issuedTo), employeeCode, remark, actor); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -245,6 +313,12 @@ func (sc *SealController) UseSealHandler(c *fiber.Ctx) error {
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
+	if ok, err := requireValidSealNumbers(c, sc.sealService, sealNumber); !ok {
+		return err
+	}
+	if ok, err := sc.requireSealsInScope(c, sealNumber); !ok {
+		return err
+	}
 	if err := sc.sealService.UseSealWithSerial(sealNumber, userID, request.SerialNumber); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -272,6 +346,12 @@ func (sc *SealController) ReturnSealHandler(c *fiber.Ctx) error {
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
+	if ok, err := requireValidSealNumbers(c, sc.sealService, sealNumber); !ok {
+		return err
+	}
+	if ok, err := sc.requireSealsInScope(c, sealNumber); !ok {
+		return err
+	}
 	if err := sc.sealService.ReturnSealWithRemarks(sealNumber, userID, request.Remarks); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -304,7 +384,10 @@ func (sc *SealController) GenerateSealsHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Seal number is required"})
 	}
 
-	seals, err := sc.sealService.GenerateAndCreateSealsFromNumber(request.SealNumber, request.Count, userID)
+	if ok, err := requireValidSealNumbers(c, sc.sealService, request.SealNumber); !ok {
+		return err
+	}
+	seals, err := sc.sealService.GenerateAndCreateSealsFromNumber(request.SealNumber, request.Count, userID, officeFromContext(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -335,51 +418,33 @@ func (sc *SealController) CreateSealHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Count must be greater than zero"})
 	}
 
-	seals, err := sc.sealService.GenerateAndCreateSealsFromNumber(request.SealNumber, request.Count, userID)
+	if ok, err := requireValidSealNumbers(c, sc.sealService, request.SealNumber); !ok {
+		return err
+	}
+	seals, err := sc.sealService.GenerateAndCreateSealsFromNumber(request.SealNumber, request.Count, userID, officeFromContext(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(fiber.Map{"message": "Seals created successfully", "seals": seals})
 }
 
-// -------------------------------------------------------------------
-// ส่วน incrementSealNumber เดิม (ถ้าจะยังใช้ในบางที่)
-// -------------------------------------------------------------------
-func incrementSealNumber(current string) string {
-	if len(current) < 5 {
-		log.Println("❌ Error: Invalid seal number format")
-		return current
-	}
-
-	re := regexp.MustCompile(`^([A-Za-z]*)(\d+)$`)
-	matches := re.FindStringSubmatch(current)
-	if len(matches) != 3 {
-		log.Println("❌ Error: Invalid seal number format")
-		return current
-	}
-
-	prefix := matches[1]
-	numberPart := matches[2]
-
-	num, err := strconv.ParseInt(numberPart, 10, 64)
-	if err != nil {
-		log.Println("❌ Error parsing seal number:", err)
-		return current
-	}
-	num++
-	return fmt.Sprintf("%s%0*d", prefix, len(numberPart), num)
-}
-
 // -------------------------------------------------------------------
 // 12) CheckSealExistsHandler
 // GET /api/seals/check/:seal_number
+//
+// (การตรวจเลขซ้ำไม่จำกัดตามสำนักงาน เพราะเลขซีลต้องไม่ซ้ำทั้งระบบ)
 // -------------------------------------------------------------------
 func (sc *SealController) CheckSealExistsHandler(c *fiber.Ctx) error {
-	sealNumber := c.Params("seal_number")
+	sealNumber := strings.TrimSpace(c.Params("seal_number"))
 	log.Println("🔍 Checking Seal:", sealNumber)
+	if sealNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "กรุณาระบุเลขซีล"})
+	}
 
-	lastNumbers := []int{16, 17, 18}
-	exists, err := sc.sealService.CheckSealBeforeGenerate(sealNumber[:len(sealNumber)-2], lastNumbers)
+	exists, err := sc.sealService.SealNumberExists(sealNumber)
+	if errors.Is(err, service.ErrInvalidSealNumber) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -389,41 +454,15 @@ func (sc *SealController) CheckSealExistsHandler(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "Seal number is available", "seal_number": sealNumber})
 }
 
-// -------------------------------------------------------------------
-// 13) InstallSealHandler (ช่างติดตั้ง Seal เฉพาะที่ assigned)
-// PUT /api/seals/:seal_number/install
-// Body: { "serial_number": "..." }
-// -------------------------------------------------------------------
-func (sc *SealController) InstallSealHandler(c *fiber.Ctx) error {
-	techID, ok := c.Locals("tech_id").(uint)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
-	}
-
-	sealNumber := c.Params("seal_number")
-	var req struct {
-		SerialNumber string `json:"serial_number,omitempty"`
-	}
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
-	}
-
-	if err := sc.sealService.UseSealWithSerial(sealNumber, techID, req.SerialNumber); err != nil {
-		log.Println("❌ Install Seal Error:", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
-	}
-	return c.JSON(fiber.Map{
-		"message":       "ติดตั้ง Seal เรียบร้อย",
-		"serial_number": req.SerialNumber,
-	})
-}
-
 // -------------------------------------------------------------------
 // 14) GetSealLogsHandler (ดู Log ซีลจาก SealNumber)
 // GET /api/seals/:seal_number/logs
 // -------------------------------------------------------------------
 func (sc *SealController) GetSealLogsHandler(c *fiber.Ctx) error {
 	sealNumber := c.Params("seal_number")
+	if ok, err := sc.requireSealsInScope(c, sealNumber); !ok {
+		return err
+	}
 	logs, err := sc.sealService.GetSealLogs(sealNumber)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch logs"})
@@ -434,7 +473,7 @@ func (sc *SealController) GetSealLogsHandler(c *fiber.Ctx) error {
 // -------------------------------------------------------------------
 // 15) AssignSealToTechnicianHandler
 // PUT /api/seals/:seal_number/assign
-// Body: { "technician_id": 123, "remark": "..."}
+// Body: { "technician_id": 123, "remark": "...", "job_number": "WO-6701-0001" }
 //
 // (Assign ซีลให้ Technician ID ตรง ๆ)
 // -------------------------------------------------------------------
@@ -447,13 +486,28 @@ func (sc *SealController) AssignSealToTechnicianHandler(c *fiber.Ctx) error {
 	var request struct {
 		TechnicianID uint   `json:"technician_id"`
 		Remark       string `json:"remark"`
+		JobNumber    string `json:"job_number"`
 	}
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
 	sealNumber := c.Params("seal_number")
-	err := sc.sealService.AssignSealToTechnician(sealNumber, request.TechnicianID, assignedBy, request.Remark)
+	if ok, err := requireValidSealNumbers(c, sc.sealService, sealNumber); !ok {
+		return err
+	}
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	if err := sc.sealService.CheckSealsInScope(scope, sealNumber); err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	if err := sc.sealService.CheckTechnicianInScope(scope, request.TechnicianID); err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+
+	err = sc.sealService.AssignSealToTechnician(sealNumber, request.TechnicianID, assignedBy, request.Remark, request.JobNumber)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -466,102 +520,137 @@ func (sc *SealController) AssignSealToTechnicianHandler(c *fiber.Ctx) error {
 }
 
 // -------------------------------------------------------------------
-// 16) IssueMultipleSealsHandler (เบิกหลายซีลทีเดียว จาก base number)
+// 16) IssueMultipleSealsHandler (เบิกหลายซีลทีเดียว จากช่วงเลข หรือ base number)
 // POST /api/seals/issue-multiple
 // Body:
 //
 //	{
-//	  "base_seal_number": "F11620000051015",
-//	  "last_numbers": [16, 17, 18],
+//	  "seal_numbers": "F11620000051016-F11620000051018",
 //	  "issued_to": 3,
 //	  "employee_code": "12345",
 //	  "remark": "จ่ายให้พนักงานตามคำสั่ง"
 //	}
 //
+// (แบบเดิมยังใช้ได้: "base_seal_number": "F11620000051015", "last_numbers": [16, 17, 18])
 // -------------------------------------------------------------------
 func (sc *SealController) IssueMultipleSealsHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
 	var req struct {
-		BaseSealNumber string `json:"base_seal_number"`
-		LastNumbers    []int  `json:"last_numbers"`
-		IssuedTo       uint   `json:"issued_to"`
-		EmployeeCode   string `json:"employee_code"`
-		Remark         string `json:"remark"`
+		SealNumbers    service.SealNumberList `json:"seal_numbers"`
+		BaseSealNumber string                 `json:"base_seal_number"`
+		LastNumbers    []int                  `json:"last_numbers"`
+		IssuedTo       uint                   `json:"issued_to"`
+		EmployeeCode   string                 `json:"employee_code"`
+		Remark         string                 `json:"remark"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid JSON input"})
 	}
-	if req.BaseSealNumber == "" || len(req.LastNumbers) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Must provide base_seal_number and last_numbers"})
+	if len(req.SealNumbers) == 0 && (req.BaseSealNumber == "" || len(req.LastNumbers) == 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Must provide seal_numbers or base_seal_number and last_numbers"})
 	}
 
-	re := regexp.MustCompile(`^([A-Za-z]*)(\d+)$`)
-	matches := re.FindStringSubmatch(req.BaseSealNumber)
-	if len(matches) != 3 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid seal format in base_seal_number"})
+	var sealNumbers []string
+	if len(req.SealNumbers) > 0 {
+		expanded, ok, err := expandSealNumbers(c, sc.sealService, req.SealNumbers...)
+		if !ok {
+			return err
+		}
+		sealNumbers = expanded
+	} else {
+		fromBase, err := sc.sealService.SealNumbersFromBase(req.BaseSealNumber, req.LastNumbers)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid seal format in base_seal_number: " + err.Error()})
+		}
+		sealNumbers = fromBase
 	}
-	prefix := matches[1]
-	baseNumStr := matches[2]
 
-	issuedSeals, err := sc.sealService.IssueMultipleSeals(prefix, baseNumStr, req.LastNumbers, req.IssuedTo, req.EmployeeCode, req.Remark)
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	issuedSeals, err := sc.sealService.IssueMultipleSeals(sealNumbers, req.IssuedTo, req.EmployeeCode, req.Remark, actor, scope)
+	if errors.Is(err, service.ErrOutsideOffice) {
+		return officeScopeErrorResponse(c, err)
+	}
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(fiber.Map{
-		"message": "Issued multiple seals successfully",
-		"seals":   issuedSeals,
+		"message":     "Issued multiple seals successfully",
+		"seal_ranges": sc.sealService.CompressSealNumbers(sealNumbersOf(issuedSeals)),
+		"seals":       issuedSeals,
 	})
 }
 
 // -------------------------------------------------------------------
 // 17) CheckMultipleSealsHandler (query param) / CheckSealsHandler (body)
+// GET  /api/seals/check?seal_numbers=F0001001-F0001050,F0001060
+// POST /api/seals/check
+// Body: { "seal_numbers": "F0001001-F0001050, F0001060" }
+//
+// (ไม่จำกัดตามสำนักงานเช่นเดียวกับข้อ 12)
 // -------------------------------------------------------------------
 func (sc *SealController) CheckMultipleSealsHandler(c *fiber.Ctx) error {
 	rawParam := c.Query("seal_numbers", "")
 	if rawParam == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No seal_numbers provided"})
 	}
-	parts := strings.Split(rawParam, ",")
-	for i := range parts {
-		parts[i] = strings.TrimSpace(parts[i])
+	sealNumbers, ok, err := expandSealNumbers(c, sc.sealService, rawParam)
+	if !ok {
+		return err
 	}
-	unavailable, err := sc.sealService.CheckMultipleSeals(parts)
+	unavailable, err := sc.sealService.CheckMultipleSeals(sealNumbers)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(fiber.Map{"unavailable": unavailable})
+	return c.JSON(fiber.Map{
+		"unavailable":        unavailable,
+		"unavailable_ranges": sc.sealService.CompressSealNumbers(unavailable),
+	})
 }
 
 func (sc *SealController) CheckSealsHandler(c *fiber.Ctx) error {
 	var request struct {
-		SealNumbers []string `json:"seal_numbers"`
+		SealNumbers service.SealNumberList `json:"seal_numbers"`
 	}
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request format"})
 	}
-	if len(request.SealNumbers) == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "seal_numbers is required"})
-	}
 
-	foundSeals, missingSeals, err := sc.sealService.CheckSealAvailability(request.SealNumbers)
+	sealNumbers, ok, err := expandSealNumbers(c, sc.sealService, request.SealNumbers...)
+	if !ok {
+		return err
+	}
+	foundSeals, missingSeals, err := sc.sealService.CheckSealAvailability(sealNumbers)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Database query failed"})
 	}
 	return c.JSON(fiber.Map{
-		"found":       foundSeals,
-		"unavailable": missingSeals,
+		"found":              foundSeals,
+		"unavailable":        missingSeals,
+		"found_ranges":       sc.sealService.CompressSealNumbers(foundSeals),
+		"unavailable_ranges": sc.sealService.CompressSealNumbers(missingSeals),
 	})
 }
 
 // -------------------------------------------------------------------
 // 18) AssignSealsByTechCodeHandler
 // POST /api/seals/assign-by-techcode
-// Body: { "technician_code": "46735201FNRM-24", "seal_numbers": ["F1001","F1002"], "remark":"..." }
+// Body: { "technician_code": "46735201FNRM-24", "seal_numbers": "F1001-F1010, F1020", "remark":"..." }
 // -------------------------------------------------------------------
 func (sc *SealController) AssignSealsByTechCodeHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
 	var req struct {
-		TechnicianCode string   `json:"technician_code"`
-		SealNumbers    []string `json:"seal_numbers"`
-		Remark         string   `json:"remark,omitempty"`
+		TechnicianCode string                 `json:"technician_code"`
+		SealNumbers    service.SealNumberList `json:"seal_numbers"`
+		Remark         string                 `json:"remark,omitempty"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
@@ -572,15 +661,31 @@ func (sc *SealController) AssignSealsByTechCodeHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	sealNumbers, ok, err := expandSealNumbers(c, sc.sealService, req.SealNumbers...)
+	if !ok {
+		return err
+	}
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	if err := sc.sealService.CheckSealsInScope(scope, sealNumbers...); err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	if err := sc.sealService.CheckTechnicianCodeInScope(scope, req.TechnicianCode); err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+
 	// เรียก SealService.AssignSealsByTechCode
-	if err := sc.sealService.AssignSealsByTechCode(req.TechnicianCode, req.SealNumbers, req.Remark); err != nil {
+	if err := sc.sealService.AssignSealsByTechCode(req.TechnicianCode, sealNumbers, req.Remark, actor); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{
 		"message":         "Assigned seals successfully",
 		"technician_code": req.TechnicianCode,
-		"seals_assigned":  req.SealNumbers,
+		"seals_assigned":  sealNumbers,
+		"seal_ranges":     sc.sealService.CompressSealNumbers(sealNumbers),
 	})
 }
 func (sc *SealController) CancelSealHandler(c *fiber.Ctx) error {
@@ -590,6 +695,12 @@ func (sc *SealController) CancelSealHandler(c *fiber.Ctx) error {
 	}
 
 	sealNumber := c.Params("seal_number")
+	if ok, err := requireValidSealNumbers(c, sc.sealService, sealNumber); !ok {
+		return err
+	}
+	if ok, err := sc.requireSealsInScope(c, sealNumber); !ok {
+		return err
+	}
 	err := sc.sealService.CancelSeal(sealNumber, userID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
@@ -599,3 +710,1079 @@ func (sc *SealController) CancelSealHandler(c *fiber.Ctx) error {
 		"message": fmt.Sprintf("ซีล %s ถูกคืนสำเร็จ และกลับเป็นสถานะ 'พร้อมใช้งาน'", sealNumber),
 	})
 }
+
+// -------------------------------------------------------------------
+// 18.1) CancelSealsHandler (คืนซีลหลายเส้นเข้าคลังในครั้งเดียว ต้องผ่านการอนุมัติถ้าตั้งค่าไว้)
+// PUT /api/seals/cancel
+// Body: { "seal_numbers": "F1001-F1050, F1060" }
+// -------------------------------------------------------------------
+func (sc *SealController) CancelSealsHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request service.CancelSealsRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	sealNumbers, ok, err := expandSealNumbers(c, sc.sealService, request.SealNumbers...)
+	if !ok {
+		return err
+	}
+	// เก็บรายการที่ขยายแล้วในคำขออนุมัติ ผู้อนุมัติเห็นซีลครบทุกเส้น
+	request.SealNumbers = sealNumbers
+	if ok, err := sc.requireSealsInScope(c, request.SealNumbers...); !ok {
+		return err
+	}
+
+	if proceed, err := submitForApproval(c, sc.approvalService, service.ApprovalOpCancelSeals, request,
+		len(request.SealNumbers), fmt.Sprintf("คืนซีล %d เส้นเข้าคลัง", len(request.SealNumbers))); !proceed {
+		return err
+	}
+
+	if err := sc.sealService.CancelSeals(request.SealNumbers, userID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":      fmt.Sprintf("คืนซีล %d เส้นสำเร็จ และกลับเป็นสถานะ 'พร้อมใช้งาน'", len(request.SealNumbers)),
+		"seal_numbers": request.SealNumbers,
+		"seal_ranges":  sc.sealService.CompressSealNumbers(request.SealNumbers),
+	})
+}
+
+// -------------------------------------------------------------------
+// 19) GetSealTransitionsHandler (action ถัดไปที่ผู้เรียกทำได้)
+// GET /api/seals/:seal_number/transitions
+// -------------------------------------------------------------------
+func (sc *SealController) GetSealTransitionsHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	sealNumber := c.Params("seal_number")
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err == nil {
+		err = sc.sealService.CheckSealsInScope(scope, sealNumber)
+	}
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	seal, transitions, err := sc.sealService.GetSealTransitions(sealNumber, actor, scope)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"seal_number":  seal.SealNumber,
+		"status":       seal.Status,
+		"status_label": seal.StatusLabel,
+		"transitions":  transitions,
+	})
+}
+
+// actorFromContext สร้าง service.Actor จาก Locals ที่ middleware เซ็ตไว้ (ช่างหรือพนักงาน PEA)
+func actorFromContext(c *fiber.Ctx) (service.Actor, bool) {
+	if techID, ok := c.Locals("tech_id").(uint); ok {
+		return service.TechnicianActor(techID), true
+	}
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return service.Actor{}, false
+	}
+	role, _ := c.Locals("role").(string)
+	return service.UserActor(userID, role).WithOffice(officeFromContext(c)), true
+}
+
+// officeFromContext รหัสกฟฟ. ของพนักงานจาก JWT (ว่างถ้าไม่มี)
+func officeFromContext(c *fiber.Ctx) string {
+	office, _ := c.Locals("pea_code").(string)
+	return office
+}
+
+// officeScopeFromContext ขอบเขตสำนักงานของผู้เรียก (admin = ทุกสำนักงาน, ไม่มีตัวตน = ไม่เห็นอะไรเลย)
+func officeScopeFromContext(c *fiber.Ctx, officeService *service.OfficeService) (service.OfficeScope, error) {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return service.OfficeScope{Offices: []string{}}, nil
+	}
+	return officeService.ScopeFor(actor)
+}
+
+// officeScopeErrorResponse ตอบ 403 เมื่อเข้าถึงข้อมูลนอกสำนักงาน ที่เหลือเป็น 500
+func officeScopeErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, service.ErrOutsideOffice) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// requireValidSealNumbers เลขซีลที่รับเข้ามาต้องตรงกับรูปแบบที่ลงทะเบียนไว้ (ตอบ 400 ถ้าพิมพ์/สแกนผิด)
+func requireValidSealNumbers(c *fiber.Ctx, sealService *service.SealService, sealNumbers ...string) (bool, error) {
+	if err := sealService.ValidateSealNumbers(sealNumbers...); err != nil {
+		if errors.Is(err, service.ErrInvalidSealNumber) {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return true, nil
+}
+
+// expandSealNumbers ขยายช่วงเลขซีลที่รับเข้ามา ("F0001001-F0001050, F0001060") เป็นรายการเลขซีล
+// ตรวจรูปแบบไปพร้อมกัน (ตอบ 400 ถ้าช่วงเลข/เลขซีลไม่ถูกต้องหรือไม่ได้ระบุซีล)
+func expandSealNumbers(c *fiber.Ctx, sealService *service.SealService, expressions ...string) ([]string, bool, error) {
+	sealNumbers, err := sealService.ExpandSealNumbers(expressions...)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSealNumber) {
+			return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return nil, false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(sealNumbers) == 0 {
+		return nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "seal_numbers is required"})
+	}
+	return sealNumbers, true, nil
+}
+
+// sealNumbersOf เลขซีลของรายการซีล (ใช้ย่อเป็นช่วงในผลลัพธ์)
+func sealNumbersOf(seals []model.Seal) []string {
+	sealNumbers := make([]string, len(seals))
+	for i := range seals {
+		sealNumbers[i] = seals[i].SealNumber
+	}
+	return sealNumbers
+}
+
+// requireSealsInScope ตรวจว่าซีลทุกเส้นอยู่ในขอบเขตสำนักงานของผู้เรียก
+// คืน false พร้อม response (403/500) ที่ส่งไปแล้วถ้าไม่ผ่าน
+func (sc *SealController) requireSealsInScope(c *fiber.Ctx, sealNumbers ...string) (bool, error) {
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err == nil {
+		err = sc.sealService.CheckSealsInScope(scope, sealNumbers...)
+	}
+	if err != nil {
+		return false, officeScopeErrorResponse(c, err)
+	}
+	return true, nil
+}
+
+// requireStoreReturnInScope คำขอคืนต้องมาจากช่างของสำนักงานในขอบเขต
+func (sc *SealController) requireStoreReturnInScope(c *fiber.Ctx, returnID uint) (bool, error) {
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err == nil {
+		err = sc.sealService.CheckSealStoreReturnInScope(scope, returnID)
+	}
+	if err != nil {
+		return false, officeScopeErrorResponse(c, err)
+	}
+	return true, nil
+}
+
+// requireStocktakeInScope รอบตรวจนับต้องเป็นของสำนักงานในขอบเขต
+func (sc *SealController) requireStocktakeInScope(c *fiber.Ctx, stocktakeID uint) (bool, error) {
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err == nil {
+		err = sc.sealService.CheckStocktakeInScope(scope, stocktakeID)
+	}
+	if err != nil {
+		return false, officeScopeErrorResponse(c, err)
+	}
+	return true, nil
+}
+
+// -------------------------------------------------------------------
+// 20) GetSealIncidentsHandler (admin ดูรายงานซีลสูญหาย/ชำรุด)
+// GET /api/seals/incidents?status=pending
+// -------------------------------------------------------------------
+func (sc *SealController) GetSealIncidentsHandler(c *fiber.Ctx) error {
+	status := model.SealIncidentStatus(c.Query("status", string(model.SealIncidentPending)))
+	if status == "all" {
+		status = ""
+	}
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	incidents, err := sc.sealService.GetSealIncidents(status, scope)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch incidents"})
+	}
+	return c.JSON(incidents)
+}
+
+// -------------------------------------------------------------------
+// 21) ConfirmSealIncidentHandler / RejectSealIncidentHandler (admin)
+// PUT /api/seals/incidents/:id/confirm
+// PUT /api/seals/incidents/:id/reject
+// Body: { "remark": "..." }
+// -------------------------------------------------------------------
+func (sc *SealController) ConfirmSealIncidentHandler(c *fiber.Ctx) error {
+	return sc.reviewSealIncident(c, sc.sealService.ConfirmSealIncident, "ยืนยันรายงานเรียบร้อย")
+}
+
+func (sc *SealController) RejectSealIncidentHandler(c *fiber.Ctx) error {
+	return sc.reviewSealIncident(c, sc.sealService.RejectSealIncident, "ปฏิเสธรายงานเรียบร้อย")
+}
+
+func (sc *SealController) reviewSealIncident(
+	c *fiber.Ctx,
+	review func(incidentID uint, actor service.Actor, remark string) (*model.SealIncident, error),
+	message string,
+) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if !actor.IsAdmin() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+
+	incidentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid incident ID"})
+	}
+	var request struct {
+		Remark string `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	incident, err := review(uint(incidentID), actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":  message,
+		"incident": incident,
+	})
+}
+
+// -------------------------------------------------------------------
+// 22) VoidSealHandler (admin ยกเลิกซีลที่ยังไม่ติดตั้ง)
+// PUT /api/seals/:seal_number/void
+// Body: { "reason": "..." }
+// -------------------------------------------------------------------
+func (sc *SealController) VoidSealHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if !actor.IsAdmin() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	sealNumber := c.Params("seal_number")
+	if ok, err := requireValidSealNumbers(c, sc.sealService, sealNumber); !ok {
+		return err
+	}
+	if err := sc.sealService.VoidSeal(sealNumber, actor, request.Reason); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":     fmt.Sprintf("ซีล %s ถูกยกเลิกใช้งานแล้ว", sealNumber),
+		"seal_number": sealNumber,
+		"reason":      request.Reason,
+	})
+}
+
+// -------------------------------------------------------------------
+// 23) ReplaceSealHandler (ถอดซีลเดิม + ติดตั้งซีลใหม่ ในธุรกรรมเดียว)
+// POST /api/seals/replace
+// Body:
+//
+//	{
+//	  "meter_serial": "M123456",
+//	  "old_seal_number": "F0001001",
+//	  "new_seal_number": "F0001050",
+//	  "reason": "เปลี่ยนมิเตอร์ชำรุด",
+//	  "new_meter_serial": "M999999"   // ถ้าเปลี่ยนมิเตอร์ทั้งลูก
+//	}
+//
+// -------------------------------------------------------------------
+func (sc *SealController) ReplaceSealHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	// พนักงานเปลี่ยนได้เฉพาะซีลของสำนักงานในขอบเขต (body ถูก parse ซ้ำใน replaceSeal)
+	var request struct {
+		OldSealNumber string `json:"old_seal_number"`
+		NewSealNumber string `json:"new_seal_number"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if ok, err := sc.requireSealsInScope(c, request.OldSealNumber, request.NewSealNumber); !ok {
+		return err
+	}
+	return replaceSeal(c, sc.sealService, actor)
+}
+
+// replaceSeal ใช้ร่วมกันระหว่าง route ของพนักงาน PEA และของช่าง
+func replaceSeal(c *fiber.Ctx, sealService *service.SealService, actor service.Actor) error {
+	var request struct {
+		MeterSerial    string `json:"meter_serial"`
+		OldSealNumber  string `json:"old_seal_number"`
+		NewSealNumber  string `json:"new_seal_number"`
+		Reason         string `json:"reason"`
+		NewMeterSerial string `json:"new_meter_serial,omitempty"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if ok, err := requireValidSealNumbers(c, sealService, request.OldSealNumber, request.NewSealNumber); !ok {
+		return err
+	}
+
+	result, err := sealService.ReplaceSeal(service.SealReplacementRequest{
+		MeterSerial:    request.MeterSerial,
+		OldSealNumber:  request.OldSealNumber,
+		NewSealNumber:  request.NewSealNumber,
+		Reason:         request.Reason,
+		NewMeterSerial: request.NewMeterSerial,
+	}, actor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":   fmt.Sprintf("เปลี่ยนซีล %s เป็น %s เรียบร้อย", request.OldSealNumber, request.NewSealNumber),
+		"removed":   result.Removed,
+		"installed": result.Installed,
+	})
+}
+
+// -------------------------------------------------------------------
+// 24) Seal transfers (ฝั่ง admin): โอนซีลระหว่างช่างแทนช่าง / ดูรายการ / ยกเลิก
+// POST /api/seals/transfers
+// Body: { "seal_numbers": "F0001001-F0001010", "to_technician_code": "T002", "remark": "..." }
+// GET  /api/seals/transfers?status=pending (admin)
+// PUT  /api/seals/transfers/:id/cancel
+// -------------------------------------------------------------------
+func (sc *SealController) InitiateSealTransferHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if !actor.IsAdmin() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+	return initiateSealTransfer(c, sc.sealService, actor)
+}
+
+func (sc *SealController) GetSealTransfersHandler(c *fiber.Ctx) error {
+	if role, _ := c.Locals("role").(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+	status := model.SealTransferStatus(c.Query("status", string(model.SealTransferPending)))
+	if status == "all" {
+		status = ""
+	}
+	transfers, err := sc.sealService.GetSealTransfers(status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch transfers"})
+	}
+	return c.JSON(transfers)
+}
+
+func (sc *SealController) CancelSealTransferHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	return cancelSealTransfer(c, sc.sealService, actor)
+}
+
+func initiateSealTransfer(c *fiber.Ctx, sealService *service.SealService, actor service.Actor) error {
+	var request struct {
+		SealNumbers      service.SealNumberList `json:"seal_numbers"`
+		ToTechnicianCode string                 `json:"to_technician_code"`
+		Remark           string                 `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if request.ToTechnicianCode == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to_technician_code is required"})
+	}
+
+	sealNumbers, ok, err := expandSealNumbers(c, sealService, request.SealNumbers...)
+	if !ok {
+		return err
+	}
+	transfers, err := sealService.InitiateSealTransfer(sealNumbers, request.ToTechnicianCode, actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":     fmt.Sprintf("ส่งรายการโอนซีล %d รายการ รอช่างผู้รับยืนยัน", len(transfers)),
+		"seal_ranges": sealService.CompressSealNumbers(sealNumbers),
+		"transfers":   transfers,
+	})
+}
+
+func cancelSealTransfer(c *fiber.Ctx, sealService *service.SealService, actor service.Actor) error {
+	transferID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transfer ID"})
+	}
+	transfer, err := sealService.CancelSealTransfer(uint(transferID), actor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":  "ยกเลิกรายการโอนเรียบร้อย",
+		"transfer": transfer,
+	})
+}
+
+// -------------------------------------------------------------------
+// 25) Store returns (ฝั่งคลัง): ตรวจรับซีลที่ช่างขอคืน
+// GET /api/seals/store-returns?status=pending
+// PUT /api/seals/store-returns/:id/confirm
+// Body: { "received_seal_numbers": "F0001001-F0001010", "remark": "..." }
+// PUT /api/seals/store-returns/:id/reject
+// Body: { "remark": "..." }
+// -------------------------------------------------------------------
+func (sc *SealController) GetSealStoreReturnsHandler(c *fiber.Ctx) error {
+	status := model.SealStoreReturnStatus(c.Query("status", string(model.SealStoreReturnPending)))
+	if status == "all" {
+		status = ""
+	}
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	returns, err := sc.sealService.GetSealStoreReturns(status, scope)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch store returns"})
+	}
+	return c.JSON(returns)
+}
+
+func (sc *SealController) ConfirmSealStoreReturnHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	returnID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid return ID"})
+	}
+	var request struct {
+		ReceivedSealNumbers service.SealNumberList `json:"received_seal_numbers"`
+		Remark              string                 `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if ok, err := sc.requireStoreReturnInScope(c, uint(returnID)); !ok {
+		return err
+	}
+	received, ok, err := expandSealNumbers(c, sc.sealService, request.ReceivedSealNumbers...)
+	if !ok {
+		return err
+	}
+
+	storeReturn, err := sc.sealService.ConfirmSealStoreReturn(uint(returnID), received, actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":         "ตรวจรับซีลคืนคลังเรียบร้อย",
+		"received_ranges": sc.sealService.CompressSealNumbers(received),
+		"store_return":    storeReturn,
+	})
+}
+
+func (sc *SealController) RejectSealStoreReturnHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	returnID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid return ID"})
+	}
+	var request struct {
+		Remark string `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if ok, err := sc.requireStoreReturnInScope(c, uint(returnID)); !ok {
+		return err
+	}
+
+	storeReturn, err := sc.sealService.RejectSealStoreReturn(uint(returnID), actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":      "ปฏิเสธคำขอคืนเรียบร้อย",
+		"store_return": storeReturn,
+	})
+}
+
+// -------------------------------------------------------------------
+// 26) Office transfers: ส่งซีลระหว่างกฟฟ. (ส่ง -> ระหว่างขนส่ง -> ปลายทางรับ)
+// POST /api/seals/office-transfers
+// Body: { "seal_numbers": "F0001001-F0001500", "to_office": "E12345", "remark": "..." }
+// GET  /api/seals/office-transfers?direction=incoming|outgoing&status=in_transit
+// PUT  /api/seals/office-transfers/:id/receive
+// PUT  /api/seals/office-transfers/:id/recall
+// -------------------------------------------------------------------
+func (sc *SealController) DispatchSealsToOfficeHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request struct {
+		SealNumbers service.SealNumberList `json:"seal_numbers"`
+		ToOffice    string                 `json:"to_office"`
+		Remark      string                 `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	sealNumbers, ok, err := expandSealNumbers(c, sc.sealService, request.SealNumbers...)
+	if !ok {
+		return err
+	}
+	transfer, err := sc.sealService.DispatchSealsToOffice(sealNumbers, request.ToOffice, actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":     fmt.Sprintf("ส่งซีล %d รายการไปยังสำนักงาน %s แล้ว รอปลายทางตรวจรับ", len(transfer.Items), transfer.ToOffice),
+		"seal_ranges": sc.sealService.CompressSealNumbers(sealNumbers),
+		"transfer":    transfer,
+	})
+}
+
+func (sc *SealController) GetOfficeTransfersHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	// admin ดูได้ทุกสำนักงาน (หรือระบุ ?office=)
+	office := actor.Office
+	if actor.IsAdmin() {
+		office = c.Query("office")
+	}
+	incoming := c.Query("direction", "incoming") != "outgoing"
+	status := model.OfficeTransferStatus(c.Query("status"))
+
+	transfers, err := sc.sealService.GetOfficeTransfers(office, incoming, status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch office transfers"})
+	}
+	return c.JSON(transfers)
+}
+
+func (sc *SealController) ReceiveOfficeTransferHandler(c *fiber.Ctx) error {
+	return sc.closeOfficeTransfer(c, sc.sealService.ReceiveOfficeTransfer, "ตรวจรับซีลเรียบร้อย")
+}
+
+func (sc *SealController) RecallOfficeTransferHandler(c *fiber.Ctx) error {
+	return sc.closeOfficeTransfer(c, sc.sealService.RecallOfficeTransfer, "เรียกคืนการส่งเรียบร้อย")
+}
+
+func (sc *SealController) closeOfficeTransfer(
+	c *fiber.Ctx,
+	close func(transferID uint, actor service.Actor) (*model.OfficeTransfer, error),
+	message string,
+) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	transferID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transfer ID"})
+	}
+
+	transfer, err := close(uint(transferID), actor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":  message,
+		"transfer": transfer,
+	})
+}
+
+// -------------------------------------------------------------------
+// 27) Reservations: จองซีลไว้ให้ช่างสำหรับงานล่วงหน้า / ปล่อยการจอง
+// POST /api/seals/reservations
+// Body: { "seal_numbers": "F0001001-F0001020", "technician_id": 7, "reserved_until": "2025-03-02T17:00:00+07:00", "remark": "..." }
+// (ไม่ระบุ reserved_until = จอง 24 ชั่วโมง)
+// PUT  /api/seals/reservations/release
+// Body: { "seal_numbers": "F0001001-F0001020", "remark": "..." }
+// GET  /api/seals/reservations?technician_id=7
+// -------------------------------------------------------------------
+func (sc *SealController) ReserveSealsHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request struct {
+		SealNumbers   service.SealNumberList `json:"seal_numbers"`
+		TechnicianID  uint                   `json:"technician_id"`
+		ReservedUntil string                 `json:"reserved_until"`
+		Remark        string                 `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if request.TechnicianID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "technician_id is required"})
+	}
+	until := time.Now().Add(24 * time.Hour)
+	if request.ReservedUntil != "" {
+		parsed, err := time.Parse(time.RFC3339, request.ReservedUntil)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid reserved_until format, use RFC3339"})
+		}
+		until = parsed
+	}
+
+	sealNumbers, ok, err := expandSealNumbers(c, sc.sealService, request.SealNumbers...)
+	if !ok {
+		return err
+	}
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	if err := sc.sealService.CheckSealsInScope(scope, sealNumbers...); err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	if err := sc.sealService.CheckTechnicianInScope(scope, request.TechnicianID); err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+
+	seals, err := sc.sealService.ReserveSeals(sealNumbers, request.TechnicianID, until, actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":     fmt.Sprintf("จองซีล %d รายการให้ช่าง ID %d ถึง %s", len(seals), request.TechnicianID, until.Format("2006-01-02 15:04")),
+		"seal_ranges": sc.sealService.CompressSealNumbers(sealNumbersOf(seals)),
+		"seals":       seals,
+	})
+}
+
+func (sc *SealController) ReleaseSealReservationsHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request struct {
+		SealNumbers service.SealNumberList `json:"seal_numbers"`
+		Remark      string                 `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	sealNumbers, ok, err := expandSealNumbers(c, sc.sealService, request.SealNumbers...)
+	if !ok {
+		return err
+	}
+	if ok, err := sc.requireSealsInScope(c, sealNumbers...); !ok {
+		return err
+	}
+
+	seals, err := sc.sealService.ReleaseSealReservations(sealNumbers, actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":     fmt.Sprintf("ปล่อยการจองซีล %d รายการเรียบร้อย", len(seals)),
+		"seal_ranges": sc.sealService.CompressSealNumbers(sealNumbersOf(seals)),
+		"seals":       seals,
+	})
+}
+
+func (sc *SealController) GetSealReservationsHandler(c *fiber.Ctx) error {
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	techID := c.QueryInt("technician_id", 0)
+	if techID < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid technician_id"})
+	}
+	seals, err := sc.sealService.GetSealReservations(uint(techID), scope)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch reservations"})
+	}
+	return c.JSON(seals)
+}
+
+// -------------------------------------------------------------------
+// 28) Stocktakes: ตรวจนับซีลในคลัง (เปิดรอบ -> สแกน -> ปิดรอบ -> รายงานผลต่าง -> ปรับสถานะ)
+// POST /api/seals/stocktakes
+// Body: { "office_code": "E12345", "remark": "..." } (ไม่ระบุ office_code = สำนักงานของผู้ใช้)
+// GET  /api/seals/stocktakes?status=open|closed
+// GET  /api/seals/stocktakes/:id
+// POST /api/seals/stocktakes/:id/scans
+// Body: { "seal_numbers": ["F0001001", "F0001002", "F0001101-F0001200"] } (รายการที่ผิดรูปแบบถูกข้ามและแจ้งใน invalid)
+// PUT  /api/seals/stocktakes/:id/close
+// GET  /api/seals/stocktakes/:id/variance
+// POST /api/seals/stocktakes/:id/adjustments (admin)
+// Body: { "seal_numbers": "F0001001-F0001005", "remark": "..." } (ไม่ระบุ seal_numbers = ทุกรายการที่ปรับได้)
+// -------------------------------------------------------------------
+func (sc *SealController) OpenStocktakeHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request struct {
+		OfficeCode string `json:"office_code"`
+		Remark     string `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	office := strings.TrimSpace(request.OfficeCode)
+	if office == "" {
+		office = actor.Office
+	}
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	if err := service.CheckOfficeInScope(scope, office); err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+
+	stocktake, err := sc.sealService.OpenStocktake(office, actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":   fmt.Sprintf("เปิดรอบตรวจนับ #%d ของสำนักงาน %s แล้ว", stocktake.ID, stocktake.OfficeCode),
+		"stocktake": stocktake,
+	})
+}
+
+func (sc *SealController) GetStocktakesHandler(c *fiber.Ctx) error {
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	stocktakes, err := sc.sealService.GetStocktakes(model.StocktakeStatus(c.Query("status")), scope)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch stocktakes"})
+	}
+	return c.JSON(stocktakes)
+}
+
+func (sc *SealController) GetStocktakeHandler(c *fiber.Ctx) error {
+	stocktakeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid stocktake ID"})
+	}
+	if ok, err := sc.requireStocktakeInScope(c, uint(stocktakeID)); !ok {
+		return err
+	}
+	stocktake, err := sc.sealService.GetStocktake(uint(stocktakeID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(stocktake)
+}
+
+func (sc *SealController) ScanStocktakeSealsHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	stocktakeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid stocktake ID"})
+	}
+
+	var request struct {
+		SealNumbers service.SealNumberList `json:"seal_numbers"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if ok, err := sc.requireStocktakeInScope(c, uint(stocktakeID)); !ok {
+		return err
+	}
+
+	result, err := sc.sealService.ScanStocktakeSeals(uint(stocktakeID), request.SealNumbers, actor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(result)
+}
+
+func (sc *SealController) CloseStocktakeHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	stocktakeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid stocktake ID"})
+	}
+	if ok, err := sc.requireStocktakeInScope(c, uint(stocktakeID)); !ok {
+		return err
+	}
+
+	report, err := sc.sealService.CloseStocktake(uint(stocktakeID), actor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(report)
+}
+
+func (sc *SealController) GetStocktakeVarianceHandler(c *fiber.Ctx) error {
+	stocktakeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid stocktake ID"})
+	}
+	if ok, err := sc.requireStocktakeInScope(c, uint(stocktakeID)); !ok {
+		return err
+	}
+
+	report, err := sc.sealService.GetStocktakeReport(uint(stocktakeID))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(report)
+}
+
+func (sc *SealController) PostStocktakeAdjustmentsHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if !actor.IsAdmin() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+	stocktakeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid stocktake ID"})
+	}
+
+	var request struct {
+		SealNumbers service.SealNumberList `json:"seal_numbers"`
+		Remark      string                 `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	var sealNumbers []string
+	if strings.TrimSpace(strings.Join(request.SealNumbers, "")) != "" {
+		expanded, ok, err := expandSealNumbers(c, sc.sealService, request.SealNumbers...)
+		if !ok {
+			return err
+		}
+		sealNumbers = expanded
+	}
+
+	adjusted, err := sc.sealService.PostStocktakeAdjustments(uint(stocktakeID), sealNumbers, actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":  fmt.Sprintf("ปรับสถานะซีลตามผลตรวจนับ %d รายการเรียบร้อย", len(adjusted)),
+		"adjusted": adjusted,
+	})
+}
+
+// -------------------------------------------------------------------
+// 29) Seal history & reversals: ย้อนกลับรายการล่าสุดที่บันทึกผิด (admin ขอ -> admin อีกคนอนุมัติ)
+// GET  /api/seals/:seal_number/history
+// POST /api/seals/:seal_number/reversals
+// Body: { "reason": "จ่ายผิดช่วงเลข" }
+// GET  /api/seals/reversals?status=pending|approved|rejected|all
+// PUT  /api/seals/reversals/:id/approve
+// PUT  /api/seals/reversals/:id/reject
+// Body: { "remark": "..." }
+// -------------------------------------------------------------------
+func (sc *SealController) GetSealHistoryHandler(c *fiber.Ctx) error {
+	sealNumber := c.Params("seal_number")
+	if ok, err := sc.requireSealsInScope(c, sealNumber); !ok {
+		return err
+	}
+	history, err := sc.sealService.GetSealHistory(sealNumber)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(history)
+}
+
+func (sc *SealController) RequestSealReversalHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if !actor.IsAdmin() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	reversal, err := sc.sealService.RequestSealReversal(c.Params("seal_number"), request.Reason, actor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "ส่งคำขอย้อนกลับแล้ว รอ admin อีกคนอนุมัติ",
+		"reversal": reversal,
+	})
+}
+
+func (sc *SealController) GetSealReversalsHandler(c *fiber.Ctx) error {
+	if role, _ := c.Locals("role").(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+	status := model.SealReversalStatus(c.Query("status", string(model.SealReversalPending)))
+	if status == "all" {
+		status = ""
+	}
+	reversals, err := sc.sealService.GetSealReversals(status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch reversals"})
+	}
+	return c.JSON(reversals)
+}
+
+func (sc *SealController) ApproveSealReversalHandler(c *fiber.Ctx) error {
+	return sc.reviewSealReversal(c, sc.sealService.ApproveSealReversal, "ย้อนกลับรายการเรียบร้อย")
+}
+
+func (sc *SealController) RejectSealReversalHandler(c *fiber.Ctx) error {
+	return sc.reviewSealReversal(c, sc.sealService.RejectSealReversal, "ไม่อนุมัติคำขอย้อนกลับแล้ว")
+}
+
+func (sc *SealController) reviewSealReversal(
+	c *fiber.Ctx,
+	review func(reversalID uint, actor service.Actor, remark string) (*model.SealReversal, error),
+	message string,
+) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if !actor.IsAdmin() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+	reversalID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid reversal ID"})
+	}
+
+	var request struct {
+		Remark string `json:"remark"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	reversal, err := review(uint(reversalID), actor, request.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":  message,
+		"reversal": reversal,
+	})
+}
+
+// -------------------------------------------------------------------
+// 30) Range analysis: ตรวจช่วงเลขก่อนสั่งซื้อ/สร้างซีล (ซีลที่มีแล้วแยกตามสถานะ เลขที่ว่าง ช่วงที่ขาด ล็อตที่ทับ)
+// GET /api/seals/range-analysis?range=F0001001-F0001500
+// GET /api/seals/range-analysis?prefix=F&from=0001001&to=0001500
+//
+// (ไม่จำกัดตามสำนักงานเช่นเดียวกับข้อ 12 เพราะเลขซีลต้องไม่ซ้ำทั้งระบบ)
+// -------------------------------------------------------------------
+func (sc *SealController) AnalyzeSealRangeHandler(c *fiber.Ctx) error {
+	expression := c.Query("range")
+	if expression == "" {
+		prefix, from, to := c.Query("prefix"), c.Query("from"), c.Query("to")
+		if from == "" || to == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "range or prefix, from and to are required"})
+		}
+		expression = prefix + from + "-" + prefix + to
+	}
+
+	analysis, err := sc.sealService.AnalyzeSealRange(expression)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSealNumber) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(analysis)
+}
+
+// -------------------------------------------------------------------
+// 31) Ledger check: เล่นซ้ำสมุดบัญชี transactions เทียบกับแถวซีล (admin)
+// POST /api/seals/ledger-check
+// Body: { "seal_numbers": "F0001001-F0001500" (ว่าง = ทุกเส้น), "repair": false }
+//
+// repair=true ปรับแถวซีลที่ไม่ตรงให้ตรงกับสมุดบัญชี (บันทึกเป็นรายการ reconcile)
+// CLI: go run ./cmd/server ledger-check [-repair] [-seals "..."]
+// -------------------------------------------------------------------
+func (sc *SealController) CheckSealLedgerHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if !actor.IsAdmin() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+
+	var request struct {
+		SealNumbers service.SealNumberList `json:"seal_numbers"`
+		Repair      bool                   `json:"repair"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	var sealNumbers []string
+	if strings.TrimSpace(strings.Join(request.SealNumbers, "")) != "" {
+		expanded, ok, err := expandSealNumbers(c, sc.sealService, request.SealNumbers...)
+		if !ok {
+			return err
+		}
+		sealNumbers = expanded
+	}
+
+	report, err := sc.sealService.CheckSealLedger(sealNumbers, request.Repair, actor)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(report)
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SealLotController struct {
+	sealLotService *service.SealLotService
+}
+
+func NewSealLotController(sealLotService *service.SealLotService) *SealLotController {
+	return &SealLotController{sealLotService: sealLotService}
+}
+
+// ✅ รายการล็อตซีลพร้อมสถิติ (?supplier=...&lot_number=...) เรียงตามอัตราชำรุด
+// GET /api/seal-lots
+func (lc *SealLotController) GetSealLotsHandler(c *fiber.Ctx) error {
+	lots, err := lc.sealLotService.GetSealLots(c.Query("supplier"), c.Query("lot_number"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch seal lots"})
+	}
+	return c.JSON(lots)
+}
+
+// ✅ สถิติของล็อตเดียว
+// GET /api/seal-lots/:id
+func (lc *SealLotController) GetSealLotHandler(c *fiber.Ctx) error {
+	lotID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid lot ID"})
+	}
+	lot, err := lc.sealLotService.GetSealLot(uint(lotID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(lot)
+}
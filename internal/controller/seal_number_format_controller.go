@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SealNumberFormatController struct {
+	formatService *service.SealNumberFormatService
+}
+
+func NewSealNumberFormatController(formatService *service.SealNumberFormatService) *SealNumberFormatController {
+	return &SealNumberFormatController{formatService: formatService}
+}
+
+// sealNumberFormatRequest ค่าที่รับจาก client (active ไม่ระบุ = เปิดใช้)
+type sealNumberFormatRequest struct {
+	Name       string               `json:"name"`
+	Prefix     string               `json:"prefix"`
+	Separator  string               `json:"separator"`
+	YearDigits int                  `json:"year_digits"`
+	Width      int                  `json:"width"`
+	CheckDigit model.SealCheckDigit `json:"check_digit"`
+	Suffix     string               `json:"suffix"`
+	Active     *bool                `json:"active"`
+}
+
+func (r sealNumberFormatRequest) toModel() model.SealNumberFormat {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return model.SealNumberFormat{
+		Name:       r.Name,
+		Prefix:     r.Prefix,
+		Separator:  r.Separator,
+		YearDigits: r.YearDigits,
+		Width:      r.Width,
+		CheckDigit: r.CheckDigit,
+		Suffix:     r.Suffix,
+		Active:     active,
+	}
+}
+
+// ✅ รูปแบบเลขซีลทั้งหมด
+// GET /api/seal-formats
+func (fc *SealNumberFormatController) GetFormatsHandler(c *fiber.Ctx) error {
+	formats, err := fc.formatService.GetFormats()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch seal number formats"})
+	}
+	return c.JSON(formats)
+}
+
+// ✅ ลงทะเบียนรูปแบบเลขซีลใหม่ (admin)
+// POST /api/seal-formats
+// Body: { "name": "PEA-2025", "prefix": "PEA", "separator": "-", "year_digits": 2, "width": 7, "check_digit": "mod10" }
+func (fc *SealNumberFormatController) CreateFormatHandler(c *fiber.Ctx) error {
+	return fc.saveFormat(c, 0)
+}
+
+// ✅ แก้ไขรูปแบบเลขซีล (admin)
+// PUT /api/seal-formats/:id
+func (fc *SealNumberFormatController) UpdateFormatHandler(c *fiber.Ctx) error {
+	formatID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid format ID"})
+	}
+	return fc.saveFormat(c, uint(formatID))
+}
+
+func (fc *SealNumberFormatController) saveFormat(c *fiber.Ctx, formatID uint) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request sealNumberFormatRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	format := request.toModel()
+	format.ID = formatID
+	if err := fc.formatService.SaveFormat(&format, actor); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message": "บันทึกรูปแบบเลขซีลเรียบร้อย",
+		"format":  format,
+	})
+}
+
+// ✅ ลบรูปแบบเลขซีล (admin)
+// DELETE /api/seal-formats/:id
+func (fc *SealNumberFormatController) DeleteFormatHandler(c *fiber.Ctx) error {
+	formatID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid format ID"})
+	}
+	if err := fc.formatService.DeleteFormat(uint(formatID)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "ลบรูปแบบเลขซีลเรียบร้อย"})
+}
+
+// ✅ ตรวจเลขซีลตามรูปแบบที่เปิดใช้ รายเส้น (ใช้ตรวจก่อนบันทึก/หลังสแกน)
+// POST /api/seal-formats/validate
+// Body: { "seal_numbers": ["PEA-25-00000175"] }
+func (fc *SealNumberFormatController) ValidateSealNumbersHandler(c *fiber.Ctx) error {
+	var request struct {
+		SealNumbers []string `json:"seal_numbers"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if len(request.SealNumbers) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "seal_numbers is required"})
+	}
+	checks, err := fc.formatService.CheckSealNumbers(request.SealNumbers)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(checks)
+}
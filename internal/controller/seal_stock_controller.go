@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SealStockController struct {
+	stockService  *service.SealStockService
+	officeService *service.OfficeService
+}
+
+func NewSealStockController(stockService *service.SealStockService, officeService *service.OfficeService) *SealStockController {
+	return &SealStockController{stockService: stockService, officeService: officeService}
+}
+
+// ✅ ขั้นต่ำซีลพร้อมใช้งานของสำนักงานในขอบเขต
+// GET /api/seal-stock/thresholds
+func (sc *SealStockController) GetThresholdsHandler(c *fiber.Ctx) error {
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	thresholds, err := sc.stockService.GetThresholds(scope)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch thresholds"})
+	}
+	return c.JSON(thresholds)
+}
+
+// ✅ ตั้งขั้นต่ำของ (สำนักงาน, prefix) (admin)
+// PUT /api/seal-stock/thresholds
+// Body: { "office_code": "E12345", "prefix": "F", "min_available": 200 }
+func (sc *SealStockController) SaveThresholdHandler(c *fiber.Ctx) error {
+	actor, ok := actorFromContext(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	if !actor.IsAdmin() {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+
+	var request struct {
+		OfficeCode   string `json:"office_code"`
+		Prefix       string `json:"prefix"`
+		MinAvailable int    `json:"min_available"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	threshold, err := sc.stockService.SaveThreshold(request.OfficeCode, request.Prefix, request.MinAvailable, actor)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":   "บันทึกขั้นต่ำเรียบร้อย",
+		"threshold": threshold,
+	})
+}
+
+// ✅ ลบขั้นต่ำ (admin)
+// DELETE /api/seal-stock/thresholds/:id
+func (sc *SealStockController) DeleteThresholdHandler(c *fiber.Ctx) error {
+	if role, _ := c.Locals("role").(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid threshold ID"})
+	}
+	if err := sc.stockService.DeleteThreshold(uint(id)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "ลบขั้นต่ำเรียบร้อย"})
+}
+
+// ✅ สำนักงานที่ซีลพร้อมใช้งานต่ำกว่าขั้นต่ำในขณะนี้
+// GET /api/seal-stock/low
+func (sc *SealStockController) GetLowStockHandler(c *fiber.Ctx) error {
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	low, err := sc.stockService.GetLowStock(scope)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to evaluate stock"})
+	}
+	return c.JSON(low)
+}
+
+// ✅ ประวัติการแจ้งเตือนซีลใกล้หมด (?status=open|resolved|all)
+// GET /api/seal-stock/alerts
+func (sc *SealStockController) GetAlertsHandler(c *fiber.Ctx) error {
+	scope, err := officeScopeFromContext(c, sc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	status := model.SealStockAlertStatus(c.Query("status", string(model.SealStockAlertOpen)))
+	if status == "all" {
+		status = ""
+	}
+	alerts, err := sc.stockService.GetAlerts(status, scope)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch alerts"})
+	}
+	return c.JSON(alerts)
+}
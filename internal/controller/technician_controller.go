@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
@@ -17,13 +18,22 @@ import (
 type TechnicianController struct {
 	technicianService *service.TechnicianService
 	sealService       *service.SealService
+	workOrderService  *service.WorkOrderService
+	approvalService   *service.ApprovalService
 }
 
 // NewTechnicianController สร้าง instance ของ TechnicianController
-func NewTechnicianController(technicianService *service.TechnicianService, sealService *service.SealService) *TechnicianController {
+func NewTechnicianController(
+	technicianService *service.TechnicianService,
+	sealService *service.SealService,
+	workOrderService *service.WorkOrderService,
+	approvalService *service.ApprovalService,
+) *TechnicianController {
 	return &TechnicianController{
 		technicianService: technicianService,
 		sealService:       sealService,
+		workOrderService:  workOrderService,
+		approvalService:   approvalService,
 	}
 }
 
@@ -107,6 +117,27 @@ func (tc *TechnicianController) GetAssignedSealsHandler(c *fiber.Ctx) error {
 	return c.JSON(seals)
 }
 
+// ✅ Technician ดู action ถัดไปที่ทำกับซีลได้ (ใช้ตาราง transition เดียวกับฝั่ง PEA)
+func (tc *TechnicianController) GetSealTransitionsHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	sealNumber := c.Params("seal_number")
+	// ช่างไม่ถูกจำกัดตามสำนักงาน สิทธิ์ตามผู้ถือซีลตรวจในตาราง transition
+	seal, transitions, err := tc.sealService.GetSealTransitions(sealNumber, service.TechnicianActor(techID), service.OfficeScope{All: true})
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"seal_number":  seal.SealNumber,
+		"status":       seal.Status,
+		"status_label": seal.StatusLabel,
+		"transitions":  transitions,
+	})
+}
+
 func (tc *TechnicianController) InstallSealHandler(c *fiber.Ctx) error {
 	techID, ok := c.Locals("tech_id").(uint)
 	if !ok {
@@ -117,6 +148,7 @@ func (tc *TechnicianController) InstallSealHandler(c *fiber.Ctx) error {
 	var req struct {
 		SealNumber   string `json:"seal_number"`
 		SerialNumber string `json:"serial_number,omitempty"`
+		JobNumber    string `json:"job_number,omitempty"` // ใบงานที่ใช้ซีล (ถ้าไม่ระบุใช้ใบงานตอนมอบหมาย)
 	}
 	if err := c.BodyParser(&req); err != nil {
 		log.Println("❌ [ERROR] Failed to parse request body:", err)
@@ -129,7 +161,11 @@ func (tc *TechnicianController) InstallSealHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Seal number is required"})
 	}
 
-	err := tc.technicianService.InstallSeal(req.SealNumber, techID, req.SerialNumber)
+	if ok, err := requireValidSealNumbers(c, tc.sealService, req.SealNumber); !ok {
+		return err
+	}
+	// ติดตั้งผ่าน SealService เพื่อให้ผูกซีลเข้ากับทะเบียนมิเตอร์ด้วย
+	err := tc.sealService.InstallSeal(req.SealNumber, techID, req.SerialNumber, req.JobNumber)
 	if err != nil {
 		log.Println("❌ [ERROR] Install Seal Error:", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
@@ -142,6 +178,16 @@ func (tc *TechnicianController) InstallSealHandler(c *fiber.Ctx) error {
 	})
 }
 
+// ✅ Technician ถอดซีลเดิมและติดตั้งซีลใหม่ (เปลี่ยนมิเตอร์ / เปิดซ่อม)
+// POST /api/technician/seals/replace
+func (tc *TechnicianController) ReplaceSealHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	return replaceSeal(c, tc.sealService, service.TechnicianActor(techID))
+}
+
 // ✅ Technician คืนซีลที่ติดตั้งแล้ว
 func (tc *TechnicianController) ReturnSealHandler(c *fiber.Ctx) error {
 	techID, ok := c.Locals("tech_id").(uint)
@@ -158,6 +204,9 @@ func (tc *TechnicianController) ReturnSealHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
+	if ok, err := requireValidSealNumbers(c, tc.sealService, sealNumber); !ok {
+		return err
+	}
 	err := tc.technicianService.ReturnSeal(sealNumber, techID, req.Remarks)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
@@ -169,6 +218,215 @@ func (tc *TechnicianController) ReturnSealHandler(c *fiber.Ctx) error {
 		"remarks":     req.Remarks,
 	})
 }
+
+// ✅ Technician แจ้งซีลสูญหาย/ชำรุด พร้อมสาเหตุและรูปหลักฐาน (multipart/form-data)
+// POST /api/technician/seals/report
+// Form: seal_number, type (lost|damaged), reason, image
+func (tc *TechnicianController) ReportSealIncidentHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	sealNumber := c.FormValue("seal_number")
+	if sealNumber == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Seal number is required"})
+	}
+
+	if ok, err := requireValidSealNumbers(c, tc.sealService, sealNumber); !ok {
+		return err
+	}
+	var imageURL string
+	if file, err := c.FormFile("image"); err == nil && file.Size > 0 {
+		imageURL, err = uploads.SaveImage(file)
+		if err != nil {
+			log.Println("❌ [ERROR] Failed to save incident image:", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save image"})
+		}
+	}
+
+	incident, err := tc.sealService.ReportSealIncident(
+		sealNumber,
+		service.TechnicianActor(techID),
+		model.SealIncidentType(c.FormValue("type")),
+		c.FormValue("reason"),
+		imageURL,
+	)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "แจ้งเรียบร้อย รอผู้ดูแลยืนยัน",
+		"incident": incident,
+	})
+}
+
+// ✅ Technician โอนซีลที่ตัวเองถืออยู่ให้ช่างคนอื่น (ผู้รับต้องกดยอมรับ)
+// POST /api/technician/seals/transfers
+// Body: { "seal_numbers": ["F0001001"], "to_technician_code": "T002", "remark": "..." }
+func (tc *TechnicianController) InitiateSealTransferHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	return initiateSealTransfer(c, tc.sealService, service.TechnicianActor(techID))
+}
+
+// ✅ Technician ดูรายการโอน (direction=incoming ที่ส่งถึงตัวเอง / outgoing ที่ตัวเองส่ง)
+// GET /api/technician/seals/transfers?direction=incoming&status=pending
+func (tc *TechnicianController) GetSealTransfersHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	incoming := c.Query("direction", "incoming") != "outgoing"
+	status := model.SealTransferStatus(c.Query("status", string(model.SealTransferPending)))
+	if status == "all" {
+		status = ""
+	}
+	transfers, err := tc.sealService.GetTechnicianSealTransfers(techID, incoming, status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch transfers"})
+	}
+	return c.JSON(transfers)
+}
+
+// ✅ Technician ผู้รับยอมรับ / ปฏิเสธรายการโอน
+// PUT /api/technician/seals/transfers/accept
+// PUT /api/technician/seals/transfers/reject
+// Body: { "transfer_ids": [1, 2], "remark": "..." }
+func (tc *TechnicianController) AcceptSealTransfersHandler(c *fiber.Ctx) error {
+	return tc.respondSealTransfers(c, tc.sealService.AcceptSealTransfers, "รับโอนซีลเรียบร้อย")
+}
+
+func (tc *TechnicianController) RejectSealTransfersHandler(c *fiber.Ctx) error {
+	return tc.respondSealTransfers(c, tc.sealService.RejectSealTransfers, "ปฏิเสธการรับโอนเรียบร้อย")
+}
+
+func (tc *TechnicianController) respondSealTransfers(
+	c *fiber.Ctx,
+	respond func(transferIDs []uint, actor service.Actor, remark string) ([]model.SealTransfer, error),
+	message string,
+) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req struct {
+		TransferIDs []uint `json:"transfer_ids"`
+		Remark      string `json:"remark"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	transfers, err := respond(req.TransferIDs, service.TechnicianActor(techID), req.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":   message,
+		"transfers": transfers,
+	})
+}
+
+// ✅ Technician ผู้ส่งยกเลิกรายการโอนที่ยังไม่มีการตอบรับ
+// PUT /api/technician/seals/transfers/:id/cancel
+func (tc *TechnicianController) CancelSealTransferHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	return cancelSealTransfer(c, tc.sealService, service.TechnicianActor(techID))
+}
+
+// ✅ Technician ขอคืนซีลที่ยังไม่ได้ใช้กลับเข้าคลัง (รอเจ้าหน้าที่คลังตรวจรับ)
+// POST /api/technician/seals/store-returns
+// Body: { "seal_numbers": "F0001001-F0001010", "remark": "..." }
+func (tc *TechnicianController) RequestSealStoreReturnHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req struct {
+		SealNumbers service.SealNumberList `json:"seal_numbers"`
+		Remark      string                 `json:"remark"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	sealNumbers, ok, err := expandSealNumbers(c, tc.sealService, req.SealNumbers...)
+	if !ok {
+		return err
+	}
+	storeReturn, err := tc.sealService.RequestSealStoreReturn(sealNumbers, techID, req.Remark)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":      "ส่งคำขอคืนซีลแล้ว กรุณานำซีลไปส่งที่คลัง",
+		"seal_ranges":  tc.sealService.CompressSealNumbers(sealNumbers),
+		"store_return": storeReturn,
+	})
+}
+
+// ✅ Technician ดูคำขอคืนซีลของตัวเอง
+// GET /api/technician/seals/store-returns?status=pending
+func (tc *TechnicianController) GetSealStoreReturnsHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	status := model.SealStoreReturnStatus(c.Query("status"))
+	returns, err := tc.sealService.GetTechnicianSealStoreReturns(techID, status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch store returns"})
+	}
+	return c.JSON(returns)
+}
+
+// ✅ Technician ยกเลิกคำขอคืนที่คลังยังไม่ตรวจรับ
+// PUT /api/technician/seals/store-returns/:id/cancel
+func (tc *TechnicianController) CancelSealStoreReturnHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	returnID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid return ID"})
+	}
+
+	storeReturn, err := tc.sealService.CancelSealStoreReturn(uint(returnID), techID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"message":      "ยกเลิกคำขอคืนเรียบร้อย",
+		"store_return": storeReturn,
+	})
+}
+
+// ✅ Technician ดูใบงานที่ได้รับมอบหมาย
+// GET /api/technician/work-orders
+func (tc *TechnicianController) GetWorkOrdersHandler(c *fiber.Ctx) error {
+	techID, ok := c.Locals("tech_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	workOrders, err := tc.workOrderService.GetWorkOrders(techID, model.WorkOrderType(c.Query("job_type")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch work orders"})
+	}
+	return c.JSON(workOrders)
+}
+
 func (tc *TechnicianController) UpdateTechnicianHandler(c *fiber.Ctx) error {
 	techIDStr := c.Params("id")
 	techID, err := strconv.Atoi(techIDStr)
@@ -251,6 +509,16 @@ func (tc *TechnicianController) DeleteTechnicianHandler(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid technician ID"})
 	}
+	if role, _ := c.Locals("role").(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied, admin only"})
+	}
+
+	// ✅ ลบช่างต้องผ่านการอนุมัติ (ถ้าตั้งค่าไว้)
+	request := service.DeleteTechnicianRequest{TechnicianID: uint(techID)}
+	if proceed, err := submitForApproval(c, tc.approvalService, service.ApprovalOpDeleteTechnician, request, 1,
+		fmt.Sprintf("ลบข้อมูลช่าง ID %d", techID)); !proceed {
+		return err
+	}
 
 	err = tc.technicianService.DeleteTechnician(uint(techID))
 	if err != nil {
@@ -320,6 +588,9 @@ func (tc *TechnicianController) UploadSealImagesHandler(c *fiber.Ctx) error {
 	err := tc.technicianService.UploadSealImages(sealNumber, techID, imageURL1, imageURL2)
 	if err != nil {
 		log.Println("❌ [ERROR] UploadSealImages Error:", err)
+		if errors.Is(err, service.ErrSealConcurrentUpdate) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -1,38 +1,136 @@
 package controller
 
 import (
-	"github.com/Kev2406/PEA/internal/domain/model"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/repository"
 	"github.com/Kev2406/PEA/internal/service"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 type TransactionController struct {
 	transactionService *service.TransactionService
+	officeService      *service.OfficeService
 }
 
-func NewTransactionController(transactionService *service.TransactionService) *TransactionController {
-	return &TransactionController{transactionService: transactionService}
+func NewTransactionController(transactionService *service.TransactionService, officeService *service.OfficeService) *TransactionController {
+	return &TransactionController{transactionService: transactionService, officeService: officeService}
 }
 
-func (tc *TransactionController) CreateTransactionHandler(c *fiber.Ctx) error {
-	var transaction model.Transaction
-	if err := c.BodyParser(&transaction); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+// parseTransactionDate รับ "2006-01-02" หรือ RFC3339 ถ้าเป็นวันที่อย่างเดียวและ endOfDay จะนับรวมทั้งวัน
+func parseTransactionDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, errors.New("รูปแบบวันที่ไม่ถูกต้อง ใช้ YYYY-MM-DD หรือ RFC3339")
 	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
 
-	err := tc.transactionService.CreateTransaction(&transaction)
+// ✅ GET /api/transactions?seal_number=&actor_id=&actor_type=&action=&from=&to=&limit=&offset=
+func (tc *TransactionController) GetTransactionsHandler(c *fiber.Ctx) error {
+	filter := repository.TransactionFilter{
+		SealNumber: c.Query("seal_number"),
+		ActorType:  c.Query("actor_type"),
+		Action:     c.Query("action"),
+		Limit:      c.QueryInt("limit", 100),
+		Offset:     c.QueryInt("offset", 0),
+	}
+	if value := c.Query("actor_id"); value != "" {
+		actorID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "actor_id ไม่ถูกต้อง"})
+		}
+		id := uint(actorID)
+		filter.ActorID = &id
+	}
+	var err error
+	if filter.From, err = parseTransactionDate(c.Query("from"), false); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.To, err = parseTransactionDate(c.Query("to"), true); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	scope, err := officeScopeFromContext(c, tc.officeService)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create transaction"})
+		return officeScopeErrorResponse(c, err)
+	}
+	transactions, total, err := tc.transactionService.FindTransactions(filter, scope)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch transactions"})
 	}
 
-	return c.JSON(transaction)
+	return c.JSON(fiber.Map{
+		"transactions": transactions,
+		"total":        total,
+	})
 }
 
-func (tc *TransactionController) GetAllTransactionsHandler(c *fiber.Ctx) error {
-	transactions, err := tc.transactionService.GetAllTransactions()
+// ✅ GET /api/transactions/seal/:seal_number ประวัติของซีลหนึ่งเส้นตามลำดับเวลา
+func (tc *TransactionController) GetSealTransactionsHandler(c *fiber.Ctx) error {
+	scope, err := officeScopeFromContext(c, tc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	transactions, total, err := tc.transactionService.FindTransactions(repository.TransactionFilter{
+		SealNumber: c.Params("seal_number"),
+		Limit:      c.QueryInt("limit", 100),
+		Offset:     c.QueryInt("offset", 0),
+	}, scope)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch transactions"})
 	}
 
-	return c.JSON(transactions)
+	return c.JSON(fiber.Map{
+		"seal_number":  c.Params("seal_number"),
+		"transactions": transactions,
+		"total":        total,
+	})
+}
+
+// ✅ GET /api/transactions/:id
+func (tc *TransactionController) GetTransactionByIDHandler(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction ID"})
+	}
+	transaction, err := tc.transactionService.GetTransactionByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Transaction not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch transaction"})
+	}
+
+	// ✅ ไม่ใช่ admin เห็นเฉพาะซีลในสำนักงานของตัวเอง
+	scope, err := officeScopeFromContext(c, tc.officeService)
+	if err != nil {
+		return officeScopeErrorResponse(c, err)
+	}
+	if !scope.All {
+		_, total, err := tc.transactionService.FindTransactions(repository.TransactionFilter{
+			SealNumber: transaction.SealNumber,
+			Limit:      1,
+		}, scope)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch transaction"})
+		}
+		if total == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Transaction not found"})
+		}
+	}
+
+	return c.JSON(transaction)
 }
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WorkOrderController struct {
+	workOrderService *service.WorkOrderService
+}
+
+func NewWorkOrderController(workOrderService *service.WorkOrderService) *WorkOrderController {
+	return &WorkOrderController{workOrderService: workOrderService}
+}
+
+// workOrderRequest body สำหรับสร้าง/แก้ไขใบงาน (due_date รูปแบบ YYYY-MM-DD)
+type workOrderRequest struct {
+	JobNumber            string `json:"job_number"`
+	JobType              string `json:"job_type"`
+	CustomerAccount      string `json:"customer_account"`
+	Address              string `json:"address"`
+	AssignedTechnicianID *uint  `json:"assigned_technician_id"`
+	DueDate              string `json:"due_date"`
+}
+
+func (r workOrderRequest) toModel() (*model.WorkOrder, error) {
+	workOrder := &model.WorkOrder{
+		JobNumber:            r.JobNumber,
+		JobType:              model.WorkOrderType(r.JobType),
+		CustomerAccount:      r.CustomerAccount,
+		Address:              r.Address,
+		AssignedTechnicianID: r.AssignedTechnicianID,
+	}
+	if r.DueDate != "" {
+		dueDate, err := time.Parse("2006-01-02", r.DueDate)
+		if err != nil {
+			return nil, err
+		}
+		workOrder.DueDate = &dueDate
+	}
+	return workOrder, nil
+}
+
+// ✅ สร้างใบงาน
+// POST /api/work-orders
+// Body: { "job_number": "WO-6701-0001", "job_type": "meter_change", "customer_account": "...", "address": "...", "assigned_technician_id": 3, "due_date": "2025-03-31" }
+func (wc *WorkOrderController) CreateWorkOrderHandler(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request workOrderRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	input, err := request.toModel()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid due_date format, use YYYY-MM-DD"})
+	}
+
+	workOrder, err := wc.workOrderService.CreateWorkOrder(input, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "Work order created successfully", "work_order": workOrder})
+}
+
+// ✅ แก้ไขใบงาน
+// PUT /api/work-orders/:job_number
+func (wc *WorkOrderController) UpdateWorkOrderHandler(c *fiber.Ctx) error {
+	var request workOrderRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	input, err := request.toModel()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid due_date format, use YYYY-MM-DD"})
+	}
+
+	workOrder, err := wc.workOrderService.UpdateWorkOrder(c.Params("job_number"), input)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "Work order updated successfully", "work_order": workOrder})
+}
+
+// ✅ รายการใบงาน (?technician_id=3&job_type=meter_change)
+// GET /api/work-orders
+func (wc *WorkOrderController) GetWorkOrdersHandler(c *fiber.Ctx) error {
+	technicianID, _ := strconv.Atoi(c.Query("technician_id"))
+	workOrders, err := wc.workOrderService.GetWorkOrders(uint(technicianID), model.WorkOrderType(c.Query("job_type")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch work orders"})
+	}
+	return c.JSON(workOrders)
+}
+
+// ✅ ดูใบงาน
+// GET /api/work-orders/:job_number
+func (wc *WorkOrderController) GetWorkOrderHandler(c *fiber.Ctx) error {
+	workOrder, err := wc.workOrderService.GetWorkOrder(c.Params("job_number"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(workOrder)
+}
+
+// ✅ ซีลที่ใช้ไปกับใบงาน
+// GET /api/work-orders/:job_number/seals
+func (wc *WorkOrderController) GetWorkOrderSealsHandler(c *fiber.Ctx) error {
+	seals, err := wc.workOrderService.GetWorkOrderSeals(c.Params("job_number"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(seals)
+}
@@ -1,19 +1,21 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
-
-	"gorm.io/gorm"
 )
 
+// Transaction สมุดบัญชีการเคลื่อนไหวของซีล หนึ่งแถวต่อการเปลี่ยนแปลงหนึ่งครั้ง (เพิ่มอย่างเดียว ไม่แก้ไข/ลบ)
+// บันทึกใน transaction เดียวกับการบันทึกซีล Metadata เก็บรายละเอียดเพิ่มเติมของแต่ละ action (JSON)
 type Transaction struct {
-	ID     uint `gorm:"primaryKey" json:"id"`
-	SealID uint `gorm:"not null" json:"seal_id"`
-	UserID uint `gorm:"not null" json:"user_id"`
-	//IssuedTo  *uint          `json:"issued_to,omitempty"`    // ✅ เพิ่มฟิลด์นี้
-	Action    string         `gorm:"not null" json:"action"` // issued, used, returned
-	Timestamp time.Time      `gorm:"autoCreateTime" json:"timestamp"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	SealID     uint            `gorm:"not null;index" json:"seal_id"`
+	SealNumber string          `gorm:"not null;index" json:"seal_number"`
+	Action     string          `gorm:"not null;index" json:"action"` // create, issue, assign, install, return, cancel, ...
+	ActorID    uint            `gorm:"index" json:"actor_id"`
+	ActorType  string          `gorm:"size:20;index" json:"actor_type"`
+	FromStatus SealStatus      `json:"from_status"` // ว่าง = ซีลเพิ่งถูกสร้าง
+	ToStatus   SealStatus      `gorm:"not null" json:"to_status"`
+	Metadata   json.RawMessage `gorm:"type:jsonb" json:"metadata,omitempty"`
+	CreatedAt  time.Time       `gorm:"index" json:"created_at"`
 }
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ApprovalStatus สถานะคำขออนุมัติ (maker-checker)
+type ApprovalStatus string
+
+const (
+	ApprovalPending   ApprovalStatus = "pending"   // รอผู้อนุมัติ
+	ApprovalApproved  ApprovalStatus = "approved"  // อนุมัติแล้ว กำลังดำเนินการ
+	ApprovalExecuted  ApprovalStatus = "executed"  // อนุมัติและดำเนินการสำเร็จ
+	ApprovalFailed    ApprovalStatus = "failed"    // อนุมัติแล้วแต่ดำเนินการไม่สำเร็จ (ดู error)
+	ApprovalRejected  ApprovalStatus = "rejected"  // ไม่อนุมัติ ไม่มีการดำเนินการ
+	ApprovalCancelled ApprovalStatus = "cancelled" // ผู้ขอยกเลิกเอง
+)
+
+// ApprovalPolicy การตั้งค่าของแต่ละ operation (ไม่มีแถว = ใช้ค่าเริ่มต้น ไม่ต้องอนุมัติ)
+type ApprovalPolicy struct {
+	Operation    string    `gorm:"primaryKey" json:"operation"`
+	Enabled      bool      `gorm:"not null" json:"enabled"`
+	ApproverRole string    `gorm:"not null" json:"approver_role"`
+	MinQuantity  int       `gorm:"not null;default:0" json:"min_quantity"` // ต้องอนุมัติเมื่อจำนวนรายการ >= ค่านี้ (0 = ทุกครั้ง)
+	UpdatedBy    uint      `json:"updated_by"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ApprovalRequest คำขอดำเนินการที่ต้องได้รับอนุมัติ เก็บคำขอเต็มและผลการตัดสิน/ดำเนินการ
+type ApprovalRequest struct {
+	ID              uint            `gorm:"primaryKey" json:"id"`
+	Operation       string          `gorm:"not null;index" json:"operation"`
+	Summary         string          `json:"summary"`
+	Quantity        int             `json:"quantity"`
+	Payload         json.RawMessage `gorm:"type:jsonb" json:"payload"`
+	Status          ApprovalStatus  `gorm:"not null;default:'pending';index" json:"status"`
+	RequestedBy     uint            `gorm:"not null;index" json:"requested_by"`
+	RequesterRole   string          `json:"requester_role"`
+	RequesterOffice string          `json:"requester_office,omitempty"`
+	ReviewedBy      *uint           `json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time      `json:"reviewed_at,omitempty"`
+	ReviewRemark    string          `json:"review_remark,omitempty"`
+	ExecutedAt      *time.Time      `json:"executed_at,omitempty"`
+	Result          json.RawMessage `gorm:"type:jsonb" json:"result,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
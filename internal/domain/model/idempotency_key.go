@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// IdempotencyStatus สถานะของคำขอที่ส่งมาพร้อม Idempotency-Key
+type IdempotencyStatus string
+
+const (
+	IdempotencyProcessing IdempotencyStatus = "processing" // คำขอแรกกำลังทำงาน
+	IdempotencyCompleted  IdempotencyStatus = "completed"  // เก็บผลลัพธ์ไว้ตอบคำขอซ้ำแล้ว
+)
+
+// IdempotencyKey ผลลัพธ์ของคำขอที่เปลี่ยนข้อมูล เก็บไว้ตอบซ้ำเมื่อ client ส่งคำขอเดิมมาอีกครั้ง
+// Scope = ผู้เรียก (user:<id> / technician:<id>) + Key unique กันไม่ให้คนละผู้ใช้ชนกัน
+type IdempotencyKey struct {
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	Scope          string            `gorm:"size:100;not null;uniqueIndex:idx_idempotency_scope_key" json:"scope"`
+	Key            string            `gorm:"size:255;not null;uniqueIndex:idx_idempotency_scope_key" json:"key"`
+	Method         string            `gorm:"size:10;not null" json:"method"`
+	Path           string            `gorm:"not null" json:"path"`
+	RequestHash    string            `gorm:"size:64;not null" json:"request_hash"`
+	Status         IdempotencyStatus `gorm:"size:20;not null" json:"status"`
+	ResponseStatus int               `json:"response_status"`
+	ContentType    string            `gorm:"size:100" json:"content_type"`
+	ResponseBody   []byte            `json:"-"`
+	ExpiresAt      time.Time         `gorm:"index" json:"expires_at"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// JobRunStatus สถานะการทำงานของงานตามกำหนดเวลา
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed"
+)
+
+// JobRunTrigger ที่มาของการสั่งงาน
+type JobRunTrigger string
+
+const (
+	JobTriggerSchedule JobRunTrigger = "schedule"
+	JobTriggerManual   JobRunTrigger = "manual"
+)
+
+// JobRun ประวัติการทำงานหนึ่งครั้งของงานเบื้องหลัง
+// ScheduledFor + JobName unique กันไม่ให้หลาย replica ทำงานรอบเดียวกันซ้ำ (manual = NULL)
+type JobRun struct {
+	ID           uint          `gorm:"primaryKey" json:"id"`
+	JobName      string        `gorm:"size:100;not null;index;uniqueIndex:idx_job_run_schedule" json:"job_name"`
+	ScheduledFor *time.Time    `gorm:"uniqueIndex:idx_job_run_schedule" json:"scheduled_for,omitempty"`
+	Trigger      JobRunTrigger `gorm:"size:20;not null" json:"trigger"`
+	TriggeredBy  *uint         `json:"triggered_by,omitempty"`
+	Host         string        `gorm:"size:100" json:"host"`
+	Status       JobRunStatus  `gorm:"size:20;not null;index" json:"status"`
+	Affected     int           `json:"affected"`
+	Error        string        `json:"error,omitempty"`
+	StartedAt    time.Time     `json:"started_at"`
+	FinishedAt   *time.Time    `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Meter ทะเบียนมิเตอร์ที่ติดตั้งซีล (อ้างอิงด้วย serial number)
+type Meter struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	SerialNumber    string         `gorm:"uniqueIndex;not null" json:"serial_number"`
+	Manufacturer    string         `json:"manufacturer,omitempty"`
+	CustomerAccount string         `gorm:"index" json:"customer_account,omitempty"` // หมายเลขผู้ใช้ไฟ
+	Location        string         `json:"location,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// MeterSeal ประวัติการติดตั้งซีลบนมิเตอร์ (หนึ่งแถวต่อการติดตั้งหนึ่งครั้ง)
+type MeterSeal struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	MeterID       uint      `gorm:"not null;index" json:"meter_id"`
+	SealID        uint      `gorm:"not null;index" json:"seal_id"`
+	SealNumber    string    `gorm:"not null" json:"seal_number"`
+	InstalledBy   uint      `gorm:"not null" json:"installed_by"`
+	InstallerType string    `gorm:"not null" json:"installer_type"` // technician | user
+	InstalledAt   time.Time `gorm:"not null" json:"installed_at"`
+
+	// การถอดซีล (ตัดซีลออกตอนเปลี่ยนมิเตอร์/เปิดซ่อม)
+	RemovedAt     *time.Time `json:"removed_at,omitempty"`
+	RemovedBy     *uint      `json:"removed_by,omitempty"`
+	RemoverType   string     `json:"remover_type,omitempty"`
+	RemovalReason string     `json:"removal_reason,omitempty"`
+
+	// ลำดับซีลบนมิเตอร์: ซีลนี้ถูกแทนด้วยซีลไหน / ซีลนี้มาแทนซีลไหน
+	ReplacedBySealID     *uint  `json:"replaced_by_seal_id,omitempty"`
+	ReplacedBySealNumber string `json:"replaced_by_seal_number,omitempty"`
+	ReplacesSealID       *uint  `json:"replaces_seal_id,omitempty"`
+	ReplacesSealNumber   string `json:"replaces_seal_number,omitempty"`
+
+	// Flagged = ตอนติดตั้ง มิเตอร์ยังมีซีลอื่นที่บันทึกว่า 'ติดตั้งแล้ว' อยู่
+	Flagged    bool   `gorm:"not null;default:false;index" json:"flagged"`
+	FlagReason string `json:"flag_reason,omitempty"`
+
+	Seal  *Seal  `gorm:"foreignKey:SealID" json:"seal,omitempty"`
+	Meter *Meter `gorm:"foreignKey:MeterID" json:"meter,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// NotificationType ประเภทการแจ้งเตือน
+type NotificationType string
+
+const (
+	NotificationLowStock  NotificationType = "low_stock"  // ซีลพร้อมใช้งานต่ำกว่าขั้นต่ำ
+	NotificationSealAging NotificationType = "seal_aging" // ซีลจ่ายแล้วค้างกับช่างเกินกำหนด
+)
+
+// Notification การแจ้งเตือนถึงพนักงานของสำนักงาน (OfficeCode ว่าง = ถึง admin)
+type Notification struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	OfficeCode  string           `gorm:"size:10;index" json:"office_code"`
+	Type        NotificationType `gorm:"size:50;not null;index" json:"type"`
+	Title       string           `gorm:"not null" json:"title"`
+	Message     string           `gorm:"type:text" json:"message"`
+	ReferenceID *uint            `json:"reference_id,omitempty"` // id ของรายการต้นเรื่อง เช่น SealStockAlert
+	ReadBy      *uint            `json:"read_by,omitempty"`
+	ReadAt      *time.Time       `json:"read_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
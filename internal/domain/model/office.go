@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Office ทะเบียนสำนักงาน (กฟฟ.) ใช้กำหนดลำดับชั้นแม่-ลูก สำหรับขอบเขตการเข้าถึงข้อมูล
+// สำนักงานที่ไม่มีในทะเบียนยังใช้งานได้ แต่จะเห็นเฉพาะข้อมูลของตัวเอง
+type Office struct {
+	Code       string    `gorm:"primaryKey;size:10" json:"code"`      // รหัสกฟฟ. (ตรงกับ users.pea_code)
+	ParentCode string    `gorm:"size:10;index" json:"parent_code"`    // สำนักงานแม่ (ว่าง = ระดับบนสุด)
+	ShortName  string    `gorm:"size:10" json:"short_name,omitempty"` // ตัวย่อ
+	Name       string    `gorm:"size:255" json:"name,omitempty"`      // ชื่อกฟฟ.
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OfficeTransferStatus สถานะการส่งซีลระหว่างสำนักงาน
+type OfficeTransferStatus string
+
+const (
+	OfficeTransferInTransit OfficeTransferStatus = "in_transit" // ต้นทางส่งแล้ว รอปลายทางตรวจรับ
+	OfficeTransferReceived  OfficeTransferStatus = "received"   // ปลายทางรับแล้ว ซีลเป็นของปลายทาง
+	OfficeTransferRecalled  OfficeTransferStatus = "recalled"   // ต้นทางเรียกคืนก่อนปลายทางรับ
+)
+
+// OfficeTransfer การส่งซีลจากกฟฟ.หนึ่งไปอีกกฟฟ. (ส่ง/รับ เป็นคู่กัน)
+type OfficeTransfer struct {
+	ID           uint                 `gorm:"primaryKey" json:"id"`
+	FromOffice   string               `gorm:"size:10;index" json:"from_office"` // ว่าง = ซีลเดิมที่ยังไม่ระบุสำนักงาน
+	ToOffice     string               `gorm:"size:10;not null;index" json:"to_office"`
+	Status       OfficeTransferStatus `gorm:"not null;default:'in_transit';index" json:"status"`
+	Remark       string               `json:"remark,omitempty"`
+	DispatchedBy uint                 `gorm:"not null" json:"dispatched_by"`
+	DispatchedAt time.Time            `json:"dispatched_at"`
+	ReceivedBy   *uint                `json:"received_by,omitempty"` // ผู้รับ หรือผู้เรียกคืน
+	ReceivedAt   *time.Time           `json:"received_at,omitempty"`
+	Items        []OfficeTransferItem `gorm:"foreignKey:TransferID" json:"items"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt       `gorm:"index" json:"-"`
+}
+
+// OfficeTransferItem ซีลแต่ละเส้นในการส่ง
+type OfficeTransferItem struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	TransferID uint      `gorm:"not null;index" json:"transfer_id"`
+	SealID     uint      `gorm:"not null;index" json:"seal_id"`
+	SealNumber string    `gorm:"not null" json:"seal_number"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -9,7 +9,9 @@ import (
 type Seal struct {
 	ID                   uint           `gorm:"primaryKey" json:"id"`
 	SealNumber           string         `gorm:"unique;not null" json:"seal_number"`
-	Status               string         `gorm:"not null" json:"status"`
+	Status               SealStatus     `gorm:"not null" json:"status"`
+	Version              uint           `gorm:"not null;default:1" json:"version"` // เพิ่มทุกครั้งที่บันทึก (optimistic lock ดู service.saveSeal)
+	StatusLabel          string         `gorm:"-" json:"status_label"`
 	IssuedBy             *uint          `json:"issued_by,omitempty"`
 	IssuedTo             *uint          `json:"issued_to,omitempty"`
 	ReturnedBy           *uint          `json:"returned_by,omitempty"`
@@ -25,8 +27,29 @@ type Seal struct {
 	EmployeeCode         string         `json:"employee_code,omitempty"`
 	IssueRemark          string         `json:"issue_remark,omitempty"`
 	AssignedToTechnician *uint          `json:"assigned_to_technician,omitempty"`
+	WorkOrderID          *uint          `gorm:"index" json:"work_order_id,omitempty"` // ใบงานที่ใช้ซีลนี้
+	SealLotID            *uint          `gorm:"index" json:"seal_lot_id,omitempty"`   // ล็อตผู้ผลิตที่ซีลนี้มาจาก
+	OfficeCode           string         `gorm:"size:10;index" json:"office_code"`     // รหัสกฟฟ. เจ้าของซีล (ว่าง = ยังไม่ระบุ)
+
+	// การจอง: ReservedBy ผู้จอง (เจ้าของการจอง), ReservedFor ช่างที่จะได้รับซีล, หมดอายุที่ ReservedUntil
+	ReservedBy    *uint      `json:"reserved_by,omitempty"`
+	ReservedFor   *uint      `json:"reserved_for,omitempty"`
+	ReservedUntil *time.Time `gorm:"index" json:"reserved_until,omitempty"`
+	ReserveRemark string     `json:"reserve_remark,omitempty"`
 
 	// ✅ เพิ่มฟิลด์เก็บลิงก์รูปภาพ (อัปโหลด 2 รูป)
 	Image1 string `json:"image1,omitempty"`
 	Image2 string `json:"image2,omitempty"`
 }
+
+// AfterFind เติมข้อความสถานะหลังดึงข้อมูลจากฐานข้อมูล
+func (s *Seal) AfterFind(tx *gorm.DB) error {
+	s.StatusLabel = s.Status.Label()
+	return nil
+}
+
+// AfterSave เติมข้อความสถานะหลังบันทึก เพื่อให้ response ตรงกับสถานะล่าสุด
+func (s *Seal) AfterSave(tx *gorm.DB) error {
+	s.StatusLabel = s.Status.Label()
+	return nil
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// SealAgingPolicy เกณฑ์อายุของซีลที่จ่ายแล้วแต่ยังไม่ติดตั้ง (มีแถวเดียว ID = 1)
+type SealAgingPolicy struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	WarnDays     int       `gorm:"not null" json:"warn_days"`     // ค้างเกินกี่วันจึงขึ้นรายการเฝ้าระวัง
+	EscalateDays int       `gorm:"not null" json:"escalate_days"` // ค้างเกินกี่วันจึงแจ้งสำนักงานที่จ่าย
+	UpdatedBy    uint      `json:"updated_by,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SealAgingEscalation บันทึกว่าซีลรอบการจ่ายนี้ถูกแจ้งเตือนไปแล้ว (จ่ายใหม่ = แจ้งใหม่ได้)
+type SealAgingEscalation struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	SealID          uint      `gorm:"not null;uniqueIndex:idx_seal_aging_escalation" json:"seal_id"`
+	IssuedAt        time.Time `gorm:"not null;uniqueIndex:idx_seal_aging_escalation" json:"issued_at"`
+	SealNumber      string    `gorm:"not null" json:"seal_number"`
+	TechnicianID    *uint     `gorm:"index" json:"technician_id,omitempty"`
+	OfficeCode      string    `gorm:"size:10;index" json:"office_code"`
+	DaysOutstanding int       `json:"days_outstanding"`
+	NotificationID  *uint     `json:"notification_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SealIncidentType ประเภทเหตุการณ์ที่ช่างแจ้ง
+type SealIncidentType string
+
+const (
+	SealIncidentLost    SealIncidentType = "lost"    // ซีลสูญหาย / ถูกขโมย
+	SealIncidentDamaged SealIncidentType = "damaged" // ซีลแตกหักระหว่างติดตั้ง
+)
+
+// SealIncidentStatus สถานะของรายงาน (รอ admin ยืนยัน)
+type SealIncidentStatus string
+
+const (
+	SealIncidentPending   SealIncidentStatus = "pending"
+	SealIncidentConfirmed SealIncidentStatus = "confirmed"
+	SealIncidentRejected  SealIncidentStatus = "rejected"
+)
+
+// SealIncident รายงานซีลสูญหาย/ชำรุด พร้อมหลักฐาน รอ admin ยืนยันก่อนเปลี่ยนสถานะซีล
+type SealIncident struct {
+	ID           uint               `gorm:"primaryKey" json:"id"`
+	SealID       uint               `gorm:"not null;index" json:"seal_id"`
+	SealNumber   string             `gorm:"not null;index" json:"seal_number"`
+	Type         SealIncidentType   `gorm:"not null" json:"type"`
+	Reason       string             `gorm:"not null" json:"reason"`
+	ImageURL     string             `json:"image_url,omitempty"`
+	ReportedBy   uint               `gorm:"not null" json:"reported_by"`   // tech_id หรือ emp_id ของผู้แจ้ง
+	ReporterType string             `gorm:"not null" json:"reporter_type"` // technician | user
+	SealStatus   SealStatus         `gorm:"not null" json:"seal_status"`   // สถานะซีล ณ ตอนที่แจ้ง
+	Status       SealIncidentStatus `gorm:"not null;default:'pending';index" json:"status"`
+	ReviewedBy   *uint              `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time         `json:"reviewed_at,omitempty"`
+	ReviewRemark string             `json:"review_remark,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt     `gorm:"index" json:"-"`
+}
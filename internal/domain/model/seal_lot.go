@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SealLot ล็อตซีลที่รับจากผู้ผลิต (หนึ่งล็อตต่อหนึ่งช่วงเลขที่สร้าง)
+type SealLot struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Supplier        string         `gorm:"not null;index" json:"supplier"`
+	PurchaseOrder   string         `gorm:"index" json:"purchase_order,omitempty"`
+	LotNumber       string         `gorm:"not null;index" json:"lot_number"`
+	DeliveryDate    *time.Time     `json:"delivery_date,omitempty"`
+	Quantity        int            `gorm:"not null" json:"quantity"`
+	FirstSealNumber string         `gorm:"not null" json:"first_seal_number"`
+	LastSealNumber  string         `gorm:"not null" json:"last_seal_number"`
+	CreatedBy       uint           `json:"created_by"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
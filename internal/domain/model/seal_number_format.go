@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// SealCheckDigit อัลกอริทึมเลขตรวจสอบท้ายเลขลำดับของซีล
+type SealCheckDigit string
+
+const (
+	SealCheckDigitNone  SealCheckDigit = ""      // ไม่มีเลขตรวจสอบ
+	SealCheckDigitMod10 SealCheckDigit = "mod10" // Luhn
+	SealCheckDigitMod11 SealCheckDigit = "mod11" // น้ำหนัก 2..7 จากขวา (เศษ 10 = X)
+)
+
+// SealNumberFormat รูปแบบเลขซีลของผู้ผลิต
+// เลขซีล = [Prefix] [YearCode] [เลขลำดับ Width หลัก + เลขตรวจสอบ] [Suffix] คั่นแต่ละส่วนด้วย Separator
+// เช่น Prefix "PEA", Separator "-", YearDigits 2, Width 7, mod10 -> PEA-25-00000175 (เลขลำดับ 17 + เลขตรวจสอบ 5)
+type SealNumberFormat struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	Name       string         `gorm:"size:50;not null;uniqueIndex" json:"name"`
+	Prefix     string         `gorm:"size:20;not null;default:''" json:"prefix"`
+	Separator  string         `gorm:"size:1;not null;default:''" json:"separator"`
+	YearDigits int            `gorm:"not null;default:0" json:"year_digits"` // 0 = ไม่มี year code, 2 หรือ 4 หลัก
+	Width      int            `gorm:"not null" json:"width"`                 // จำนวนหลักของเลขลำดับ (ไม่รวมเลขตรวจสอบ)
+	CheckDigit SealCheckDigit `gorm:"size:10;not null;default:''" json:"check_digit"`
+	Suffix     string         `gorm:"size:20;not null;default:''" json:"suffix"`
+	Active     bool           `gorm:"not null" json:"active"`
+	UpdatedBy  uint           `json:"updated_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// SealReversalStatus สถานะคำขอย้อนกลับรายการของซีล
+type SealReversalStatus string
+
+const (
+	SealReversalPending  SealReversalStatus = "pending"  // รอ admin อีกคนอนุมัติ
+	SealReversalApproved SealReversalStatus = "approved" // ย้อนกลับแล้ว
+	SealReversalRejected SealReversalStatus = "rejected" // ไม่อนุมัติ ซีลคงเดิม
+)
+
+// SealReversal คำขอย้อนกลับรายการล่าสุดของซีล (ผู้ขอและผู้อนุมัติต้องเป็น admin คนละคน)
+type SealReversal struct {
+	ID                    uint               `gorm:"primaryKey" json:"id"`
+	SealID                uint               `gorm:"not null;index" json:"seal_id"`
+	SealNumber            string             `gorm:"not null" json:"seal_number"`
+	TransactionID         uint               `gorm:"not null" json:"transaction_id"` // รายการในสมุดบัญชี transactions ที่ขอย้อนกลับ
+	Action                string             `gorm:"not null" json:"action"`
+	FromStatus            SealStatus         `json:"from_status"`
+	ToStatus              SealStatus         `json:"to_status"`
+	Reason                string             `gorm:"not null" json:"reason"`
+	Status                SealReversalStatus `gorm:"not null;default:'pending';index" json:"status"`
+	RequestedBy           uint               `gorm:"not null" json:"requested_by"`
+	ReviewedBy            *uint              `json:"reviewed_by,omitempty"`
+	ReviewedAt            *time.Time         `json:"reviewed_at,omitempty"`
+	ReviewRemark          string             `json:"review_remark,omitempty"`
+	ReversalTransactionID *uint              `json:"reversal_transaction_id,omitempty"` // รายการชดเชยที่สร้างตอนอนุมัติ
+	CreatedAt             time.Time          `json:"created_at"`
+	UpdatedAt             time.Time          `json:"updated_at"`
+}
@@ -0,0 +1,75 @@
+package model
+
+import "strings"
+
+// SealStatus คือรหัสสถานะของซีลที่ใช้เก็บในฐานข้อมูลและส่งออกทาง API
+// (รหัสคงที่ภาษาอังกฤษ ส่วนข้อความที่แสดงผลให้ใช้ Label)
+type SealStatus string
+
+const (
+	SealStatusAvailable SealStatus = "available"  // พร้อมใช้งาน
+	SealStatusIssued    SealStatus = "issued"     // จ่าย
+	SealStatusInstalled SealStatus = "installed"  // ติดตั้งแล้ว
+	SealStatusUsed      SealStatus = "used"       // ใช้งานแล้ว
+	SealStatusLost      SealStatus = "lost"       // สูญหาย (ยืนยันโดย admin แล้ว)
+	SealStatusDamaged   SealStatus = "damaged"    // ชำรุด (ยืนยันโดย admin แล้ว)
+	SealStatusVoid      SealStatus = "void"       // ยกเลิกใช้งาน
+	SealStatusInTransit SealStatus = "in_transit" // อยู่ระหว่างส่งไปสำนักงานอื่น
+	SealStatusReserved  SealStatus = "reserved"   // จองไว้ให้ช่างสำหรับงานที่วางแผนไว้ (ยังไม่จ่าย)
+)
+
+// sealStatusLabels ข้อความแสดงผลของแต่ละสถานะ แยกตามภาษา
+var sealStatusLabels = map[SealStatus]map[string]string{
+	SealStatusAvailable: {"th": "พร้อมใช้งาน", "en": "Available"},
+	SealStatusIssued:    {"th": "จ่าย", "en": "Issued"},
+	SealStatusInstalled: {"th": "ติดตั้งแล้ว", "en": "Installed"},
+	SealStatusUsed:      {"th": "ใช้งานแล้ว", "en": "Used"},
+	SealStatusLost:      {"th": "สูญหาย", "en": "Lost"},
+	SealStatusDamaged:   {"th": "ชำรุด", "en": "Damaged"},
+	SealStatusVoid:      {"th": "ยกเลิกใช้งาน", "en": "Void"},
+	SealStatusInTransit: {"th": "ระหว่างขนส่ง", "en": "In transit"},
+	SealStatusReserved:  {"th": "จองแล้ว", "en": "Reserved"},
+}
+
+// AllSealStatuses เรียงตามลำดับวงจรชีวิตของซีล (ใช้ทำรายงาน)
+func AllSealStatuses() []SealStatus {
+	return []SealStatus{
+		SealStatusAvailable,
+		SealStatusReserved,
+		SealStatusInTransit,
+		SealStatusIssued,
+		SealStatusInstalled,
+		SealStatusUsed,
+		SealStatusLost,
+		SealStatusDamaged,
+		SealStatusVoid,
+	}
+}
+
+// Label คืนข้อความภาษาไทยของสถานะ
+func (s SealStatus) Label() string {
+	return s.LabelIn("th")
+}
+
+// LabelIn คืนข้อความของสถานะตามภาษาที่ขอ (ถ้าไม่มีจะคืนภาษาไทย)
+func (s SealStatus) LabelIn(lang string) string {
+	labels, ok := sealStatusLabels[s]
+	if !ok {
+		return string(s)
+	}
+	if label, ok := labels[lang]; ok {
+		return label
+	}
+	return labels["th"]
+}
+
+// ParseSealStatus รับได้ทั้งรหัสสถานะ ("issued") และข้อความภาษาไทยแบบเดิม ("จ่าย")
+func ParseSealStatus(raw string) (SealStatus, bool) {
+	raw = strings.TrimSpace(raw)
+	for status, labels := range sealStatusLabels {
+		if raw == string(status) || raw == labels["th"] {
+			return status, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// SealStockThreshold ยอดซีล 'พร้อมใช้งาน' ขั้นต่ำของสำนักงาน แยกตาม prefix ของเลขซีล
+// Prefix ว่าง = นับรวมทุก prefix ของสำนักงาน
+type SealStockThreshold struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	OfficeCode   string    `gorm:"size:10;not null;uniqueIndex:idx_seal_stock_threshold" json:"office_code"`
+	Prefix       string    `gorm:"size:10;not null;default:'';uniqueIndex:idx_seal_stock_threshold" json:"prefix"`
+	MinAvailable int       `gorm:"not null" json:"min_available"`
+	UpdatedBy    uint      `json:"updated_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SealStockAlertStatus สถานะการแจ้งเตือนซีลใกล้หมด
+type SealStockAlertStatus string
+
+const (
+	SealStockAlertOpen     SealStockAlertStatus = "open"     // ยอดยังต่ำกว่าขั้นต่ำ
+	SealStockAlertResolved SealStockAlertStatus = "resolved" // เติมสต็อกแล้ว
+)
+
+// SealStockAlert บันทึกทุกครั้งที่ยอดคงเหลือตกต่ำกว่าขั้นต่ำ (มีได้ครั้งละหนึ่งรายการที่เปิดอยู่ต่อ threshold)
+type SealStockAlert struct {
+	ID           uint                 `gorm:"primaryKey" json:"id"`
+	ThresholdID  uint                 `gorm:"not null;index" json:"threshold_id"`
+	OfficeCode   string               `gorm:"size:10;not null;index" json:"office_code"`
+	Prefix       string               `gorm:"size:10;not null" json:"prefix"`
+	MinAvailable int                  `gorm:"not null" json:"min_available"`
+	Available    int64                `gorm:"not null" json:"available"` // ยอดคงเหลือตอนแจ้งเตือน
+	Status       SealStockAlertStatus `gorm:"not null;default:'open';index" json:"status"`
+	ResolvedAt   *time.Time           `json:"resolved_at,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SealStoreReturnStatus สถานะคำขอคืนซีลเข้าคลังของช่าง
+type SealStoreReturnStatus string
+
+const (
+	SealStoreReturnPending   SealStoreReturnStatus = "pending"   // รอเจ้าหน้าที่คลังตรวจรับ
+	SealStoreReturnConfirmed SealStoreReturnStatus = "confirmed" // คลังตรวจรับแล้ว
+	SealStoreReturnRejected  SealStoreReturnStatus = "rejected"  // คลังปฏิเสธทั้งคำขอ
+	SealStoreReturnCancelled SealStoreReturnStatus = "cancelled" // ช่างยกเลิกก่อนคลังตรวจรับ
+)
+
+// SealStoreReturn คำขอคืนซีลที่จ่ายแล้วแต่ยังไม่ได้ใช้กลับเข้าคลัง (ช่างเป็นผู้ขอ)
+type SealStoreReturn struct {
+	ID            uint                  `gorm:"primaryKey" json:"id"`
+	TechnicianID  uint                  `gorm:"not null;index" json:"technician_id"`
+	Status        SealStoreReturnStatus `gorm:"not null;default:'pending';index" json:"status"`
+	Remark        string                `json:"remark,omitempty"`
+	ConfirmedBy   *uint                 `json:"confirmed_by,omitempty"` // เจ้าหน้าที่คลังที่ตรวจรับ/ปฏิเสธ
+	ConfirmedAt   *time.Time            `json:"confirmed_at,omitempty"`
+	ConfirmRemark string                `json:"confirm_remark,omitempty"`
+	Items         []SealStoreReturnItem `gorm:"foreignKey:ReturnID" json:"items"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt        `gorm:"index" json:"-"`
+}
+
+// SealStoreReturnItem ซีลแต่ละเส้นในคำขอคืน
+// CheckedIn = true เมื่อคลังได้รับซีลจริง (ซีลที่ไม่ได้รับยังคงอยู่กับช่าง)
+type SealStoreReturnItem struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ReturnID   uint      `gorm:"not null;index" json:"return_id"`
+	SealID     uint      `gorm:"not null;index" json:"seal_id"`
+	SealNumber string    `gorm:"not null" json:"seal_number"`
+	CheckedIn  bool      `gorm:"default:false" json:"checked_in"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
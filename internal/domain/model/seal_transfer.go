@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SealTransferStatus สถานะการโอนซีลระหว่างช่าง
+type SealTransferStatus string
+
+const (
+	SealTransferPending   SealTransferStatus = "pending"   // รอช่างผู้รับกดยอมรับ
+	SealTransferAccepted  SealTransferStatus = "accepted"  // ช่างผู้รับยอมรับแล้ว (ย้ายผู้ถือซีลแล้ว)
+	SealTransferRejected  SealTransferStatus = "rejected"  // ช่างผู้รับปฏิเสธ
+	SealTransferCancelled SealTransferStatus = "cancelled" // ผู้ส่ง/admin ยกเลิกก่อนผู้รับตอบ
+)
+
+// SealTransfer การส่งมอบซีลที่จ่ายแล้วจากช่างคนหนึ่งไปอีกคน (ต้องให้ผู้รับยืนยัน)
+type SealTransfer struct {
+	ID               uint               `gorm:"primaryKey" json:"id"`
+	SealID           uint               `gorm:"not null;index" json:"seal_id"`
+	SealNumber       string             `gorm:"not null;index" json:"seal_number"`
+	FromTechnicianID uint               `gorm:"not null;index" json:"from_technician_id"`
+	ToTechnicianID   uint               `gorm:"not null;index" json:"to_technician_id"`
+	InitiatedBy      uint               `gorm:"not null" json:"initiated_by"`
+	InitiatorType    string             `gorm:"not null" json:"initiator_type"` // technician | user
+	Status           SealTransferStatus `gorm:"not null;default:'pending';index" json:"status"`
+	Remark           string             `json:"remark,omitempty"`
+	RespondedAt      *time.Time         `json:"responded_at,omitempty"`
+	ResponseRemark   string             `json:"response_remark,omitempty"`
+	CreatedAt        time.Time          `json:"created_at"` // เวลาที่เริ่มโอน
+	UpdatedAt        time.Time          `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt     `gorm:"index" json:"-"`
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StocktakeStatus สถานะรอบการตรวจนับซีลในคลัง
+type StocktakeStatus string
+
+const (
+	StocktakeOpen   StocktakeStatus = "open"   // กำลังสแกนซีล
+	StocktakeClosed StocktakeStatus = "closed" // ปิดรอบแล้ว ผลต่างถูกบันทึกไว้
+)
+
+// StocktakeVarianceKind ประเภทผลต่างจากการตรวจนับ
+type StocktakeVarianceKind string
+
+const (
+	StocktakeMissing     StocktakeVarianceKind = "missing"      // ระบบว่าอยู่ในคลังแต่ไม่พบ
+	StocktakeUnexpected  StocktakeVarianceKind = "unexpected"   // พบในคลังแต่ไม่ใช่ซีลของสำนักงานนี้/ไม่มีในระบบ
+	StocktakeWrongStatus StocktakeVarianceKind = "wrong_status" // พบในคลังแต่สถานะในระบบไม่ใช่ซีลในคลัง
+)
+
+// Stocktake รอบการตรวจนับซีลในคลังของสำนักงาน
+type Stocktake struct {
+	ID         uint                `gorm:"primaryKey" json:"id"`
+	OfficeCode string              `gorm:"size:10;not null;index" json:"office_code"`
+	Status     StocktakeStatus     `gorm:"not null;default:'open';index" json:"status"`
+	Remark     string              `json:"remark,omitempty"`
+	OpenedBy   uint                `gorm:"not null" json:"opened_by"`
+	OpenedAt   time.Time           `json:"opened_at"`
+	ClosedBy   *uint               `json:"closed_by,omitempty"`
+	ClosedAt   *time.Time          `json:"closed_at,omitempty"`
+	Expected   int                 `json:"expected"` // จำนวนซีลในคลังตามระบบ ณ เวลาปิดรอบ
+	Scanned    int                 `json:"scanned"`
+	Variances  []StocktakeVariance `gorm:"foreignKey:StocktakeID" json:"variances,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt      `gorm:"index" json:"-"`
+}
+
+// StocktakeScan ซีลที่สแกนพบในรอบการตรวจนับ (สแกนซ้ำนับครั้งเดียว)
+type StocktakeScan struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	StocktakeID uint      `gorm:"not null;uniqueIndex:idx_stocktake_scan" json:"stocktake_id"`
+	SealNumber  string    `gorm:"not null;uniqueIndex:idx_stocktake_scan" json:"seal_number"`
+	ScannedBy   uint      `json:"scanned_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StocktakeVariance ผลต่างหนึ่งรายการ บันทึกตอนปิดรอบ
+// Adjusted = true เมื่อปรับสถานะซีลตามผลตรวจนับแล้ว
+type StocktakeVariance struct {
+	ID             uint                  `gorm:"primaryKey" json:"id"`
+	StocktakeID    uint                  `gorm:"not null;index" json:"stocktake_id"`
+	Kind           StocktakeVarianceKind `gorm:"not null" json:"kind"`
+	SealNumber     string                `gorm:"not null" json:"seal_number"`
+	SealID         *uint                 `json:"seal_id,omitempty"` // nil = ไม่มีในระบบ
+	RecordedStatus SealStatus            `json:"recorded_status,omitempty"`
+	RecordedOffice string                `json:"recorded_office,omitempty"`
+	Adjusted       bool                  `gorm:"default:false" json:"adjusted"`
+	AdjustedAction string                `json:"adjusted_action,omitempty"`
+	AdjustedBy     *uint                 `json:"adjusted_by,omitempty"`
+	AdjustedAt     *time.Time            `json:"adjusted_at,omitempty"`
+}
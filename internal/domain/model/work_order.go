@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkOrderType ประเภทใบงาน
+type WorkOrderType string
+
+const (
+	WorkOrderNewConnection WorkOrderType = "new_connection" // ขอใช้ไฟใหม่
+	WorkOrderMeterChange   WorkOrderType = "meter_change"   // สับเปลี่ยนมิเตอร์
+	WorkOrderInspection    WorkOrderType = "inspection"     // ตรวจสอบมิเตอร์
+)
+
+var workOrderTypeLabels = map[WorkOrderType]string{
+	WorkOrderNewConnection: "ขอใช้ไฟใหม่",
+	WorkOrderMeterChange:   "สับเปลี่ยนมิเตอร์",
+	WorkOrderInspection:    "ตรวจสอบมิเตอร์",
+}
+
+// Label คืนชื่อประเภทใบงานภาษาไทย
+func (t WorkOrderType) Label() string {
+	return workOrderTypeLabels[t]
+}
+
+// Valid ตรวจว่าเป็นประเภทใบงานที่รองรับ
+func (t WorkOrderType) Valid() bool {
+	_, ok := workOrderTypeLabels[t]
+	return ok
+}
+
+// WorkOrder ใบงานที่ใช้ผูกการจ่าย/ติดตั้งซีลเข้ากับงานจริง
+type WorkOrder struct {
+	ID                   uint           `gorm:"primaryKey" json:"id"`
+	JobNumber            string         `gorm:"uniqueIndex;not null" json:"job_number"`
+	JobType              WorkOrderType  `gorm:"not null;index" json:"job_type"`
+	CustomerAccount      string         `gorm:"index" json:"customer_account,omitempty"`
+	Address              string         `json:"address,omitempty"`
+	AssignedTechnicianID *uint          `gorm:"index" json:"assigned_technician_id,omitempty"`
+	DueDate              *time.Time     `json:"due_date,omitempty"`
+	CreatedBy            uint           `json:"created_by"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type ApprovalRepository struct {
+	db *gorm.DB
+}
+
+func NewApprovalRepository(db *gorm.DB) *ApprovalRepository {
+	return &ApprovalRepository{db: db}
+}
+
+func (r *ApprovalRepository) Update(request *model.ApprovalRequest) error {
+	return r.db.Save(request).Error
+}
+
+func (r *ApprovalRepository) FindByID(id uint) (*model.ApprovalRequest, error) {
+	var request model.ApprovalRequest
+	if err := r.db.First(&request, id).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// FindAll คำขอล่าสุดก่อน (status/operation ว่าง = ทั้งหมด, requestedBy nil = ทุกคน)
+func (r *ApprovalRepository) FindAll(status model.ApprovalStatus, operation string, requestedBy *uint) ([]model.ApprovalRequest, error) {
+	var requests []model.ApprovalRequest
+	query := r.db.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if operation != "" {
+		query = query.Where("operation = ?", operation)
+	}
+	if requestedBy != nil {
+		query = query.Where("requested_by = ?", *requestedBy)
+	}
+	err := query.Find(&requests).Error
+	return requests, err
+}
+
+// ClaimPending เปลี่ยนคำขอที่ยังรออยู่เป็นสถานะใหม่ (false = ถูกตัดสินไปก่อนแล้ว)
+func (r *ApprovalRepository) ClaimPending(tx *gorm.DB, id uint, status model.ApprovalStatus, reviewerID uint, remark string, at time.Time) (bool, error) {
+	result := tx.Model(&model.ApprovalRequest{}).
+		Where("id = ? AND status = ?", id, model.ApprovalPending).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"reviewed_by":   reviewerID,
+			"reviewed_at":   at,
+			"review_remark": remark,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindPolicy การตั้งค่าของ operation (nil ถ้ายังไม่เคยตั้ง)
+func (r *ApprovalRepository) FindPolicy(operation string) (*model.ApprovalPolicy, error) {
+	var policy model.ApprovalPolicy
+	if err := r.db.Where("operation = ?", operation).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *ApprovalRepository) FindPolicies() ([]model.ApprovalPolicy, error) {
+	var policies []model.ApprovalPolicy
+	err := r.db.Find(&policies).Error
+	return policies, err
+}
+
+func (r *ApprovalRepository) SavePolicy(policy *model.ApprovalPolicy) error {
+	return r.db.Save(policy).Error
+}
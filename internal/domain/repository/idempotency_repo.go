@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Claim จองคีย์ให้คำขอนี้ (false = มีคำขอที่ใช้คีย์เดียวกันอยู่แล้ว)
+func (r *IdempotencyRepository) Claim(record *model.IdempotencyKey) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Find คีย์ของผู้เรียก (nil ถ้าไม่มี)
+func (r *IdempotencyRepository) Find(scope string, key string) (*model.IdempotencyKey, error) {
+	var record model.IdempotencyKey
+	if err := r.db.Where("scope = ? AND key = ?", scope, key).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Complete เก็บผลลัพธ์ของคำขอแรก
+func (r *IdempotencyRepository) Complete(id uint, status int, contentType string, body []byte) error {
+	return r.db.Model(&model.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          model.IdempotencyCompleted,
+		"response_status": status,
+		"content_type":    contentType,
+		"response_body":   body,
+	}).Error
+}
+
+func (r *IdempotencyRepository) Delete(id uint) error {
+	return r.db.Delete(&model.IdempotencyKey{}, id).Error
+}
+
+// DeleteStale ลบคีย์ที่ค้างสถานะ processing นานเกิน (คำขอแรกล้มกลางทาง) ให้คำขอซ้ำทำงานใหม่ได้
+func (r *IdempotencyRepository) DeleteStale(id uint, before time.Time) (bool, error) {
+	result := r.db.Where("id = ? AND status = ? AND updated_at < ?", id, model.IdempotencyProcessing, before).
+		Delete(&model.IdempotencyKey{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteExpired ลบคีย์ที่หมดอายุแล้ว
+func (r *IdempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type JobRunRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRunRepository(db *gorm.DB) *JobRunRepository {
+	return &JobRunRepository{db: db}
+}
+
+func (r *JobRunRepository) Create(run *model.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *JobRunRepository) Update(run *model.JobRun) error {
+	return r.db.Save(run).Error
+}
+
+// ExistsForSchedule รอบตามกำหนดเวลานี้ถูกทำไปแล้วหรือยัง (โดย replica ใดก็ได้)
+func (r *JobRunRepository) ExistsForSchedule(jobName string, scheduledFor time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.JobRun{}).
+		Where("job_name = ? AND scheduled_for = ?", jobName, scheduledFor).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindByJob ประวัติล่าสุดของงาน (limit <= 0 = ทั้งหมด)
+func (r *JobRunRepository) FindByJob(jobName string, limit int) ([]model.JobRun, error) {
+	var runs []model.JobRun
+	query := r.db.Where("job_name = ?", jobName).Order("started_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&runs).Error
+	return runs, err
+}
+
+// FindLatest การทำงานครั้งล่าสุดของงาน (nil ถ้ายังไม่เคยทำงาน)
+func (r *JobRunRepository) FindLatest(jobName string) (*model.JobRun, error) {
+	var run model.JobRun
+	if err := r.db.Where("job_name = ?", jobName).Order("started_at DESC").First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
@@ -19,9 +19,9 @@ func (r *LogRepository) Create(log *model.Log) error {
 }
 
 // ✅ ดึง Log ทั้งหมด
-func (r *LogRepository) GetAll() ([]model.Log, error) {
+func (r *LogRepository) GetAll(offices []string) ([]model.Log, error) {
 	var logs []model.Log
-	err := r.db.Find(&logs).Error
+	err := r.inOffices(offices).Find(&logs).Error
 	return logs, err
 }
 
@@ -88,23 +88,23 @@ func (r *LogRepository) GetByID(logID uint) (*model.Log, error) {
 }
 
 // ✅ ดึง Log ตามประเภท (Type)
-func (r *LogRepository) GetByType(logType string) ([]model.Log, error) {
+func (r *LogRepository) GetByType(logType string, offices []string) ([]model.Log, error) {
 	var logs []model.Log
-	err := r.db.Where("action = ?", logType).Find(&logs).Error
+	err := r.inOffices(offices).Where("action = ?", logType).Find(&logs).Error
 	return logs, err
 }
 
 // ✅ ดึง Log ตาม User ID
-func (r *LogRepository) GetByUser(userID uint) ([]model.Log, error) {
+func (r *LogRepository) GetByUser(userID uint, offices []string) ([]model.Log, error) {
 	var logs []model.Log
-	err := r.db.Where("user_id = ?", userID).Find(&logs).Error
+	err := r.inOffices(offices).Where("user_id = ?", userID).Find(&logs).Error
 	return logs, err
 }
 
 // ✅ ดึง Log ตามช่วงเวลา (Date Range)
-func (r *LogRepository) GetByDateRange(startDate, endDate string) ([]model.Log, error) {
+func (r *LogRepository) GetByDateRange(startDate, endDate string, offices []string) ([]model.Log, error) {
 	var logs []model.Log
-	err := r.db.Where("timestamp BETWEEN ? AND ?", startDate, endDate).Find(&logs).Error
+	err := r.inOffices(offices).Where("timestamp BETWEEN ? AND ?", startDate, endDate).Find(&logs).Error
 	return logs, err
 }
 
@@ -114,8 +114,37 @@ func (r *LogRepository) Delete(logID uint) error {
 }
 
 // ✅ Fetch logs by action type
-func (r *LogRepository) GetByAction(action string) ([]model.Log, error) {
+func (r *LogRepository) GetByAction(action string, offices []string) ([]model.Log, error) {
 	var logs []model.Log
-	err := r.db.Where("action LIKE ?", "%"+action+"%").Find(&logs).Error
+	err := r.inOffices(offices).Where("action LIKE ?", "%"+action+"%").Find(&logs).Error
 	return logs, err
 }
+
+// ✅ ตรวจว่า Log นี้เป็นของผู้ใช้ในสำนักงาน offices
+func (r *LogRepository) IsInOffices(logID uint, offices []string) (bool, error) {
+	var count int64
+	err := r.inOffices(offices).Model(&model.Log{}).Where("id = ?", logID).Count(&count).Error
+	return count > 0, err
+}
+
+// ✅ ตรวจว่า userID เป็นพนักงาน (emp_id) หรือช่างของสำนักงาน offices
+func (r *LogRepository) OwnerInOffices(userID uint, offices []string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.User{}).Where("emp_id = ? AND pea_code IN ?", userID, offices).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	err = r.db.Model(&model.Technician{}).Where("id = ? AND electric_code IN ?", userID, offices).Count(&count).Error
+	return count > 0, err
+}
+
+// inOffices กรอง Log ตามสำนักงานของผู้บันทึก (nil = ไม่กรอง)
+// logs.user_id เก็บทั้ง emp_id ของพนักงานและ id ของช่าง จึงต้องเทียบทั้งสองตาราง
+func (r *LogRepository) inOffices(offices []string) *gorm.DB {
+	if offices == nil {
+		return r.db
+	}
+	users := r.db.Model(&model.User{}).Select("emp_id").Where("pea_code IN ?", offices)
+	technicians := r.db.Model(&model.Technician{}).Select("id").Where("electric_code IN ?", offices)
+	return r.db.Where("(user_id IN (?) OR user_id IN (?))", users, technicians)
+}
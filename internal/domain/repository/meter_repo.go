@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type MeterRepository struct {
+	db *gorm.DB
+}
+
+func NewMeterRepository(db *gorm.DB) *MeterRepository {
+	return &MeterRepository{db: db}
+}
+
+func (r *MeterRepository) Create(meter *model.Meter) error {
+	return r.db.Create(meter).Error
+}
+
+func (r *MeterRepository) Update(meter *model.Meter) error {
+	return r.db.Save(meter).Error
+}
+
+func (r *MeterRepository) FindBySerial(serial string) (*model.Meter, error) {
+	var meter model.Meter
+	if err := r.db.Where("serial_number = ?", serial).First(&meter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบมิเตอร์ในระบบ")
+		}
+		return nil, err
+	}
+	return &meter, nil
+}
+
+// Search ค้นหามิเตอร์จาก serial / หมายเลขผู้ใช้ไฟ (ว่าง = ทั้งหมด)
+func (r *MeterRepository) Search(keyword string) ([]model.Meter, error) {
+	var meters []model.Meter
+	query := r.db.Order("serial_number")
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("serial_number LIKE ? OR customer_account LIKE ?", like, like)
+	}
+	err := query.Find(&meters).Error
+	return meters, err
+}
+
+// FindSealLinks ประวัติซีลทั้งหมดของมิเตอร์ (ล่าสุดก่อน) พร้อมข้อมูลซีล
+func (r *MeterRepository) FindSealLinks(meterID uint) ([]model.MeterSeal, error) {
+	var links []model.MeterSeal
+	err := r.db.Preload("Seal").
+		Where("meter_id = ?", meterID).
+		Order("installed_at DESC").
+		Find(&links).Error
+	return links, err
+}
+
+// FindFlaggedLinks รายการติดตั้งที่ถูก flag (ซีลซ้อนบนมิเตอร์เดียวกัน)
+func (r *MeterRepository) FindFlaggedLinks() ([]model.MeterSeal, error) {
+	var links []model.MeterSeal
+	err := r.db.Preload("Seal").Preload("Meter").
+		Where("flagged = ?", true).
+		Order("installed_at DESC").
+		Find(&links).Error
+	return links, err
+}
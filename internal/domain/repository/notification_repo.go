@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) FindByID(id uint) (*model.Notification, error) {
+	var notification model.Notification
+	if err := r.db.First(&notification, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบการแจ้งเตือน")
+		}
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// FindByOffices การแจ้งเตือนของสำนักงาน (offices nil = ทั้งหมด) ล่าสุดก่อน
+func (r *NotificationRepository) FindByOffices(offices []string, unreadOnly bool) ([]model.Notification, error) {
+	var notifications []model.Notification
+	query := r.db.Order("created_at DESC")
+	if offices != nil {
+		query = query.Where("office_code IN ?", offices)
+	}
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *NotificationRepository) Update(notification *model.Notification) error {
+	return r.db.Save(notification).Error
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type OfficeRepository struct {
+	db *gorm.DB
+}
+
+func NewOfficeRepository(db *gorm.DB) *OfficeRepository {
+	return &OfficeRepository{db: db}
+}
+
+// Save เพิ่มหรือแก้ไขสำนักงาน (Code เป็น primary key)
+func (r *OfficeRepository) Save(office *model.Office) error {
+	return r.db.Save(office).Error
+}
+
+func (r *OfficeRepository) FindByCode(code string) (*model.Office, error) {
+	var office model.Office
+	if err := r.db.Where("code = ?", code).First(&office).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบสำนักงานในทะเบียน")
+		}
+		return nil, err
+	}
+	return &office, nil
+}
+
+func (r *OfficeRepository) FindAll() ([]model.Office, error) {
+	var offices []model.Office
+	err := r.db.Order("code").Find(&offices).Error
+	return offices, err
+}
+
+// FindChildCodes รหัสสำนักงานลูกโดยตรงของ parentCodes
+func (r *OfficeRepository) FindChildCodes(parentCodes []string) ([]string, error) {
+	var codes []string
+	err := r.db.Model(&model.Office{}).Where("parent_code IN ?", parentCodes).Pluck("code", &codes).Error
+	return codes, err
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type OfficeTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewOfficeTransferRepository(db *gorm.DB) *OfficeTransferRepository {
+	return &OfficeTransferRepository{db: db}
+}
+
+func (r *OfficeTransferRepository) FindByID(id uint) (*model.OfficeTransfer, error) {
+	var transfer model.OfficeTransfer
+	if err := r.db.Preload("Items").First(&transfer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบรายการส่งซีลระหว่างสำนักงาน")
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// FindByOffice รายการส่งของสำนักงาน (incoming = ปลายทาง, outgoing = ต้นทาง, office ว่าง = ทุกสำนักงาน)
+func (r *OfficeTransferRepository) FindByOffice(office string, incoming bool, status model.OfficeTransferStatus) ([]model.OfficeTransfer, error) {
+	var transfers []model.OfficeTransfer
+	query := r.db.Preload("Items").Order("created_at DESC")
+	if office != "" {
+		column := "from_office"
+		if incoming {
+			column = "to_office"
+		}
+		query = query.Where(column+" = ?", office)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&transfers).Error
+	return transfers, err
+}
+
+// OfficeExists ตรวจว่ามีพนักงานสังกัดรหัสกฟฟ.นี้ (ใช้แทนทะเบียนสำนักงาน)
+func (r *OfficeTransferRepository) OfficeExists(office string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.User{}).Where("pea_code = ?", office).Count(&count).Error
+	return count > 0, err
+}
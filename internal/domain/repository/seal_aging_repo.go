@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type SealAgingRepository struct {
+	db *gorm.DB
+}
+
+func NewSealAgingRepository(db *gorm.DB) *SealAgingRepository {
+	return &SealAgingRepository{db: db}
+}
+
+// GetPolicy คืนเกณฑ์ที่ตั้งไว้ (nil ถ้ายังไม่เคยตั้ง)
+func (r *SealAgingRepository) GetPolicy() (*model.SealAgingPolicy, error) {
+	var policy model.SealAgingPolicy
+	if err := r.db.First(&policy, 1).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *SealAgingRepository) SavePolicy(policy *model.SealAgingPolicy) error {
+	policy.ID = 1
+	return r.db.Save(policy).Error
+}
+
+// FindIssuedBefore ซีล 'จ่าย' ที่จ่ายก่อน cutoff (offices nil = ทุกสำนักงาน) เก่าสุดก่อน
+func (r *SealAgingRepository) FindIssuedBefore(cutoff time.Time, offices []string) ([]model.Seal, error) {
+	var seals []model.Seal
+	query := r.db.Where("status = ? AND issued_at IS NOT NULL AND issued_at <= ?", model.SealStatusIssued, cutoff).
+		Order("issued_at")
+	if offices != nil {
+		query = query.Where("office_code IN ?", offices)
+	}
+	err := query.Find(&seals).Error
+	return seals, err
+}
+
+// FindEscalations การแจ้งเตือนที่เคยส่งแล้วของซีลเหล่านี้
+func (r *SealAgingRepository) FindEscalations(sealIDs []uint) ([]model.SealAgingEscalation, error) {
+	var escalations []model.SealAgingEscalation
+	if len(sealIDs) == 0 {
+		return escalations, nil
+	}
+	err := r.db.Where("seal_id IN ?", sealIDs).Find(&escalations).Error
+	return escalations, err
+}
+
+func (r *SealAgingRepository) FindTechnicians(ids []uint) ([]model.Technician, error) {
+	var technicians []model.Technician
+	if len(ids) == 0 {
+		return technicians, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&technicians).Error
+	return technicians, err
+}
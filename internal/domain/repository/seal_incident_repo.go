@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type SealIncidentRepository struct {
+	db *gorm.DB
+}
+
+func NewSealIncidentRepository(db *gorm.DB) *SealIncidentRepository {
+	return &SealIncidentRepository{db: db}
+}
+
+func (r *SealIncidentRepository) Create(incident *model.SealIncident) error {
+	return r.db.Create(incident).Error
+}
+
+func (r *SealIncidentRepository) FindByID(id uint) (*model.SealIncident, error) {
+	var incident model.SealIncident
+	if err := r.db.First(&incident, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบรายงานในระบบ")
+		}
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// FindPendingBySeal คืนรายงานที่ยังรอยืนยันของซีล (nil ถ้าไม่มี)
+func (r *SealIncidentRepository) FindPendingBySeal(sealID uint) (*model.SealIncident, error) {
+	var incident model.SealIncident
+	err := r.db.Where("seal_id = ? AND status = ?", sealID, model.SealIncidentPending).First(&incident).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// FindByStatus ดึงรายงานตามสถานะ (ว่าง = ทั้งหมด) เรียงจากล่าสุด
+// offices กรองตามสำนักงานเจ้าของซีล (nil = ทุกสำนักงาน)
+func (r *SealIncidentRepository) FindByStatus(status model.SealIncidentStatus, offices []string) ([]model.SealIncident, error) {
+	var incidents []model.SealIncident
+	query := r.inOffices(offices).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&incidents).Error
+	return incidents, err
+}
+
+func (r *SealIncidentRepository) CountByStatus(status model.SealIncidentStatus, offices []string) (int64, error) {
+	var count int64
+	err := r.inOffices(offices).Model(&model.SealIncident{}).Where("status = ?", status).Count(&count).Error
+	return count, err
+}
+
+func (r *SealIncidentRepository) inOffices(offices []string) *gorm.DB {
+	if offices == nil {
+		return r.db
+	}
+	return r.db.Where("seal_id IN (?)", r.db.Model(&model.Seal{}).Select("id").Where("office_code IN ?", offices))
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type SealLotRepository struct {
+	db *gorm.DB
+}
+
+func NewSealLotRepository(db *gorm.DB) *SealLotRepository {
+	return &SealLotRepository{db: db}
+}
+
+func (r *SealLotRepository) FindByID(id uint) (*model.SealLot, error) {
+	var lot model.SealLot
+	if err := r.db.First(&lot, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบล็อตซีล")
+		}
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// Search ค้นหาล็อตตามผู้ผลิต / เลขล็อต (ว่าง = ทั้งหมด) ล่าสุดก่อน
+func (r *SealLotRepository) Search(supplier string, lotNumber string) ([]model.SealLot, error) {
+	var lots []model.SealLot
+	query := r.db.Order("created_at DESC")
+	if supplier != "" {
+		query = query.Where("supplier = ?", supplier)
+	}
+	if lotNumber != "" {
+		query = query.Where("lot_number = ?", lotNumber)
+	}
+	err := query.Find(&lots).Error
+	return lots, err
+}
+
+// SealLotStatusCount จำนวนซีลของล็อตแยกตามสถานะ
+type SealLotStatusCount struct {
+	SealLotID uint
+	Status    model.SealStatus
+	Count     int64
+}
+
+// CountSealsByStatus นับซีลของล็อตที่ระบุแยกตามสถานะ
+func (r *SealLotRepository) CountSealsByStatus(lotIDs []uint) ([]SealLotStatusCount, error) {
+	var rows []SealLotStatusCount
+	if len(lotIDs) == 0 {
+		return rows, nil
+	}
+	err := r.db.Model(&model.Seal{}).
+		Select("seal_lot_id, status, COUNT(*) AS count").
+		Where("seal_lot_id IN ?", lotIDs).
+		Group("seal_lot_id, status").
+		Scan(&rows).Error
+	return rows, err
+}
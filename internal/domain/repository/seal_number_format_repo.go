@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type SealNumberFormatRepository struct {
+	db *gorm.DB
+}
+
+func NewSealNumberFormatRepository(db *gorm.DB) *SealNumberFormatRepository {
+	return &SealNumberFormatRepository{db: db}
+}
+
+func (r *SealNumberFormatRepository) FindAll() ([]model.SealNumberFormat, error) {
+	var formats []model.SealNumberFormat
+	err := r.db.Order("id").Find(&formats).Error
+	return formats, err
+}
+
+// FindActive รูปแบบที่เปิดใช้ (ตามลำดับที่สร้าง ใช้รูปแบบแรกที่ตรงเมื่อมีหลายรูปแบบ)
+func (r *SealNumberFormatRepository) FindActive() ([]model.SealNumberFormat, error) {
+	var formats []model.SealNumberFormat
+	err := r.db.Where("active = ?", true).Order("id").Find(&formats).Error
+	return formats, err
+}
+
+func (r *SealNumberFormatRepository) FindByID(id uint) (*model.SealNumberFormat, error) {
+	var format model.SealNumberFormat
+	if err := r.db.First(&format, id).Error; err != nil {
+		return nil, err
+	}
+	return &format, nil
+}
+
+func (r *SealNumberFormatRepository) Save(format *model.SealNumberFormat) error {
+	return r.db.Save(format).Error
+}
+
+func (r *SealNumberFormatRepository) Delete(id uint) error {
+	return r.db.Delete(&model.SealNumberFormat{}, id).Error
+}
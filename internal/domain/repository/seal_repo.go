@@ -2,8 +2,10 @@ package repository
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/Kev2406/PEA/internal/domain/model"
@@ -22,45 +24,50 @@ func (r *SealRepository) Create(seal *model.Seal) error {
 	return r.db.Create(seal).Error
 }
 
-func (r *SealRepository) CreateMultiple(seals []model.Seal) error {
+// CreateMultiple สร้างซีลทั้งหมดใน tx เดียว ถ้ามีเลขที่มีอยู่แล้วจะไม่สร้างเลย (ไม่ข้ามเลขซ้ำแบบเงียบ ๆ)
+func (r *SealRepository) CreateMultiple(tx *gorm.DB, seals []model.Seal) error {
 	// If no seals to insert, return early
 	if len(seals) == 0 {
 		return nil
 	}
 
 	// Extract all seal numbers to check
-	var sealNumbers []string
+	sealNumbers := make([]string, 0, len(seals))
 	for _, seal := range seals {
 		sealNumbers = append(sealNumbers, seal.SealNumber)
 	}
-
-	// Find existing seals
-	var existingSeals []model.Seal
-	if err := r.db.Where("seal_number IN ?", sealNumbers).Find(&existingSeals).Error; err != nil {
+	existing, err := r.findExistingNumbers(tx, sealNumbers)
+	if err != nil {
 		return err
 	}
-
-	// Create a map for faster lookup
-	existingSealMap := make(map[string]bool)
-	for _, seal := range existingSeals {
-		existingSealMap[seal.SealNumber] = true
+	if len(existing) > 0 {
+		return fmt.Errorf("Security seal ซ้ำกรุณากรอกเลขใหม่ด้วยค่ะ: %s", strings.Join(existing, ", "))
 	}
+	return tx.CreateInBatches(&seals, sealNumberChunkSize).Error
+}
 
-	// Filter out existing seals
-	var newSeals []model.Seal
-	for _, seal := range seals {
-		if !existingSealMap[seal.SealNumber] {
-			newSeals = append(newSeals, seal)
-		}
-	}
+// sealNumberChunkSize จำนวนเลขซีลต่อหนึ่งคำสั่ง (กันพารามิเตอร์เกินขีดจำกัดของ Postgres)
+const sealNumberChunkSize = 1000
 
-	// If no new seals to insert, return success
-	if len(newSeals) == 0 {
-		return nil
-	}
+// FindExistingNumbers เลขซีลในรายการที่มีอยู่ในระบบแล้ว
+func (r *SealRepository) FindExistingNumbers(sealNumbers []string) ([]string, error) {
+	return r.findExistingNumbers(r.db, sealNumbers)
+}
 
-	// Insert only the new seals
-	return r.db.Create(&newSeals).Error
+func (r *SealRepository) findExistingNumbers(db *gorm.DB, sealNumbers []string) ([]string, error) {
+	existing := []string{}
+	for start := 0; start < len(sealNumbers); start += sealNumberChunkSize {
+		end := start + sealNumberChunkSize
+		if end > len(sealNumbers) {
+			end = len(sealNumbers)
+		}
+		var found []string
+		if err := db.Model(&model.Seal{}).Where("seal_number IN ?", sealNumbers[start:end]).Pluck("seal_number", &found).Error; err != nil {
+			return nil, err
+		}
+		existing = append(existing, found...)
+	}
+	return existing, nil
 }
 
 func (r *SealRepository) FindByNumber(sealNumber string) (*model.Seal, error) {
@@ -142,6 +149,14 @@ func parseInt64(s string) int64 {
 	}
 	return n
 }
+
+// FindByNumberRange ซีลที่เลขอยู่ระหว่าง first ถึง last (เทียบแบบข้อความ ผู้เรียกต้องกรองชุดเลขเอง)
+func (r *SealRepository) FindByNumberRange(first string, last string) ([]model.Seal, error) {
+	var seals []model.Seal
+	err := r.db.Where("seal_number BETWEEN ? AND ?", first, last).Order("seal_number").Find(&seals).Error
+	return seals, err
+}
+
 func (r *SealRepository) CheckSealExists(sealNumber string) (bool, error) {
 	var count int64
 	if err := r.db.Model(&model.Seal{}).Where("seal_number = ?", sealNumber).Count(&count).Error; err != nil {
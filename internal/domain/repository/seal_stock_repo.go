@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type SealStockRepository struct {
+	db *gorm.DB
+}
+
+func NewSealStockRepository(db *gorm.DB) *SealStockRepository {
+	return &SealStockRepository{db: db}
+}
+
+// SaveThreshold เพิ่มหรือแก้ขั้นต่ำของ (สำนักงาน, prefix)
+func (r *SealStockRepository) SaveThreshold(threshold *model.SealStockThreshold) error {
+	var existing model.SealStockThreshold
+	err := r.db.Where("office_code = ? AND prefix = ?", threshold.OfficeCode, threshold.Prefix).First(&existing).Error
+	if err == nil {
+		threshold.ID = existing.ID
+		threshold.CreatedAt = existing.CreatedAt
+		return r.db.Save(threshold).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return r.db.Create(threshold).Error
+}
+
+func (r *SealStockRepository) FindThresholdByID(id uint) (*model.SealStockThreshold, error) {
+	var threshold model.SealStockThreshold
+	if err := r.db.First(&threshold, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบการตั้งค่าขั้นต่ำ")
+		}
+		return nil, err
+	}
+	return &threshold, nil
+}
+
+// FindThresholds ขั้นต่ำทั้งหมด (offices nil = ทุกสำนักงาน)
+func (r *SealStockRepository) FindThresholds(offices []string) ([]model.SealStockThreshold, error) {
+	var thresholds []model.SealStockThreshold
+	query := r.db.Order("office_code, prefix")
+	if offices != nil {
+		query = query.Where("office_code IN ?", offices)
+	}
+	err := query.Find(&thresholds).Error
+	return thresholds, err
+}
+
+// FindThresholdsFor ขั้นต่ำที่เกี่ยวกับซีล prefix นี้ของสำนักงาน (รวมแบบไม่ระบุ prefix)
+func (r *SealStockRepository) FindThresholdsFor(office string, prefix string) ([]model.SealStockThreshold, error) {
+	var thresholds []model.SealStockThreshold
+	err := r.db.Where("office_code = ? AND prefix IN ?", office, []string{prefix, ""}).Find(&thresholds).Error
+	return thresholds, err
+}
+
+// CountAvailable นับซีลพร้อมใช้งานของสำนักงาน (prefix ต้องตรงทั้งส่วนตัวอักษร เช่น F ไม่นับ FA)
+func (r *SealStockRepository) CountAvailable(office string, prefix string) (int64, error) {
+	var count int64
+	query := r.db.Model(&model.Seal{}).Where("office_code = ? AND status = ?", office, model.SealStatusAvailable)
+	if prefix != "" {
+		query = query.Where("seal_number ~ ?", "^"+prefix+"[0-9]+$")
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// FindOpenAlert การแจ้งเตือนที่ยังเปิดอยู่ของ threshold (nil ถ้าไม่มี)
+func (r *SealStockRepository) FindOpenAlert(thresholdID uint) (*model.SealStockAlert, error) {
+	var alert model.SealStockAlert
+	err := r.db.Where("threshold_id = ? AND status = ?", thresholdID, model.SealStockAlertOpen).First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// FindAlerts การแจ้งเตือนตามสถานะ (ว่าง = ทั้งหมด, offices nil = ทุกสำนักงาน) ล่าสุดก่อน
+func (r *SealStockRepository) FindAlerts(status model.SealStockAlertStatus, offices []string) ([]model.SealStockAlert, error) {
+	var alerts []model.SealStockAlert
+	query := r.db.Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if offices != nil {
+		query = query.Where("office_code IN ?", offices)
+	}
+	err := query.Find(&alerts).Error
+	return alerts, err
+}
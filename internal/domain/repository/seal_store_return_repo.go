@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type SealStoreReturnRepository struct {
+	db *gorm.DB
+}
+
+func NewSealStoreReturnRepository(db *gorm.DB) *SealStoreReturnRepository {
+	return &SealStoreReturnRepository{db: db}
+}
+
+func (r *SealStoreReturnRepository) FindByID(id uint) (*model.SealStoreReturn, error) {
+	var storeReturn model.SealStoreReturn
+	if err := r.db.Preload("Items").First(&storeReturn, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบคำขอคืนซีล")
+		}
+		return nil, err
+	}
+	return &storeReturn, nil
+}
+
+// FindPendingItemsBySeals คืนซีลในชุดนี้ที่อยู่ในคำขอคืนที่ยังรอคลังตรวจรับ
+func (r *SealStoreReturnRepository) FindPendingItemsBySeals(sealIDs []uint) ([]model.SealStoreReturnItem, error) {
+	var items []model.SealStoreReturnItem
+	err := r.db.Joins("JOIN seal_store_returns ON seal_store_returns.id = seal_store_return_items.return_id").
+		Where("seal_store_return_items.seal_id IN ? AND seal_store_returns.status = ? AND seal_store_returns.deleted_at IS NULL",
+			sealIDs, model.SealStoreReturnPending).
+		Find(&items).Error
+	return items, err
+}
+
+// FindByTechnician คำขอคืนของช่าง (ล่าสุดก่อน)
+func (r *SealStoreReturnRepository) FindByTechnician(techID uint, status model.SealStoreReturnStatus) ([]model.SealStoreReturn, error) {
+	var returns []model.SealStoreReturn
+	query := r.db.Preload("Items").Where("technician_id = ?", techID).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&returns).Error
+	return returns, err
+}
+
+// FindByStatus คำขอคืนทั้งหมดตามสถานะ (ว่าง = ทั้งหมด) offices กรองตามสังกัดของช่าง (nil = ทุกสำนักงาน)
+func (r *SealStoreReturnRepository) FindByStatus(status model.SealStoreReturnStatus, offices []string) ([]model.SealStoreReturn, error) {
+	var returns []model.SealStoreReturn
+	query := r.db.Preload("Items").Order("created_at DESC")
+	if offices != nil {
+		query = query.Where("technician_id IN (?)",
+			r.db.Model(&model.Technician{}).Select("id").Where("electric_code IN ?", offices))
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&returns).Error
+	return returns, err
+}
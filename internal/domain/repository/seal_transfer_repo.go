@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type SealTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewSealTransferRepository(db *gorm.DB) *SealTransferRepository {
+	return &SealTransferRepository{db: db}
+}
+
+func (r *SealTransferRepository) FindByID(id uint) (*model.SealTransfer, error) {
+	var transfer model.SealTransfer
+	if err := r.db.First(&transfer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบรายการโอนซีล")
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// FindPendingBySeals คืนรายการโอนที่ยังรอผู้รับของซีลชุดนี้
+func (r *SealTransferRepository) FindPendingBySeals(sealIDs []uint) ([]model.SealTransfer, error) {
+	var transfers []model.SealTransfer
+	err := r.db.Where("seal_id IN ? AND status = ?", sealIDs, model.SealTransferPending).Find(&transfers).Error
+	return transfers, err
+}
+
+// FindByTechnician รายการโอนที่ช่างเป็นผู้รับ (incoming) หรือผู้ส่ง (outgoing)
+func (r *SealTransferRepository) FindByTechnician(techID uint, incoming bool, status model.SealTransferStatus) ([]model.SealTransfer, error) {
+	var transfers []model.SealTransfer
+	column := "from_technician_id"
+	if incoming {
+		column = "to_technician_id"
+	}
+	query := r.db.Where(column+" = ?", techID).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&transfers).Error
+	return transfers, err
+}
+
+// FindByStatus รายการโอนทั้งหมดตามสถานะ (ว่าง = ทั้งหมด)
+func (r *SealTransferRepository) FindByStatus(status model.SealTransferStatus) ([]model.SealTransfer, error) {
+	var transfers []model.SealTransfer
+	query := r.db.Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&transfers).Error
+	return transfers, err
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type StocktakeRepository struct {
+	db *gorm.DB
+}
+
+func NewStocktakeRepository(db *gorm.DB) *StocktakeRepository {
+	return &StocktakeRepository{db: db}
+}
+
+func (r *StocktakeRepository) Create(stocktake *model.Stocktake) error {
+	return r.db.Create(stocktake).Error
+}
+
+func (r *StocktakeRepository) FindByID(id uint) (*model.Stocktake, error) {
+	var stocktake model.Stocktake
+	if err := r.db.Preload("Variances").First(&stocktake, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบรอบการตรวจนับ")
+		}
+		return nil, err
+	}
+	return &stocktake, nil
+}
+
+// FindOpenByOffice รอบที่ยังเปิดอยู่ของสำนักงาน (nil ถ้าไม่มี)
+func (r *StocktakeRepository) FindOpenByOffice(office string) (*model.Stocktake, error) {
+	var stocktake model.Stocktake
+	err := r.db.Where("office_code = ? AND status = ?", office, model.StocktakeOpen).First(&stocktake).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stocktake, nil
+}
+
+// FindAll รอบการตรวจนับ (ล่าสุดก่อน) offices nil = ทุกสำนักงาน, status ว่าง = ทั้งหมด
+func (r *StocktakeRepository) FindAll(offices []string, status model.StocktakeStatus) ([]model.Stocktake, error) {
+	var stocktakes []model.Stocktake
+	query := r.db.Order("opened_at DESC")
+	if offices != nil {
+		query = query.Where("office_code IN ?", offices)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&stocktakes).Error
+	return stocktakes, err
+}
+
+// FindScannedNumbers เลขซีลที่สแกนแล้วในรอบนี้
+func (r *StocktakeRepository) FindScannedNumbers(stocktakeID uint) ([]string, error) {
+	var numbers []string
+	err := r.db.Model(&model.StocktakeScan{}).Where("stocktake_id = ?", stocktakeID).
+		Order("seal_number").Pluck("seal_number", &numbers).Error
+	return numbers, err
+}
+
+// CountScans จำนวนซีลที่สแกนแล้วในรอบนี้
+func (r *StocktakeRepository) CountScans(stocktakeID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.StocktakeScan{}).Where("stocktake_id = ?", stocktakeID).Count(&count).Error
+	return count, err
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"errors"
 	"log"
+	"time"
 
 	"github.com/Kev2406/PEA/internal/domain/model"
 	"gorm.io/gorm"
@@ -61,9 +62,30 @@ func (r *TechnicianRepository) FindSealByNumber(sealNumber string) (*model.Seal,
 	return &seal, nil
 }
 
-// ✅ อัปเดตข้อมูลซีล
-func (r *TechnicianRepository) UpdateSeal(seal *model.Seal) error {
-	return r.db.Save(seal).Error
+// UpdateSealImages บันทึกเฉพาะ image1/image2 ของซีล ตรวจ version ที่อ่านมาแล้วเพิ่ม version (เหมือน service.saveSeal)
+// คืน false ถ้าซีลถูกแก้ไขโดยคำขออื่นไปก่อน (ไม่เขียนทับสถานะที่เปลี่ยนไปแล้ว)
+func (r *TechnicianRepository) UpdateSealImages(seal *model.Seal) (bool, error) {
+	result := r.db.Model(&model.Seal{}).
+		Where("id = ? AND version = ?", seal.ID, seal.Version).
+		Updates(map[string]interface{}{
+			"image1":     seal.Image1,
+			"image2":     seal.Image2,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	seal.Version++
+	return true, nil
+}
+
+// Transaction รันหลายคำสั่งในธุรกรรมเดียว (บันทึกซีล + ประวัติ + log)
+func (r *TechnicianRepository) Transaction(fc func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fc)
 }
 
 // ✅ บันทึก Log
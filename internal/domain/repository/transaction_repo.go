@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/Kev2406/PEA/internal/domain/model"
 	"gorm.io/gorm"
 )
@@ -13,13 +15,63 @@ func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (r *TransactionRepository) Create(transaction *model.Transaction) error {
-	return r.db.Create(transaction).Error
+// TransactionFilter เงื่อนไขค้นหาสมุดบัญชี (ค่าว่าง/nil = ไม่กรอง)
+// Offices = nil คือทุกสำนักงาน นอกนั้นเฉพาะซีลที่อยู่ในสำนักงานเหล่านี้
+type TransactionFilter struct {
+	SealNumber string
+	ActorID    *uint
+	ActorType  string
+	Action     string
+	From       *time.Time
+	To         *time.Time
+	Offices    []string
+	Limit      int
+	Offset     int
+}
+
+// Find รายการตามเงื่อนไข ล่าสุดก่อน พร้อมจำนวนทั้งหมดก่อนแบ่งหน้า
+func (r *TransactionRepository) Find(filter TransactionFilter) ([]model.Transaction, int64, error) {
+	query := r.db.Model(&model.Transaction{})
+	if filter.SealNumber != "" {
+		query = query.Where("seal_number = ?", filter.SealNumber)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.ActorType != "" {
+		query = query.Where("actor_type = ?", filter.ActorType)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.Offices != nil {
+		seals := r.db.Model(&model.Seal{}).Select("id").Where("office_code IN ?", filter.Offices)
+		query = query.Where("seal_id IN (?)", seals)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = query.Order("created_at DESC, id DESC").Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var transactions []model.Transaction
+	err := query.Find(&transactions).Error
+	return transactions, total, err
 }
 
-func (r *TransactionRepository) GetAll() ([]model.Transaction, error) {
+// FindBySeals รายการทั้งหมดของซีลหลายเส้น เรียงตามซีลแล้วตามลำดับที่บันทึก (ใช้เล่นซ้ำสมุดบัญชี)
+func (r *TransactionRepository) FindBySeals(sealIDs []uint) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	err := r.db.Find(&transactions).Error
+	err := r.db.Where("seal_id IN ?", sealIDs).Order("seal_id, created_at, id").Find(&transactions).Error
 	return transactions, err
 }
 
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type WorkOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkOrderRepository(db *gorm.DB) *WorkOrderRepository {
+	return &WorkOrderRepository{db: db}
+}
+
+func (r *WorkOrderRepository) Create(workOrder *model.WorkOrder) error {
+	return r.db.Create(workOrder).Error
+}
+
+func (r *WorkOrderRepository) Update(workOrder *model.WorkOrder) error {
+	return r.db.Save(workOrder).Error
+}
+
+func (r *WorkOrderRepository) FindByJobNumber(jobNumber string) (*model.WorkOrder, error) {
+	var workOrder model.WorkOrder
+	if err := r.db.Where("job_number = ?", jobNumber).First(&workOrder).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบใบงานในระบบ")
+		}
+		return nil, err
+	}
+	return &workOrder, nil
+}
+
+// Search ค้นหาใบงานตามช่างผู้รับผิดชอบ / ประเภท (ค่าว่าง = ไม่กรอง) เรียงตามวันครบกำหนด
+func (r *WorkOrderRepository) Search(technicianID uint, jobType model.WorkOrderType) ([]model.WorkOrder, error) {
+	var workOrders []model.WorkOrder
+	query := r.db.Order("due_date IS NULL, due_date, created_at DESC")
+	if technicianID != 0 {
+		query = query.Where("assigned_technician_id = ?", technicianID)
+	}
+	if jobType != "" {
+		query = query.Where("job_type = ?", jobType)
+	}
+	err := query.Find(&workOrders).Error
+	return workOrders, err
+}
+
+// FindSeals ซีลทั้งหมดที่ผูกกับใบงาน
+func (r *WorkOrderRepository) FindSeals(workOrderID uint) ([]model.Seal, error) {
+	var seals []model.Seal
+	err := r.db.Where("work_order_id = ?", workOrderID).Order("seal_number").Find(&seals).Error
+	return seals, err
+}
@@ -1,7 +1,10 @@
 package migration
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/Kev2406/PEA/internal/domain/model"
 	"gorm.io/gorm"
@@ -29,6 +32,13 @@ func CreateStoreTable(db *gorm.DB) error {
 	}
 	log.Println("✅ Technician Table Migrated Successfully!")
 
+	log.Println("🔄 Migrating Office Table...")
+	if err := db.AutoMigrate(&model.Office{}); err != nil {
+		log.Printf("❌ Failed to migrate Office: %v", err)
+		return err
+	}
+	log.Println("✅ Office Table Migrated Successfully!")
+
 	log.Println("🔄 Migrating Seal Table...")
 	if err := db.AutoMigrate(&model.Seal{}); err != nil {
 		log.Printf("❌ Failed to migrate Seal: %v", err)
@@ -36,13 +46,152 @@ func CreateStoreTable(db *gorm.DB) error {
 	}
 	log.Println("✅ Seal Table Migrated Successfully!")
 
+	log.Println("🔄 Converting legacy seal statuses...")
+	if err := migrateLegacySealStatuses(db); err != nil {
+		log.Printf("❌ Failed to convert seal statuses: %v", err)
+		return err
+	}
+	log.Println("✅ Seal Statuses Converted Successfully!")
+
+	log.Println("🔄 Backfilling seal office codes...")
+	if err := backfillSealOfficeCodes(db); err != nil {
+		log.Printf("❌ Failed to backfill seal office codes: %v", err)
+		return err
+	}
+	log.Println("✅ Seal Office Codes Backfilled Successfully!")
+
+	log.Println("🔄 Migrating SealLot Table...")
+	if err := db.AutoMigrate(&model.SealLot{}); err != nil {
+		log.Printf("❌ Failed to migrate SealLot: %v", err)
+		return err
+	}
+	log.Println("✅ SealLot Table Migrated Successfully!")
+
+	log.Println("🔄 Migrating WorkOrder Table...")
+	if err := db.AutoMigrate(&model.WorkOrder{}); err != nil {
+		log.Printf("❌ Failed to migrate WorkOrder: %v", err)
+		return err
+	}
+	log.Println("✅ WorkOrder Table Migrated Successfully!")
+
+	log.Println("🔄 Migrating OfficeTransfer Tables...")
+	if err := db.AutoMigrate(&model.OfficeTransfer{}, &model.OfficeTransferItem{}); err != nil {
+		log.Printf("❌ Failed to migrate OfficeTransfer: %v", err)
+		return err
+	}
+	log.Println("✅ OfficeTransfer Tables Migrated Successfully!")
+
+	log.Println("🔄 Migrating SealStock Tables...")
+	if err := db.AutoMigrate(&model.SealStockThreshold{}, &model.SealStockAlert{}); err != nil {
+		log.Printf("❌ Failed to migrate SealStock: %v", err)
+		return err
+	}
+	log.Println("✅ SealStock Tables Migrated Successfully!")
+
+	log.Println("🔄 Migrating Notification Table...")
+	if err := db.AutoMigrate(&model.Notification{}); err != nil {
+		log.Printf("❌ Failed to migrate Notification: %v", err)
+		return err
+	}
+	log.Println("✅ Notification Table Migrated Successfully!")
+
+	log.Println("🔄 Migrating SealAging Tables...")
+	if err := db.AutoMigrate(&model.SealAgingPolicy{}, &model.SealAgingEscalation{}); err != nil {
+		log.Printf("❌ Failed to migrate SealAging: %v", err)
+		return err
+	}
+	log.Println("✅ SealAging Tables Migrated Successfully!")
+
+	log.Println("🔄 Migrating Stocktake Tables...")
+	if err := db.AutoMigrate(&model.Stocktake{}, &model.StocktakeScan{}, &model.StocktakeVariance{}); err != nil {
+		log.Printf("❌ Failed to migrate Stocktake: %v", err)
+		return err
+	}
+	log.Println("✅ Stocktake Tables Migrated Successfully!")
+
+	log.Println("🔄 Migrating JobRun Table...")
+	if err := db.AutoMigrate(&model.JobRun{}); err != nil {
+		log.Printf("❌ Failed to migrate JobRun: %v", err)
+		return err
+	}
+	log.Println("✅ JobRun Table Migrated Successfully!")
+
+	log.Println("🔄 Migrating Approval Tables...")
+	if err := db.AutoMigrate(&model.ApprovalPolicy{}, &model.ApprovalRequest{}); err != nil {
+		log.Printf("❌ Failed to migrate Approval: %v", err)
+		return err
+	}
+	log.Println("✅ Approval Tables Migrated Successfully!")
+
+	log.Println("🔄 Migrating SealNumberFormat Table...")
+	if err := db.AutoMigrate(&model.SealNumberFormat{}); err != nil {
+		log.Printf("❌ Failed to migrate SealNumberFormat: %v", err)
+		return err
+	}
+	log.Println("✅ SealNumberFormat Table Migrated Successfully!")
+
+	log.Println("🔄 Migrating IdempotencyKey Table...")
+	if err := db.AutoMigrate(&model.IdempotencyKey{}); err != nil {
+		log.Printf("❌ Failed to migrate IdempotencyKey: %v", err)
+		return err
+	}
+	log.Println("✅ IdempotencyKey Table Migrated Successfully!")
+
+	log.Println("🔄 Migrating SealIncident Table...")
+	if err := db.AutoMigrate(&model.SealIncident{}); err != nil {
+		log.Printf("❌ Failed to migrate SealIncident: %v", err)
+		return err
+	}
+	log.Println("✅ SealIncident Table Migrated Successfully!")
+
+	log.Println("🔄 Migrating Meter Tables...")
+	if err := db.AutoMigrate(&model.Meter{}, &model.MeterSeal{}); err != nil {
+		log.Printf("❌ Failed to migrate Meter: %v", err)
+		return err
+	}
+	log.Println("✅ Meter Tables Migrated Successfully!")
+
+	log.Println("🔄 Migrating SealTransfer Table...")
+	if err := db.AutoMigrate(&model.SealTransfer{}); err != nil {
+		log.Printf("❌ Failed to migrate SealTransfer: %v", err)
+		return err
+	}
+	log.Println("✅ SealTransfer Table Migrated Successfully!")
+
+	log.Println("🔄 Migrating SealStoreReturn Tables...")
+	if err := db.AutoMigrate(&model.SealStoreReturn{}, &model.SealStoreReturnItem{}); err != nil {
+		log.Printf("❌ Failed to migrate SealStoreReturn: %v", err)
+		return err
+	}
+	log.Println("✅ SealStoreReturn Tables Migrated Successfully!")
+
 	log.Println("🔄 Migrating Transaction Table...")
+	if err := migrateLegacyTransactionColumns(db); err != nil {
+		log.Printf("❌ Failed to migrate Transaction: %v", err)
+		return err
+	}
 	if err := db.AutoMigrate(&model.Transaction{}); err != nil {
 		log.Printf("❌ Failed to migrate Transaction: %v", err)
 		return err
 	}
+	if err := migrateSealHistoryToLedger(db); err != nil {
+		log.Printf("❌ Failed to migrate SealHistory into Transaction: %v", err)
+		return err
+	}
 	log.Println("✅ Transaction Table Migrated Successfully!")
 
+	// ✅ คำขอย้อนกลับอ้างอิงรายการในสมุดบัญชี ต้อง migrate หลังตาราง transactions
+	log.Println("🔄 Migrating SealReversal Table...")
+	if err := migrateSealReversalTransactionIDs(db); err != nil {
+		log.Printf("❌ Failed to migrate SealReversal: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&model.SealReversal{}); err != nil {
+		log.Printf("❌ Failed to migrate SealReversal: %v", err)
+		return err
+	}
+	log.Println("✅ SealReversal Table Migrated Successfully!")
+
 	log.Println("🔄 Migrating Log Table...")
 	if err := db.AutoMigrate(&model.Log{}); err != nil {
 		log.Printf("❌ Failed to migrate Log: %v", err)
@@ -57,3 +206,180 @@ func CreateStoreTable(db *gorm.DB) error {
 
 	return nil
 }
+
+// migrateLegacySealStatuses แปลงสถานะภาษาไทยแบบเดิม (เช่น "จ่าย") เป็นรหัสสถานะ (เช่น "issued")
+func migrateLegacySealStatuses(db *gorm.DB) error {
+	for _, status := range model.AllSealStatuses() {
+		if err := db.Model(&model.Seal{}).
+			Where("TRIM(status) = ?", status.Label()).
+			Update("status", status).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillSealOfficeCodes เติมรหัสกฟฟ. ให้ซีลเดิมที่ยังไม่ระบุสำนักงาน (สร้างก่อนมีการแยกตามสำนักงาน)
+// ไม่เช่นนั้นพนักงานสำนักงานจะมองไม่เห็นและแก้ไขซีลเหล่านี้ไม่ได้
+//  1. ซีลที่เคยจ่ายแล้ว ใช้กฟฟ. ของพนักงานที่จ่าย (seals.issued_by = users.emp_id)
+//  2. ที่เหลือใช้ LEGACY_SEAL_OFFICE_CODE ถ้าตั้งค่าไว้
+//
+// ซีลที่ยังว่างอยู่ admin ยังเห็นได้ และโอนเข้าสำนักงานได้ผ่าน office-transfers
+func backfillSealOfficeCodes(db *gorm.DB) error {
+	// คอลัมน์ที่เพิ่มภายหลังเป็น NULL ในแถวเดิม
+	if err := db.Exec("UPDATE seals SET office_code = '' WHERE office_code IS NULL").Error; err != nil {
+		return err
+	}
+	if err := db.Exec(`UPDATE seals SET office_code = users.pea_code
+		FROM users
+		WHERE seals.office_code = '' AND seals.issued_by = users.emp_id
+			AND users.pea_code <> '' AND users.deleted_at IS NULL`).Error; err != nil {
+		return err
+	}
+	if office := strings.TrimSpace(os.Getenv("LEGACY_SEAL_OFFICE_CODE")); office != "" {
+		if err := db.Model(&model.Seal{}).Where("office_code = ''").
+			Update("office_code", office).Error; err != nil {
+			return err
+		}
+	}
+
+	var remaining int64
+	if err := db.Model(&model.Seal{}).Where("office_code = ''").Count(&remaining).Error; err != nil {
+		return err
+	}
+	if remaining > 0 {
+		log.Printf("⚠️ ซีล %d เส้นยังไม่ระบุสำนักงาน (ตั้ง LEGACY_SEAL_OFFICE_CODE หรือให้ admin โอนเข้าสำนักงาน)", remaining)
+	}
+	return nil
+}
+
+// migrateLegacyTransactionColumns ลบคอลัมน์ของตาราง transactions แบบเดิม
+// user_id เดิมเป็น NOT NULL จะทำให้บันทึกสมุดบัญชีแบบใหม่ไม่ได้
+// ⚠️ ลบได้เฉพาะตอนที่ตารางยังว่าง แถวแบบเดิมไม่มีเลขซีล/สถานะ แปลงเป็นสมุดบัญชีไม่ได้
+// ถ้ามีข้อมูลอยู่ให้หยุด migration และย้ายข้อมูลออกเองก่อน
+func migrateLegacyTransactionColumns(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Transaction{}) {
+		return nil
+	}
+	var legacy []string
+	for _, column := range []string{"user_id", "timestamp", "updated_at", "deleted_at"} {
+		if db.Migrator().HasColumn(&model.Transaction{}, column) {
+			legacy = append(legacy, column)
+		}
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+
+	var rows []int
+	if err := db.Raw("SELECT 1 FROM transactions LIMIT 1").Scan(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) > 0 {
+		return fmt.Errorf("ตาราง transactions แบบเดิมมีข้อมูลอยู่ (คอลัมน์ %s) ไม่สามารถลบคอลัมน์ได้อัตโนมัติ กรุณาย้ายข้อมูลออกก่อน",
+			strings.Join(legacy, ", "))
+	}
+	for _, column := range legacy {
+		if err := db.Migrator().DropColumn(&model.Transaction{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ฟิลด์ของซีลที่สมุดบัญชีติดตาม (ตรงกับ service.sealLedgerState) แยกตามชนิดเพื่อเติมค่าว่างให้ถูกชนิด
+var (
+	sealLedgerStringFields = []string{"office_code", "employee_code", "issue_remark", "installed_serial", "return_remarks", "reserve_remark"}
+	sealLedgerNullFields   = []string{"seal_lot_id", "issued_by", "issued_to", "issued_at", "assigned_to_technician", "work_order_id",
+		"used_by", "used_at", "returned_by", "returned_at", "reserved_by", "reserved_for", "reserved_until"}
+)
+
+// migrateSealHistoryToLedger ย้ายประวัติจากตาราง seal_histories เดิมเข้าสมุดบัญชี transactions
+// ซึ่งเป็นแหล่งข้อมูลเดียวของประวัติซีล (ไม่ลบตารางเดิม เก็บไว้ตรวจสอบย้อนหลัง)
+//  1. แถวที่ยังไม่มีในสมุดบัญชี สร้างรายการใหม่ (metadata.history_id ชี้แถวเดิม, created_at เดิม)
+//  2. ค่าเดิมของซีล (metadata.previous) มาจาก snapshot ใน seal_histories.before
+//  3. reverses_id เปลี่ยนจากรหัสใน seal_histories เป็นรหัสใน transactions
+//
+// รันซ้ำได้: แถวที่ย้ายแล้วถูกข้าม
+func migrateSealHistoryToLedger(db *gorm.DB) error {
+	if !db.Migrator().HasTable("seal_histories") {
+		return nil
+	}
+	var previous []string
+	for _, field := range sealLedgerStringFields {
+		previous = append(previous, fmt.Sprintf("'%s', COALESCE(h.before::jsonb -> '%s', '\"\"'::jsonb)", field, field))
+	}
+	for _, field := range sealLedgerNullFields {
+		previous = append(previous, fmt.Sprintf("'%s', COALESCE(h.before::jsonb -> '%s', 'null'::jsonb)", field, field))
+	}
+	// jsonb_build_object รับได้ไม่เกิน 100 อาร์กิวเมนต์ ฟิลด์ที่ติดตามมี 19 ฟิลด์ (38 อาร์กิวเมนต์)
+	previousExpr := "CASE WHEN NULLIF(h.before, '') IS NULL THEN '{}'::jsonb " +
+		"ELSE jsonb_build_object('previous', jsonb_build_object(" + strings.Join(previous, ", ") + ")) END"
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`INSERT INTO transactions
+			(seal_id, seal_number, action, actor_id, actor_type, from_status, to_status, metadata, created_at)
+			SELECT h.seal_id, h.seal_number, h.action, h.actor_id, h.actor_type, h.from_status, h.to_status,
+				jsonb_build_object('history_id', h.id) ||
+				CASE WHEN COALESCE(h.remark, '') = '' THEN '{}'::jsonb ELSE jsonb_build_object('remark', h.remark) END,
+				h.created_at
+			FROM seal_histories h
+			WHERE NOT EXISTS (
+				SELECT 1 FROM transactions t WHERE t.metadata ->> 'history_id' = h.id::text
+			)`).Error; err != nil {
+			return err
+		}
+		// แถวที่โค้ดเดิมเขียนทั้งสองตารางไม่มี previous
+		if err := tx.Exec(`UPDATE transactions t SET metadata = t.metadata || ` + previousExpr + `
+			FROM seal_histories h
+			WHERE t.metadata ->> 'history_id' = h.id::text AND t.metadata -> 'previous' IS NULL`).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE transactions t
+			SET metadata = t.metadata || jsonb_build_object('reverses_id', o.id)
+			FROM seal_histories h, transactions o
+			WHERE t.metadata ->> 'history_id' = h.id::text AND h.reverses_id IS NOT NULL
+				AND o.metadata ->> 'history_id' = h.reverses_id::text`).Error
+	})
+}
+
+// migrateSealReversalTransactionIDs เปลี่ยนการอ้างอิงของคำขอย้อนกลับจาก seal_histories เป็น transactions
+// (history_id -> transaction_id, reversal_history_id -> reversal_transaction_id)
+// ถ้ามีคำขอที่หารายการในสมุดบัญชีไม่พบ จะหยุด migration แทนการทิ้งข้อมูล
+func migrateSealReversalTransactionIDs(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.SealReversal{}) || !db.Migrator().HasColumn(&model.SealReversal{}, "history_id") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		renames := []struct{ from, to string }{
+			{"history_id", "transaction_id"},
+			{"reversal_history_id", "reversal_transaction_id"},
+		}
+		for _, rename := range renames {
+			if !tx.Migrator().HasColumn(&model.SealReversal{}, rename.from) {
+				continue
+			}
+			if err := tx.Exec(fmt.Sprintf("ALTER TABLE seal_reversals ADD COLUMN IF NOT EXISTS %s bigint", rename.to)).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(fmt.Sprintf(`UPDATE seal_reversals r SET %[2]s = t.id
+				FROM transactions t
+				WHERE r.%[1]s IS NOT NULL AND t.metadata ->> 'history_id' = r.%[1]s::text`, rename.from, rename.to)).Error; err != nil {
+				return err
+			}
+			var unmapped int64
+			if err := tx.Table("seal_reversals").
+				Where(fmt.Sprintf("%s IS NOT NULL AND %s IS NULL", rename.from, rename.to)).
+				Count(&unmapped).Error; err != nil {
+				return err
+			}
+			if unmapped > 0 {
+				return fmt.Errorf("คำขอย้อนกลับ %d รายการอ้างอิง seal_histories ที่ไม่มีในสมุดบัญชี transactions (%s)", unmapped, rename.from)
+			}
+			if err := tx.Migrator().DropColumn(&model.SealReversal{}, rename.from); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// IdempotencyKeyHeader client ส่งคีย์เดิมเมื่อส่งคำขอซ้ำ (เช่น สัญญาณมือถือหลุดระหว่างรอผล)
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader ตอบ "true" เมื่อเป็นผลลัพธ์เดิมของคำขอแรก
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// ✅ IdempotencyMiddleware คำขอ POST/PUT/PATCH/DELETE ที่มี Idempotency-Key จะทำงานครั้งเดียว
+// คำขอซ้ำ (คีย์ + เนื้อหาเดิม) ได้ผลลัพธ์เดิม, คีย์เดิมแต่เนื้อหาต่าง = 422, คำขอแรกยังไม่เสร็จ = 409
+// ผลลัพธ์ 5xx / 401 / 429 ไม่ถูกเก็บ เพื่อให้ลองใหม่ได้
+// ต้องวางต่อจาก JWTMiddleware / TechnicianJWTMiddleware เพราะคีย์แยกตามผู้เรียกที่ยืนยันตัวตนแล้ว
+func IdempotencyMiddleware(idempotencyService *service.IdempotencyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get(IdempotencyKeyHeader))
+		if key == "" || !isMutatingMethod(c.Method()) {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Idempotency-Key must be at most 255 characters"})
+		}
+
+		scope, ok := idempotencyScope(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Idempotency-Key requires an authenticated caller"})
+		}
+
+		requestHash, err := requestFingerprint(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid multipart form: " + err.Error()})
+		}
+
+		record, replay, err := idempotencyService.Begin(scope, key, c.Method(), c.Path(), requestHash)
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrIdempotencyInProgress):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case err != nil:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if replay != nil {
+			c.Set(IdempotentReplayedHeader, "true")
+			if replay.ContentType != "" {
+				c.Set(fiber.HeaderContentType, replay.ContentType)
+			}
+			return c.Status(replay.ResponseStatus).Send(replay.ResponseBody)
+		}
+
+		if err := c.Next(); err != nil {
+			abortIdempotencyKey(idempotencyService, record)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError || status == fiber.StatusUnauthorized || status == fiber.StatusTooManyRequests {
+			abortIdempotencyKey(idempotencyService, record)
+			return nil
+		}
+		body := append([]byte(nil), c.Response().Body()...)
+		contentType := string(c.Response().Header.ContentType())
+		if err := idempotencyService.Complete(record, status, contentType, body); err != nil {
+			log.Println("⚠️ [IdempotencyMiddleware] Failed to store response:", err)
+		}
+		return nil
+	}
+}
+
+// requestFingerprint hash ของคำขอไว้ตรวจว่าคำขอซ้ำมีเนื้อหาเดิม
+// multipart ใช้ boundary สุ่มใหม่ทุกครั้งที่ส่ง จึง hash จากฟิลด์ที่แยกแล้ว + hash ของไฟล์แต่ละไฟล์แทน body ดิบ
+func requestFingerprint(c *fiber.Ctx) (string, error) {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method() + "\n" + c.OriginalURL() + "\n"))
+	if !strings.HasPrefix(strings.ToLower(c.Get(fiber.HeaderContentType)), fiber.MIMEMultipartForm) {
+		hash.Write(c.Body())
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range sortedKeys(form.Value) {
+		for _, value := range form.Value[name] {
+			fmt.Fprintf(hash, "field %q=%q\n", name, value)
+		}
+	}
+	for _, name := range sortedKeys(form.File) {
+		for _, header := range form.File[name] {
+			file, err := header.Open()
+			if err != nil {
+				return "", err
+			}
+			content := sha256.New()
+			_, err = io.Copy(content, file)
+			file.Close()
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(hash, "file %q=%q %d %x\n", name, header.Filename, header.Size, content.Sum(nil))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// idempotencyScope แยกคีย์ตามผู้เรียกที่ JWT middleware ยืนยันตัวตนไว้แล้ว (tech_id หรือ user_id ใน Locals)
+// ต่ออายุ token แล้วส่งซ้ำก็ยังเป็นผู้เรียกเดิม
+func idempotencyScope(c *fiber.Ctx) (string, bool) {
+	if techID, ok := c.Locals("tech_id").(uint); ok {
+		return fmt.Sprintf("technician:%d", techID), true
+	}
+	if userID, ok := c.Locals("user_id").(uint); ok {
+		return fmt.Sprintf("user:%d", userID), true
+	}
+	return "", false
+}
+
+func abortIdempotencyKey(idempotencyService *service.IdempotencyService, record *model.IdempotencyKey) {
+	if err := idempotencyService.Abort(record); err != nil {
+		log.Println("⚠️ [IdempotencyMiddleware] Failed to release key:", err)
+	}
+}
@@ -0,0 +1,24 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/Kev2406/PEA/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupApprovalRoutes คิวอนุมัติ (maker-checker) /api/approvals
+func SetupApprovalRoutes(router fiber.Router, approvalController *controller.ApprovalController) {
+	api := router.Group("/api")
+	approvals := api.Group("/approvals")
+
+	// ✅ การตั้งค่าว่า operation ใดต้องอนุมัติ (admin)
+	approvals.Get("/policies", middleware.AdminOnlyMiddleware, approvalController.GetApprovalPoliciesHandler)
+	approvals.Put("/policies/:operation", middleware.AdminOnlyMiddleware, approvalController.UpdateApprovalPolicyHandler)
+
+	// ✅ คำขอ: ดูรายการ / อนุมัติ / ไม่อนุมัติ / ผู้ขอยกเลิก
+	approvals.Get("/", approvalController.GetApprovalsHandler)
+	approvals.Get("/:id", approvalController.GetApprovalHandler)
+	approvals.Put("/:id/approve", approvalController.ApproveApprovalHandler)
+	approvals.Put("/:id/reject", approvalController.RejectApprovalHandler)
+	approvals.Put("/:id/cancel", approvalController.CancelApprovalHandler)
+}
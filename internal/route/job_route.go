@@ -0,0 +1,17 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/Kev2406/PEA/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupJobRoutes งานเบื้องหลังตามกำหนดเวลา (/api/jobs) เฉพาะ admin
+func SetupJobRoutes(router fiber.Router, jobController *controller.JobController) {
+	api := router.Group("/api")
+	jobs := api.Group("/jobs", middleware.AdminOnlyMiddleware)
+
+	jobs.Get("/", jobController.GetJobsHandler)
+	jobs.Get("/:name/runs", jobController.GetJobRunsHandler)
+	jobs.Post("/:name/run", jobController.TriggerJobHandler)
+}
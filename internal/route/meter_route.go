@@ -0,0 +1,21 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupMeterRoutes ทะเบียนมิเตอร์และประวัติซีลต่อมิเตอร์ (/api/meters)
+func SetupMeterRoutes(router fiber.Router, meterController *controller.MeterController) {
+	api := router.Group("/api")
+	meters := api.Group("/meters")
+
+	meters.Get("/", meterController.GetMetersHandler)
+	meters.Post("/", meterController.RegisterMeterHandler)
+
+	// ✅ ต้องอยู่ก่อน /:serial
+	meters.Get("/flagged", meterController.GetFlaggedInstallationsHandler)
+
+	meters.Get("/:serial", meterController.GetMeterHandler)
+	meters.Get("/:serial/seals", meterController.GetMeterSealsHandler)
+}
@@ -0,0 +1,15 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupNotificationRoutes การแจ้งเตือนถึงสำนักงาน (/api/notifications)
+func SetupNotificationRoutes(router fiber.Router, notificationController *controller.NotificationController) {
+	api := router.Group("/api")
+	notifications := api.Group("/notifications")
+
+	notifications.Get("/", notificationController.GetNotificationsHandler)
+	notifications.Put("/:id/read", notificationController.MarkNotificationReadHandler)
+}
@@ -0,0 +1,15 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupOfficeRoutes ทะเบียนสำนักงานและลำดับชั้นแม่-ลูก สำหรับขอบเขตข้อมูล (/api/offices)
+func SetupOfficeRoutes(router fiber.Router, officeController *controller.OfficeController) {
+	api := router.Group("/api")
+	offices := api.Group("/offices")
+
+	offices.Get("/", officeController.GetOfficesHandler)
+	offices.Put("/", officeController.SaveOfficeHandler)
+}
@@ -0,0 +1,16 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupSealAgingRoutes เกณฑ์และรายงานซีลที่จ่ายแล้วค้างกับช่างเกินกำหนด (/api/seal-aging)
+func SetupSealAgingRoutes(router fiber.Router, agingController *controller.SealAgingController) {
+	api := router.Group("/api")
+	aging := api.Group("/seal-aging")
+
+	aging.Get("/policy", agingController.GetPolicyHandler)
+	aging.Put("/policy", agingController.SavePolicyHandler)
+	aging.Get("/overdue", agingController.GetOverdueSealsHandler)
+}
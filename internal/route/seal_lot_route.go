@@ -0,0 +1,15 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupSealLotRoutes ล็อตซีลจากผู้ผลิตและสถิติรายล็อต (/api/seal-lots)
+func SetupSealLotRoutes(router fiber.Router, sealLotController *controller.SealLotController) {
+	api := router.Group("/api")
+	lots := api.Group("/seal-lots")
+
+	lots.Get("/", sealLotController.GetSealLotsHandler)
+	lots.Get("/:id", sealLotController.GetSealLotHandler)
+}
@@ -0,0 +1,21 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/Kev2406/PEA/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupSealNumberFormatRoutes รูปแบบเลขซีลของผู้ผลิต (/api/seal-formats)
+func SetupSealNumberFormatRoutes(router fiber.Router, formatController *controller.SealNumberFormatController) {
+	api := router.Group("/api")
+	formats := api.Group("/seal-formats")
+
+	formats.Get("/", formatController.GetFormatsHandler)
+	formats.Post("/validate", formatController.ValidateSealNumbersHandler)
+
+	// ✅ ลงทะเบียน/แก้ไข/ลบรูปแบบ (admin)
+	formats.Post("/", middleware.AdminOnlyMiddleware, formatController.CreateFormatHandler)
+	formats.Put("/:id", middleware.AdminOnlyMiddleware, formatController.UpdateFormatHandler)
+	formats.Delete("/:id", middleware.AdminOnlyMiddleware, formatController.DeleteFormatHandler)
+}
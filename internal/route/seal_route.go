@@ -17,6 +17,9 @@ func SetupSealRoutes(router fiber.Router, sealController *controller.SealControl
 	// -- 2) POST /api/seals/generate : admin can generate multiple seals
 	seal.Post("/generate", middleware.JWTMiddleware(), sealController.GenerateSealsHandler)
 
+	// -- 2.1) POST /api/seals/generate-batches : admin generates several ranges, optionally recording supplier lots
+	seal.Post("/generate-batches", middleware.JWTMiddleware(), sealController.GenerateSealsMultipleBatchesHandler)
+
 	// -- 3) PUT /api/seals/:seal_number/assign : assign a seal to a technician (by technician ID)
 	seal.Put("/:seal_number/assign", middleware.JWTMiddleware(), sealController.AssignSealToTechnicianHandler)
 
@@ -36,7 +39,7 @@ func SetupSealRoutes(router fiber.Router, sealController *controller.SealControl
 	seal.Post("/issue-multiple", middleware.JWTMiddleware(), sealController.IssueMultipleSealsHandler)
 
 	// -- 9) GET /api/seals/status/:status : get seals by status
-	seal.Get("/status/:status", sealController.GetSealsByStatusHandler)
+	seal.Get("/status/:status", middleware.JWTMiddleware(), sealController.GetSealsByStatusHandler)
 
 	// -- 10) GET /api/seals/:id/status/:status : get seal by ID & status
 	seal.Get("/:id/status/:status", middleware.JWTMiddleware(), sealController.GetSealByIDAndStatusHandler)
@@ -50,6 +53,59 @@ func SetupSealRoutes(router fiber.Router, sealController *controller.SealControl
 	// -- 13) PUT /api/seals/:seal_number/return : user returns a seal after use
 	seal.Put("/:seal_number/return", middleware.JWTMiddleware(), sealController.ReturnSealHandler)
 
+	// -- 13.1) GET /api/seals/:seal_number/transitions : actions the caller may perform next
+	seal.Get("/:seal_number/transitions", middleware.JWTMiddleware(), sealController.GetSealTransitionsHandler)
+
+	// -- 13.1.1) GET /api/seals/:seal_number/history : status history ; POST .../reversals : request an undo (admin)
+	seal.Get("/:seal_number/history", middleware.JWTMiddleware(), sealController.GetSealHistoryHandler)
+	seal.Post("/:seal_number/reversals", middleware.JWTMiddleware(), sealController.RequestSealReversalHandler)
+
+	// -- 13.2) lost / damaged incident review (admin) : must be registered before /:seal_number
+	seal.Get("/incidents", middleware.JWTMiddleware(), sealController.GetSealIncidentsHandler)
+	seal.Put("/incidents/:id/confirm", middleware.JWTMiddleware(), sealController.ConfirmSealIncidentHandler)
+	seal.Put("/incidents/:id/reject", middleware.JWTMiddleware(), sealController.RejectSealIncidentHandler)
+
+	// -- 13.3) technician-to-technician transfers (admin) : must be registered before /:seal_number
+	seal.Post("/transfers", middleware.JWTMiddleware(), sealController.InitiateSealTransferHandler)
+	seal.Get("/transfers", middleware.JWTMiddleware(), sealController.GetSealTransfersHandler)
+	seal.Put("/transfers/:id/cancel", middleware.JWTMiddleware(), sealController.CancelSealTransferHandler)
+
+	// -- 13.4) technician store returns (storekeeper check-in) : must be registered before /:seal_number
+	seal.Get("/store-returns", middleware.JWTMiddleware(), sealController.GetSealStoreReturnsHandler)
+	seal.Put("/store-returns/:id/confirm", middleware.JWTMiddleware(), sealController.ConfirmSealStoreReturnHandler)
+	seal.Put("/store-returns/:id/reject", middleware.JWTMiddleware(), sealController.RejectSealStoreReturnHandler)
+
+	// -- 13.5) inter-office dispatch / receive : must be registered before /:seal_number
+	seal.Post("/office-transfers", middleware.JWTMiddleware(), sealController.DispatchSealsToOfficeHandler)
+	seal.Get("/office-transfers", middleware.JWTMiddleware(), sealController.GetOfficeTransfersHandler)
+	seal.Put("/office-transfers/:id/receive", middleware.JWTMiddleware(), sealController.ReceiveOfficeTransferHandler)
+	seal.Put("/office-transfers/:id/recall", middleware.JWTMiddleware(), sealController.RecallOfficeTransferHandler)
+
+	// -- 13.6) reservations for planned jobs : must be registered before /:seal_number
+	seal.Post("/reservations", middleware.JWTMiddleware(), sealController.ReserveSealsHandler)
+	seal.Get("/reservations", middleware.JWTMiddleware(), sealController.GetSealReservationsHandler)
+	seal.Put("/reservations/release", middleware.JWTMiddleware(), sealController.ReleaseSealReservationsHandler)
+
+	// -- 13.7) stocktakes (physical count) : must be registered before /:seal_number
+	seal.Post("/stocktakes", middleware.JWTMiddleware(), sealController.OpenStocktakeHandler)
+	seal.Get("/stocktakes", middleware.JWTMiddleware(), sealController.GetStocktakesHandler)
+	seal.Get("/stocktakes/:id", middleware.JWTMiddleware(), sealController.GetStocktakeHandler)
+	seal.Post("/stocktakes/:id/scans", middleware.JWTMiddleware(), sealController.ScanStocktakeSealsHandler)
+	seal.Put("/stocktakes/:id/close", middleware.JWTMiddleware(), sealController.CloseStocktakeHandler)
+	seal.Get("/stocktakes/:id/variance", middleware.JWTMiddleware(), sealController.GetStocktakeVarianceHandler)
+	seal.Post("/stocktakes/:id/adjustments", middleware.JWTMiddleware(), sealController.PostStocktakeAdjustmentsHandler)
+
+	// -- 13.8) reversal review by a second admin : must be registered before /:seal_number
+	seal.Get("/reversals", middleware.JWTMiddleware(), sealController.GetSealReversalsHandler)
+	seal.Put("/reversals/:id/approve", middleware.JWTMiddleware(), sealController.ApproveSealReversalHandler)
+	seal.Put("/reversals/:id/reject", middleware.JWTMiddleware(), sealController.RejectSealReversalHandler)
+
+	// -- 13.9) GET /api/seals/range-analysis : existing / missing / overlapping lots in a number range : must be registered before /:seal_number
+	seal.Get("/range-analysis", middleware.JWTMiddleware(), sealController.AnalyzeSealRangeHandler)
+
+	// -- 13.10) POST /api/seals/ledger-check : replay the transaction ledger and report/repair drift (admin)
+	seal.Post("/ledger-check", middleware.JWTMiddleware(), sealController.CheckSealLedgerHandler)
+
 	// -- 14) GET /api/seals/:seal_number : get a single seal by number (wildcard route - put last!)
 	seal.Get("/:seal_number", middleware.JWTMiddleware(), sealController.GetSealHandler)
 
@@ -58,6 +114,11 @@ func SetupSealRoutes(router fiber.Router, sealController *controller.SealControl
 
 	// -- 16) POST /api/seals/assign-by-techcode : assign seals by technician_code (ฟีเจอร์ใหม่)
 	seal.Post("/assign-by-techcode", middleware.JWTMiddleware(), sealController.AssignSealsByTechCodeHandler)
+	seal.Put("/cancel", middleware.JWTMiddleware(), sealController.CancelSealsHandler) // คืนหลายเส้น (maker-checker)
 	seal.Put("/:seal_number/cancel", middleware.JWTMiddleware(), sealController.CancelSealHandler)
+	seal.Put("/:seal_number/void", middleware.JWTMiddleware(), sealController.VoidSealHandler)
+
+	// -- 17) POST /api/seals/replace : remove the old seal and install a replacement on a meter
+	seal.Post("/replace", middleware.JWTMiddleware(), sealController.ReplaceSealHandler)
 
 }
@@ -0,0 +1,18 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupSealStockRoutes ขั้นต่ำซีลพร้อมใช้งานและการแจ้งเตือนซีลใกล้หมด (/api/seal-stock)
+func SetupSealStockRoutes(router fiber.Router, stockController *controller.SealStockController) {
+	api := router.Group("/api")
+	stock := api.Group("/seal-stock")
+
+	stock.Get("/thresholds", stockController.GetThresholdsHandler)
+	stock.Put("/thresholds", stockController.SaveThresholdHandler)
+	stock.Delete("/thresholds/:id", stockController.DeleteThresholdHandler)
+	stock.Get("/low", stockController.GetLowStockHandler)
+	stock.Get("/alerts", stockController.GetAlertsHandler)
+}
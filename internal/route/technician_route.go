@@ -6,7 +6,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupTechnicianRoutes(router fiber.Router, techController *controller.TechnicianController) {
+// SetupTechnicianRoutes idempotency ทำงานหลังยืนยันตัวตน (route สาธารณะไม่รองรับ Idempotency-Key)
+func SetupTechnicianRoutes(router fiber.Router, techController *controller.TechnicianController, idempotency fiber.Handler) {
 	// 🔹 Group สำหรับ Technician (ใช้ /api/technician)
 	tech := router.Group("/api/technician")
 
@@ -16,17 +17,43 @@ func SetupTechnicianRoutes(router fiber.Router, techController *controller.Techn
 	tech.Post("/import", techController.ImportTechniciansHandler) // Import รายชื่อช่าง
 	tech.Get("/list", techController.GetAllTechniciansHandler)    // ดูรายชื่อช่างทั้งหมด
 
-	tech.Put("/update/:id", techController.UpdateTechnicianHandler)    // อัปเดตข้อมูลช่าง
-	tech.Delete("/delete/:id", techController.DeleteTechnicianHandler) // ลบข้อมูลช่าง
+	tech.Put("/update/:id", techController.UpdateTechnicianHandler) // อัปเดตข้อมูลช่าง
+
+	// ✅ ลบข้อมูลช่าง (admin เท่านั้น และต้องผ่านการอนุมัติถ้าตั้งค่าไว้)
+	tech.Delete("/delete/:id", middleware.JWTMiddleware(), idempotency, techController.DeleteTechnicianHandler)
 
 	// 🔹 Protected Routes (ต้องใช้ JWT)
-	protectedTech := tech.Group("", middleware.TechnicianJWTMiddleware())
+	protectedTech := tech.Group("", middleware.TechnicianJWTMiddleware(), idempotency)
 
 	// ✅ Routes ที่เกี่ยวกับ Seal (เฉพาะช่างที่มีสิทธิ์)
 	protectedTech.Get("/seals", techController.GetAssignedSealsHandler)               // ดูซีลที่ได้รับมอบหมาย
 	protectedTech.Put("/seals/install", techController.InstallSealHandler)            // 🔥 เปลี่ยนเป็น POST รองรับการอัปโหลด
 	protectedTech.Put("/seals/return/:seal_number", techController.ReturnSealHandler) // คืนซีล
 
+	// ✅ action ถัดไปที่ช่างทำกับซีลได้ (ตาราง transition)
+	protectedTech.Get("/seals/:seal_number/transitions", techController.GetSealTransitionsHandler)
+
+	// ✅ ถอดซีลเดิม + ติดตั้งซีลใหม่ในครั้งเดียว
+	protectedTech.Post("/seals/replace", techController.ReplaceSealHandler)
+
+	// ✅ แจ้งซีลสูญหาย/ชำรุด (รอ admin ยืนยัน)
+	protectedTech.Post("/seals/report", techController.ReportSealIncidentHandler)
+
+	// ✅ โอนซีลระหว่างช่าง (ผู้รับต้องยอมรับก่อนจึงย้ายผู้ถือซีล)
+	protectedTech.Post("/seals/transfers", techController.InitiateSealTransferHandler)
+	protectedTech.Get("/seals/transfers", techController.GetSealTransfersHandler)
+	protectedTech.Put("/seals/transfers/accept", techController.AcceptSealTransfersHandler)
+	protectedTech.Put("/seals/transfers/reject", techController.RejectSealTransfersHandler)
+	protectedTech.Put("/seals/transfers/:id/cancel", techController.CancelSealTransferHandler)
+
+	// ✅ ขอคืนซีลที่ยังไม่ได้ใช้เข้าคลัง (รอคลังตรวจรับ)
+	protectedTech.Post("/seals/store-returns", techController.RequestSealStoreReturnHandler)
+	protectedTech.Get("/seals/store-returns", techController.GetSealStoreReturnsHandler)
+	protectedTech.Put("/seals/store-returns/:id/cancel", techController.CancelSealStoreReturnHandler)
+
+	// ✅ ใบงานที่ได้รับมอบหมาย
+	protectedTech.Get("/work-orders", techController.GetWorkOrdersHandler)
+
 	// ✅ **เพิ่ม API สำหรับอัปโหลดรูปซีล (แยกจาก Install)**
 	protectedTech.Post("/seals/upload-images", techController.UploadSealImagesHandler) // อัปโหลดรูปสำหรับซีลที่ติดตั้งแล้ว
 }
@@ -0,0 +1,22 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/Kev2406/PEA/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupTransactionRoutes สมุดบัญชีการเคลื่อนไหวของซีล (อ่านอย่างเดียว เขียนโดยการเปลี่ยนสถานะซีล)
+func SetupTransactionRoutes(router fiber.Router, transactionController *controller.TransactionController) {
+	api := router.Group("/api")
+	transactions := api.Group("/transactions")
+
+	// ✅ ค้นหาตามซีล ผู้ทำ action และช่วงวันที่
+	transactions.Get("/", middleware.JWTMiddleware(), transactionController.GetTransactionsHandler)
+
+	// ✅ ประวัติของซีลหนึ่งเส้น
+	transactions.Get("/seal/:seal_number", middleware.JWTMiddleware(), transactionController.GetSealTransactionsHandler)
+
+	// ✅ ดึงตาม ID (Keep this LAST to avoid conflicts)
+	transactions.Get("/:id", middleware.JWTMiddleware(), transactionController.GetTransactionByIDHandler)
+}
@@ -0,0 +1,19 @@
+package route
+
+import (
+	"github.com/Kev2406/PEA/internal/controller"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupWorkOrderRoutes ใบงานและซีลที่ใช้ในแต่ละใบงาน (/api/work-orders)
+func SetupWorkOrderRoutes(router fiber.Router, workOrderController *controller.WorkOrderController) {
+	api := router.Group("/api")
+	workOrders := api.Group("/work-orders")
+
+	workOrders.Get("/", workOrderController.GetWorkOrdersHandler)
+	workOrders.Post("/", workOrderController.CreateWorkOrderHandler)
+
+	workOrders.Get("/:job_number", workOrderController.GetWorkOrderHandler)
+	workOrders.Put("/:job_number", workOrderController.UpdateWorkOrderHandler)
+	workOrders.Get("/:job_number/seals", workOrderController.GetWorkOrderSealsHandler)
+}
@@ -0,0 +1,67 @@
+package service
+
+// -------------------------------------------------------------------
+// Operation ที่ต้องผ่านการอนุมัติ (maker-checker) และคำขอของแต่ละ operation
+// controller ตรวจสอบคำขอก่อน แล้วส่งเข้าคิวเมื่อ RequiresApproval เป็น true
+// เมื่ออนุมัติ executor ที่ลงทะเบียนไว้จะเรียก service เดิมด้วยคำขอชุดเดียวกัน
+// -------------------------------------------------------------------
+
+const (
+	ApprovalOpUpdatePolicy        = "approval.update_policy"
+	ApprovalOpGenerateSealBatches = "seal.generate_batches"
+	ApprovalOpCancelSeals         = "seal.cancel_bulk"
+	ApprovalOpDeleteTechnician    = "technician.delete"
+	ApprovalOpDeleteLog           = "log.delete"
+)
+
+// CancelSealsRequest คำขอคืนซีลหลายเส้นกลับเข้าคลัง
+type CancelSealsRequest struct {
+	SealNumbers SealNumberList `json:"seal_numbers"`
+}
+
+// DeleteTechnicianRequest คำขอลบข้อมูลช่าง
+type DeleteTechnicianRequest struct {
+	TechnicianID uint `json:"technician_id"`
+}
+
+// DeleteLogRequest คำขอลบ log
+type DeleteLogRequest struct {
+	LogID uint `json:"log_id"`
+}
+
+// RegisterApprovalOperations ลงทะเบียน operation ของแต่ละ service กับคิวอนุมัติ
+func RegisterApprovalOperations(
+	approvals *ApprovalService,
+	sealService *SealService,
+	technicianService *TechnicianService,
+	logService *LogService,
+) error {
+	operations := []struct {
+		name    string
+		label   string
+		execute ApprovalExecutor
+	}{
+		{ApprovalOpGenerateSealBatches, "สร้างซีลหลายชุด", ApprovalHandler(
+			func(request GenerateSealBatchesRequest, requester Actor) (interface{}, error) {
+				return sealService.GenerateSealBatches(request, requester.ID, requester.Office)
+			})},
+		{ApprovalOpCancelSeals, "คืนซีลหลายเส้นเข้าคลัง", ApprovalHandler(
+			func(request CancelSealsRequest, requester Actor) (interface{}, error) {
+				return request, sealService.CancelSeals(request.SealNumbers, requester.ID)
+			})},
+		{ApprovalOpDeleteTechnician, "ลบข้อมูลช่าง", ApprovalHandler(
+			func(request DeleteTechnicianRequest, requester Actor) (interface{}, error) {
+				return request, technicianService.DeleteTechnician(request.TechnicianID)
+			})},
+		{ApprovalOpDeleteLog, "ลบ log", ApprovalHandler(
+			func(request DeleteLogRequest, requester Actor) (interface{}, error) {
+				return request, logService.DeleteLog(request.LogID)
+			})},
+	}
+	for _, op := range operations {
+		if err := approvals.Register(op.name, op.label, op.execute); err != nil {
+			return err
+		}
+	}
+	return nil
+}
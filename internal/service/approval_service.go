@@ -0,0 +1,413 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// -------------------------------------------------------------------
+// Approvals (maker-checker): operation ที่ตั้งค่าไว้จะไม่ทำทันที แต่เข้าคิวรออนุมัติ
+// ผู้ใช้อีกคนที่มี role ตามที่กำหนดอนุมัติ/ไม่อนุมัติ แล้วจึงเรียก service จริง
+// คำขอเต็ม (payload) การตัดสิน และผลการดำเนินการถูกเก็บไว้ใน approval_requests
+// -------------------------------------------------------------------
+
+var ErrApprovalNotFound = errors.New("ไม่พบคำขออนุมัติ")
+
+// DefaultApproverRole role ผู้อนุมัติเมื่อยังไม่ได้ตั้งค่า operation
+const DefaultApproverRole = "admin"
+
+// ApprovalExecutor ดำเนินการตามคำขอที่อนุมัติแล้ว (payload คือ JSON ของคำขอเดิม, requester คือผู้ขอ)
+type ApprovalExecutor func(payload json.RawMessage, requester Actor) (interface{}, error)
+
+// ApprovalHandler แปลง payload เป็นชนิดของคำขอก่อนส่งให้ run
+func ApprovalHandler[T any](run func(request T, requester Actor) (interface{}, error)) ApprovalExecutor {
+	return func(payload json.RawMessage, requester Actor) (interface{}, error) {
+		var request T
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, fmt.Errorf("ข้อมูลคำขอเสียหาย: %v", err)
+		}
+		return run(request, requester)
+	}
+}
+
+type approvalOperation struct {
+	name    string
+	label   string
+	execute ApprovalExecutor
+}
+
+// ApprovalOperationInfo operation ที่ลงทะเบียนไว้พร้อมการตั้งค่าปัจจุบัน
+type ApprovalOperationInfo struct {
+	Operation    string     `json:"operation"`
+	Label        string     `json:"label"`
+	Enabled      bool       `json:"enabled"`
+	ApproverRole string     `json:"approver_role"`
+	MinQuantity  int        `json:"min_quantity"`
+	UpdatedBy    uint       `json:"updated_by,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+type ApprovalService struct {
+	repo *repository.ApprovalRepository
+	db   *gorm.DB
+
+	mu         sync.RWMutex
+	operations map[string]*approvalOperation
+}
+
+func NewApprovalService(repo *repository.ApprovalRepository, db *gorm.DB) *ApprovalService {
+	s := &ApprovalService{repo: repo, db: db, operations: map[string]*approvalOperation{}}
+	// การแก้ไขการตั้งค่าก็ต้องผ่านการอนุมัติเช่นกัน (เมื่อเปิดใช้) ไม่เช่นนั้น admin คนเดียวปิดการอนุมัติได้เอง
+	_ = s.Register(ApprovalOpUpdatePolicy, "แก้ไขการตั้งค่าการอนุมัติ", ApprovalHandler(s.applyPolicy))
+	return s
+}
+
+// Register เพิ่ม operation ที่ต้องผ่านการอนุมัติ พร้อมฟังก์ชันที่ทำงานเมื่ออนุมัติแล้ว
+func (s *ApprovalService) Register(name string, label string, execute ApprovalExecutor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.operations[name]; exists {
+		return fmt.Errorf("operation %s ถูกลงทะเบียนไว้แล้ว", name)
+	}
+	s.operations[name] = &approvalOperation{name: name, label: label, execute: execute}
+	return nil
+}
+
+func (s *ApprovalService) operation(name string) (*approvalOperation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	op, ok := s.operations[name]
+	if !ok {
+		return nil, fmt.Errorf("ไม่รู้จัก operation: %s", name)
+	}
+	return op, nil
+}
+
+// policy การตั้งค่าของ operation
+// ค่าเริ่มต้น (ยังไม่มีแถว) = ไม่ต้องอนุมัติ เพื่อไม่ให้ระบบที่มี admin คนเดียวล็อกตัวเองออก
+// ต้องเปิดการอนุมัติเองต่อ operation เมื่อมีผู้อนุมัติคนที่สองแล้ว
+func (s *ApprovalService) policy(name string) (*model.ApprovalPolicy, error) {
+	policy, err := s.repo.FindPolicy(name)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &model.ApprovalPolicy{Operation: name, Enabled: false, ApproverRole: DefaultApproverRole}
+	}
+	return policy, nil
+}
+
+// RequiresApproval operation นี้ (จำนวน quantity รายการ) ต้องเข้าคิวรออนุมัติหรือไม่
+func (s *ApprovalService) RequiresApproval(operation string, quantity int) (bool, error) {
+	if _, err := s.operation(operation); err != nil {
+		return false, err
+	}
+	policy, err := s.policy(operation)
+	if err != nil {
+		return false, err
+	}
+	return policy.Enabled && quantity >= policy.MinQuantity, nil
+}
+
+// Submit เข้าคิวรออนุมัติ payload ต้องเป็นคำขอที่ตรวจสอบแล้ว เพราะจะถูกส่งให้ executor ตามเดิม
+func (s *ApprovalService) Submit(operation string, payload interface{}, quantity int, summary string, actor Actor) (*model.ApprovalRequest, error) {
+	if !actor.IsUser() {
+		return nil, errors.New("เฉพาะพนักงานเท่านั้นที่ส่งคำขออนุมัติได้")
+	}
+	op, err := s.operation(operation)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &model.ApprovalRequest{
+		Operation:       op.name,
+		Summary:         summary,
+		Quantity:        quantity,
+		Payload:         raw,
+		Status:          model.ApprovalPending,
+		RequestedBy:     actor.ID,
+		RequesterRole:   actor.Role,
+		RequesterOffice: actor.Office,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(request).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ขออนุมัติ '%s' คำขอ #%d: %s", op.label, request.ID, summary),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// GetApprovals รายการคำขอ ผู้ที่ไม่ใช่ admin เห็นเฉพาะคำขอของตัวเอง
+func (s *ApprovalService) GetApprovals(status model.ApprovalStatus, operation string, actor Actor) ([]model.ApprovalRequest, error) {
+	var requestedBy *uint
+	if !actor.IsAdmin() {
+		requestedBy = &actor.ID
+	}
+	return s.repo.FindAll(status, operation, requestedBy)
+}
+
+func (s *ApprovalService) GetApproval(id uint, actor Actor) (*model.ApprovalRequest, error) {
+	request, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrApprovalNotFound
+	}
+	if !actor.IsAdmin() && request.RequestedBy != actor.ID {
+		return nil, ErrApprovalNotFound
+	}
+	return request, nil
+}
+
+// loadReviewableApproval คำขอที่รออนุมัติ และผู้ตัดสินมีสิทธิ์ (role ตรง และไม่ใช่ผู้ขอเอง)
+func (s *ApprovalService) loadReviewableApproval(id uint, actor Actor) (*model.ApprovalRequest, *approvalOperation, error) {
+	request, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, nil, ErrApprovalNotFound
+	}
+	if request.Status != model.ApprovalPending {
+		return nil, nil, fmt.Errorf("คำขอ #%d ไม่ได้อยู่ในสถานะรออนุมัติ", request.ID)
+	}
+	op, err := s.operation(request.Operation)
+	if err != nil {
+		return nil, nil, err
+	}
+	policy, err := s.policy(request.Operation)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !actor.IsUser() || actor.Role != policy.ApproverRole {
+		return nil, nil, fmt.Errorf("ต้องเป็นผู้ใช้ role '%s' จึงจะตัดสินคำขอนี้ได้", policy.ApproverRole)
+	}
+	if request.RequestedBy == actor.ID {
+		return nil, nil, errors.New("ผู้ขอไม่สามารถอนุมัติคำขอของตัวเองได้ ต้องเป็นผู้ใช้คนอื่น")
+	}
+	return request, op, nil
+}
+
+// ApproveApproval อนุมัติแล้วดำเนินการทันทีในนามผู้ขอ ผลลัพธ์/ข้อผิดพลาดถูกบันทึกไว้กับคำขอ
+func (s *ApprovalService) ApproveApproval(id uint, actor Actor, remark string) (*model.ApprovalRequest, error) {
+	request, op, err := s.loadReviewableApproval(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.review(request, op, model.ApprovalApproved, actor, remark); err != nil {
+		return nil, err
+	}
+
+	requester := UserActor(request.RequestedBy, request.RequesterRole).WithOffice(request.RequesterOffice)
+	result, execErr := runApprovalExecutor(op, request.Payload, requester)
+
+	executedAt := time.Now()
+	request.ExecutedAt = &executedAt
+	if execErr != nil {
+		request.Status = model.ApprovalFailed
+		request.Error = execErr.Error()
+	} else {
+		request.Status = model.ApprovalExecuted
+		if result != nil {
+			if raw, err := json.Marshal(result); err == nil {
+				request.Result = raw
+			}
+		}
+	}
+	if err := s.repo.Update(request); err != nil {
+		return nil, err
+	}
+	if execErr != nil {
+		log.Printf("❌ Approval #%d (%s) failed: %v", request.ID, request.Operation, execErr)
+	}
+	return request, nil
+}
+
+// RejectApproval ไม่อนุมัติ ไม่มีการดำเนินการใด ๆ
+func (s *ApprovalService) RejectApproval(id uint, actor Actor, remark string) (*model.ApprovalRequest, error) {
+	request, op, err := s.loadReviewableApproval(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.review(request, op, model.ApprovalRejected, actor, remark); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// review บันทึกการตัดสิน (กันสองคนตัดสินคำขอเดียวกันพร้อมกัน)
+func (s *ApprovalService) review(request *model.ApprovalRequest, op *approvalOperation, status model.ApprovalStatus, actor Actor, remark string) error {
+	now := time.Now()
+	remark = strings.TrimSpace(remark)
+	verb := "อนุมัติ"
+	if status == model.ApprovalRejected {
+		verb = "ไม่อนุมัติ"
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		claimed, err := s.repo.ClaimPending(tx, request.ID, status, actor.ID, remark, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return fmt.Errorf("คำขอ #%d ถูกตัดสินไปแล้ว", request.ID)
+		}
+		action := fmt.Sprintf("%s '%s' คำขอ #%d ของผู้ใช้ ID %d", verb, op.label, request.ID, request.RequestedBy)
+		if remark != "" {
+			action += " - หมายเหตุ: " + remark
+		}
+		return tx.Create(&model.Log{UserID: actor.ID, Action: action}).Error
+	})
+	if err != nil {
+		return err
+	}
+	request.Status = status
+	request.ReviewedBy = &actor.ID
+	request.ReviewedAt = &now
+	request.ReviewRemark = remark
+	return nil
+}
+
+// CancelApproval ผู้ขอยกเลิกคำขอของตัวเองที่ยังรออนุมัติ
+func (s *ApprovalService) CancelApproval(id uint, actor Actor) (*model.ApprovalRequest, error) {
+	request, err := s.repo.FindByID(id)
+	if err != nil || request.RequestedBy != actor.ID {
+		return nil, ErrApprovalNotFound
+	}
+	if request.Status != model.ApprovalPending {
+		return nil, fmt.Errorf("คำขอ #%d ไม่ได้อยู่ในสถานะรออนุมัติ", request.ID)
+	}
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		claimed, err := s.repo.ClaimPending(tx, request.ID, model.ApprovalCancelled, actor.ID, "ผู้ขอยกเลิก", now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return fmt.Errorf("คำขอ #%d ถูกตัดสินไปแล้ว", request.ID)
+		}
+		return tx.Create(&model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ยกเลิกคำขออนุมัติ #%d (%s)", request.ID, request.Operation),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	request.Status = model.ApprovalCancelled
+	request.ReviewedBy = &actor.ID
+	request.ReviewedAt = &now
+	return request, nil
+}
+
+// runApprovalExecutor กัน panic ของ executor ไม่ให้คำขอค้างอยู่ในสถานะ approved
+func runApprovalExecutor(op *approvalOperation, payload json.RawMessage, requester Actor) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return op.execute(payload, requester)
+}
+
+// -------------------------------------------------------------------
+// การตั้งค่าการอนุมัติของแต่ละ operation
+// -------------------------------------------------------------------
+
+// ApprovalPolicyRequest คำขอแก้ไขการตั้งค่าของ operation
+type ApprovalPolicyRequest struct {
+	Operation    string `json:"operation"`
+	Enabled      bool   `json:"enabled"`
+	ApproverRole string `json:"approver_role"`
+	MinQuantity  int    `json:"min_quantity"`
+}
+
+// ValidatePolicy ตรวจสอบคำขอแก้ไขการตั้งค่า (เติม role เริ่มต้นให้ถ้าไม่ระบุ)
+func (s *ApprovalService) ValidatePolicy(request *ApprovalPolicyRequest) error {
+	if _, err := s.operation(request.Operation); err != nil {
+		return err
+	}
+	request.ApproverRole = strings.TrimSpace(request.ApproverRole)
+	if request.ApproverRole == "" {
+		request.ApproverRole = DefaultApproverRole
+	}
+	if request.MinQuantity < 0 {
+		return errors.New("min_quantity ต้องไม่ติดลบ")
+	}
+	return nil
+}
+
+// GetPolicies operation ทั้งหมดที่ลงทะเบียนไว้พร้อมการตั้งค่าปัจจุบัน
+func (s *ApprovalService) GetPolicies() ([]ApprovalOperationInfo, error) {
+	saved, err := s.repo.FindPolicies()
+	if err != nil {
+		return nil, err
+	}
+	byOperation := make(map[string]model.ApprovalPolicy, len(saved))
+	for _, policy := range saved {
+		byOperation[policy.Operation] = policy
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	infos := make([]ApprovalOperationInfo, 0, len(s.operations))
+	for _, op := range s.operations {
+		info := ApprovalOperationInfo{Operation: op.name, Label: op.label, Enabled: false, ApproverRole: DefaultApproverRole}
+		if policy, ok := byOperation[op.name]; ok {
+			updatedAt := policy.UpdatedAt
+			info.Enabled = policy.Enabled
+			info.ApproverRole = policy.ApproverRole
+			info.MinQuantity = policy.MinQuantity
+			info.UpdatedBy = policy.UpdatedBy
+			info.UpdatedAt = &updatedAt
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Operation < infos[j].Operation })
+	return infos, nil
+}
+
+// UpdatePolicy แก้ไขการตั้งค่าทันทีเมื่อการแก้ไขการตั้งค่าไม่ต้องอนุมัติ (ใช้เมื่อ RequiresApproval เป็น false)
+func (s *ApprovalService) UpdatePolicy(request ApprovalPolicyRequest, actor Actor) (*model.ApprovalPolicy, error) {
+	if err := s.ValidatePolicy(&request); err != nil {
+		return nil, err
+	}
+	return s.savePolicy(request, actor.ID)
+}
+
+func (s *ApprovalService) applyPolicy(request ApprovalPolicyRequest, requester Actor) (interface{}, error) {
+	if err := s.ValidatePolicy(&request); err != nil {
+		return nil, err
+	}
+	return s.savePolicy(request, requester.ID)
+}
+
+func (s *ApprovalService) savePolicy(request ApprovalPolicyRequest, userID uint) (*model.ApprovalPolicy, error) {
+	policy := &model.ApprovalPolicy{
+		Operation:    request.Operation,
+		Enabled:      request.Enabled,
+		ApproverRole: request.ApproverRole,
+		MinQuantity:  request.MinQuantity,
+		UpdatedBy:    userID,
+	}
+	if err := s.repo.SavePolicy(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// ทดสอบการแปลงกำหนดการ cron และการหาเวลาถัดไป (ไม่ต้องใช้ฐานข้อมูล)
+
+func TestCronScheduleNext(t *testing.T) {
+	// วันเสาร์ 17 ต.ค. 2026 10:30:45
+	from := time.Date(2026, time.October, 17, 10, 30, 45, 0, time.UTC)
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", from, at(2026, time.October, 17, 10, 31)},
+		{"hourly", "@hourly", from, at(2026, time.October, 17, 11, 0)},
+		{"daily", "@daily", from, at(2026, time.October, 18, 0, 0)},
+		{"monthly", "@monthly", from, at(2026, time.November, 1, 0, 0)},
+		{"step", "*/15 * * * *", from, at(2026, time.October, 17, 10, 45)},
+		{"range with step", "5-10/2 8 * * *", from, at(2026, time.October, 18, 8, 5)},
+		{"list", "0,50 10 * * *", from, at(2026, time.October, 17, 10, 50)},
+		{"same minute is not next", "30 10 * * *", from, at(2026, time.October, 18, 10, 30)},
+		{"weekdays skip weekend", "0 9 * * 1-5", from, at(2026, time.October, 19, 9, 0)},
+		{"sunday as 7", "0 0 * * 7", from, at(2026, time.October, 18, 0, 0)},
+		{"sunday as 0", "0 0 * * 0", from, at(2026, time.October, 18, 0, 0)},
+		{"day 31 skips short months", "0 0 31 * *", at(2026, time.November, 1, 0, 0), at(2026, time.December, 31, 0, 0)},
+		{"leap day", "0 0 29 2 *", from, at(2028, time.February, 29, 0, 0)},
+		{"year rollover", "0 0 1 1 *", from, at(2027, time.January, 1, 0, 0)},
+		{"day of month or day of week", "0 0 1 * 1", from, at(2026, time.October, 19, 0, 0)},
+		{"never matches", "0 0 30 2 *", from, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCronSchedule(%q): %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%q, %s) = %s, want %s", tt.spec, tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"@yearly",
+	} {
+		if _, err := ParseCronSchedule(spec); err == nil {
+			t.Errorf("ParseCronSchedule(%q): expected error", spec)
+		}
+	}
+}
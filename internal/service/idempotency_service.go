@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("Idempotency-Key นี้ถูกใช้กับคำขออื่นแล้ว กรุณาใช้คีย์ใหม่")
+	ErrIdempotencyInProgress = errors.New("คำขอที่ใช้ Idempotency-Key นี้กำลังทำงานอยู่ กรุณาลองใหม่อีกครั้ง")
+)
+
+const (
+	// IdempotencyKeyTTL เก็บผลลัพธ์ไว้ตอบคำขอซ้ำนานเท่าไร
+	IdempotencyKeyTTL = 24 * time.Hour
+	// idempotencyStaleAfter คีย์ที่ค้าง processing นานเกินนี้ถือว่าคำขอแรกล้มไปแล้ว
+	idempotencyStaleAfter = 5 * time.Minute
+)
+
+// IdempotencyService จองคีย์ให้คำขอแรก และคืนผลลัพธ์เดิมให้คำขอที่ส่งซ้ำ
+type IdempotencyService struct {
+	repo *repository.IdempotencyRepository
+}
+
+func NewIdempotencyService(repo *repository.IdempotencyRepository) *IdempotencyService {
+	return &IdempotencyService{repo: repo}
+}
+
+// Begin จองคีย์ (scope + key) ให้คำขอนี้
+//   - คืน record: เป็นคำขอแรก ให้ทำงานต่อแล้วเรียก Complete หรือ Abort
+//   - คืน replay: เคยทำสำเร็จแล้ว ให้ตอบผลลัพธ์เดิม
+//   - ErrIdempotencyKeyReused: คีย์เดิมแต่เนื้อหาคำขอต่างกัน
+//   - ErrIdempotencyInProgress: คำขอแรกยังทำงานไม่เสร็จ
+func (s *IdempotencyService) Begin(scope, key, method, path, requestHash string) (*model.IdempotencyKey, *model.IdempotencyKey, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		now := time.Now()
+		record := &model.IdempotencyKey{
+			Scope:       scope,
+			Key:         key,
+			Method:      method,
+			Path:        path,
+			RequestHash: requestHash,
+			Status:      model.IdempotencyProcessing,
+			ExpiresAt:   now.Add(IdempotencyKeyTTL),
+		}
+		claimed, err := s.repo.Claim(record)
+		if err != nil {
+			return nil, nil, err
+		}
+		if claimed {
+			return record, nil, nil
+		}
+
+		existing, err := s.repo.Find(scope, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if existing == nil {
+			continue // ถูกลบไประหว่างนั้น ลองจองใหม่
+		}
+		if existing.ExpiresAt.Before(now) {
+			if err := s.repo.Delete(existing.ID); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		if existing.RequestHash != requestHash {
+			return nil, nil, ErrIdempotencyKeyReused
+		}
+		if existing.Status == model.IdempotencyCompleted {
+			return nil, existing, nil
+		}
+		stale, err := s.repo.DeleteStale(existing.ID, now.Add(-idempotencyStaleAfter))
+		if err != nil {
+			return nil, nil, err
+		}
+		if !stale {
+			return nil, nil, ErrIdempotencyInProgress
+		}
+	}
+	return nil, nil, ErrIdempotencyInProgress
+}
+
+// Complete เก็บผลลัพธ์ของคำขอแรกไว้ตอบคำขอซ้ำ
+func (s *IdempotencyService) Complete(record *model.IdempotencyKey, status int, contentType string, body []byte) error {
+	return s.repo.Complete(record.ID, status, contentType, body)
+}
+
+// Abort ปล่อยคีย์ (คำขอแรกล้มเหลวแบบลองใหม่ได้ เช่น 5xx)
+func (s *IdempotencyService) Abort(record *model.IdempotencyKey) error {
+	return s.repo.Delete(record.ID)
+}
+
+// PurgeExpiredKeys ลบคีย์ที่หมดอายุ (งานตามกำหนดเวลา คืนจำนวนที่ลบ)
+func (s *IdempotencyService) PurgeExpiredKeys() (int, error) {
+	deleted, err := s.repo.DeleteExpired(time.Now())
+	return int(deleted), err
+}
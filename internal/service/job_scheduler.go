@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// -------------------------------------------------------------------
+// Job scheduler: งานเบื้องหลังตามกำหนดเวลาแบบ cron ภายใน process
+// ทุก replica ตั้งเวลาเหมือนกัน แต่ advisory lock ของ Postgres ทำให้แต่ละรอบทำงานเพียงที่เดียว
+// ทุกการทำงานบันทึกลงตาราง job_runs
+// -------------------------------------------------------------------
+
+var (
+	ErrJobNotFound = errors.New("ไม่พบงานที่ระบุ")
+	ErrJobRunning  = errors.New("งานนี้กำลังทำงานอยู่")
+)
+
+// JobFunc งานหนึ่งรอบ คืนจำนวนรายการที่ดำเนินการ
+type JobFunc func() (int, error)
+
+type scheduledJob struct {
+	name        string
+	description string
+	spec        string
+	schedule    *CronSchedule
+	run         JobFunc
+}
+
+// JobInfo ข้อมูลงานสำหรับหน้า admin
+type JobInfo struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Schedule    string        `json:"schedule"`
+	NextRun     time.Time     `json:"next_run"`
+	LastRun     *model.JobRun `json:"last_run"`
+}
+
+type JobScheduler struct {
+	repo *repository.JobRunRepository
+	db   *gorm.DB
+	host string
+
+	mu   sync.Mutex
+	jobs map[string]*scheduledJob
+}
+
+func NewJobScheduler(repo *repository.JobRunRepository, db *gorm.DB) *JobScheduler {
+	host, _ := os.Hostname()
+	return &JobScheduler{repo: repo, db: db, host: host, jobs: map[string]*scheduledJob{}}
+}
+
+// Register เพิ่มงานพร้อมกำหนดเวลาแบบ cron (ต้องเรียกก่อน Start)
+func (s *JobScheduler) Register(name string, spec string, description string, run JobFunc) error {
+	schedule, err := ParseCronSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("งาน %s: %w", name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("งาน %s ถูกลงทะเบียนไว้แล้ว", name)
+	}
+	s.jobs[name] = &scheduledJob{name: name, description: description, spec: spec, schedule: schedule, run: run}
+	return nil
+}
+
+// Start เริ่มตั้งเวลาทุกงานที่ลงทะเบียนไว้ จนกว่า ctx จะถูกยกเลิก
+func (s *JobScheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+	log.Printf("⏱️ Job scheduler started (%d jobs)", len(s.jobs))
+}
+
+func (s *JobScheduler) loop(ctx context.Context, job *scheduledJob) {
+	for {
+		next := job.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("⚠️ งาน %s ไม่มีรอบถัดไปตามกำหนดเวลา '%s'", job.name, job.spec)
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if _, err := s.execute(job, model.JobTriggerSchedule, nil, &next, nil); err != nil &&
+				!errors.Is(err, ErrJobRunning) {
+				log.Printf("❌ Job %s error: %v", job.name, err)
+			}
+		}
+	}
+}
+
+// GetJobs งานทั้งหมด พร้อมรอบถัดไปและผลการทำงานครั้งล่าสุด
+func (s *JobScheduler) GetJobs() ([]JobInfo, error) {
+	s.mu.Lock()
+	jobs := make([]*scheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	s.mu.Unlock()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].name < jobs[j].name })
+
+	now := time.Now()
+	infos := make([]JobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		last, err := s.repo.FindLatest(job.name)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, JobInfo{
+			Name:        job.name,
+			Description: job.description,
+			Schedule:    job.spec,
+			NextRun:     job.schedule.Next(now),
+			LastRun:     last,
+		})
+	}
+	return infos, nil
+}
+
+// GetJobRuns ประวัติการทำงานของงาน
+func (s *JobScheduler) GetJobRuns(name string, limit int) ([]model.JobRun, error) {
+	if _, err := s.findJob(name); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByJob(name, limit)
+}
+
+// TriggerJob สั่งงานทันที (admin) คืนรายการการทำงานเมื่อได้ lock แล้ว งานทำต่อเบื้องหลัง
+// ถ้างานกำลังทำอยู่ (ที่ replica ใดก็ตาม) คืน ErrJobRunning
+func (s *JobScheduler) TriggerJob(name string, actor Actor) (*model.JobRun, error) {
+	job, err := s.findJob(name)
+	if err != nil {
+		return nil, err
+	}
+	started := make(chan *model.JobRun, 1)
+	errc := make(chan error, 1)
+	go func() {
+		if _, err := s.execute(job, model.JobTriggerManual, &actor.ID, nil, started); err != nil {
+			errc <- err
+		}
+	}()
+	select {
+	case run := <-started:
+		return run, nil
+	case err := <-errc:
+		return nil, err
+	}
+}
+
+func (s *JobScheduler) findJob(name string) (*scheduledJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// execute ถือ advisory lock ของงานตลอดการทำงาน (session lock จึงต้องใช้ connection เดียวกันตั้งแต่ lock ถึง unlock)
+// รอบตามกำหนดเวลาที่มีบันทึกอยู่แล้ว (replica อื่นทำไปแล้ว) จะถูกข้าม
+func (s *JobScheduler) execute(job *scheduledJob, trigger model.JobRunTrigger, triggeredBy *uint, scheduledFor *time.Time, started chan<- *model.JobRun) (*model.JobRun, error) {
+	var run *model.JobRun
+	err := s.db.Connection(func(conn *gorm.DB) error {
+		key := jobLockKey(job.name)
+		var locked bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return ErrJobRunning
+		}
+		defer func() {
+			if err := conn.Exec("SELECT pg_advisory_unlock(?)", key).Error; err != nil {
+				log.Printf("⚠️ ปลด lock ของงาน %s ไม่สำเร็จ: %v", job.name, err)
+			}
+		}()
+
+		if scheduledFor != nil {
+			done, err := s.repo.ExistsForSchedule(job.name, *scheduledFor)
+			if err != nil || done {
+				return err
+			}
+		}
+
+		run = &model.JobRun{
+			JobName:      job.name,
+			ScheduledFor: scheduledFor,
+			Trigger:      trigger,
+			TriggeredBy:  triggeredBy,
+			Host:         s.host,
+			Status:       model.JobRunRunning,
+			StartedAt:    time.Now(),
+		}
+		if err := s.repo.Create(run); err != nil {
+			return err
+		}
+		if started != nil {
+			started <- run
+		}
+
+		affected, err := runJobSafely(job)
+		finished := time.Now()
+		run.FinishedAt = &finished
+		run.Affected = affected
+		run.Status = model.JobRunSucceeded
+		if err != nil {
+			run.Status = model.JobRunFailed
+			run.Error = err.Error()
+		}
+		if err := s.repo.Update(run); err != nil {
+			return err
+		}
+		if run.Status == model.JobRunFailed {
+			log.Printf("❌ Job %s failed: %s", job.name, run.Error)
+		} else if affected > 0 {
+			log.Printf("✅ Job %s: %d รายการ", job.name, affected)
+		}
+		return nil
+	})
+	return run, err
+}
+
+// runJobSafely กัน panic ในงานไม่ให้ scheduler ทั้งตัวล่ม
+func runJobSafely(job *scheduledJob) (affected int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.run()
+}
+
+// jobLockKey แปลงชื่องานเป็น key ของ advisory lock
+func jobLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("job:" + name))
+	return int64(h.Sum64())
+}
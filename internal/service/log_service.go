@@ -33,8 +33,8 @@ func (s *LogService) CreateLog(userID uint, action string) error {
 }
 
 // ✅ ดึง Log ทั้งหมด
-func (s *LogService) GetAllLogs() ([]model.Log, error) {
-	return s.repo.GetAll()
+func (s *LogService) GetAllLogs(scope OfficeScope) ([]model.Log, error) {
+	return s.repo.GetAll(scope.Codes())
 }
 
 // ✅ ดึง Logs พร้อมข้อมูลของ Users
@@ -43,35 +43,51 @@ func (s *LogService) GetLogsWithUsers() ([]map[string]interface{}, error) {
 }
 
 // ✅ ดึง Log ตาม ID
-func (s *LogService) GetLogByID(logID uint) (*model.Log, error) {
+func (s *LogService) GetLogByID(logID uint, scope OfficeScope) (*model.Log, error) {
 	if logID == 0 {
 		return nil, errors.New("logID is required")
 	}
-	return s.repo.GetByID(logID)
+	log, err := s.repo.GetByID(logID)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.All {
+		ok, err := s.repo.IsInOffices(logID, scope.Codes())
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrOutsideOffice
+		}
+	}
+	return log, nil
 }
 
 // ✅ ดึง Log ตามประเภท (Type)
-func (s *LogService) GetLogsByType(logType string) ([]model.Log, error) {
+func (s *LogService) GetLogsByType(logType string, scope OfficeScope) ([]model.Log, error) {
 	if logType == "" {
 		return nil, errors.New("log type is required")
 	}
-	return s.repo.GetByType(logType)
+	return s.repo.GetByType(logType, scope.Codes())
 }
 
 // ✅ ดึง Log ตาม User ID
-func (s *LogService) GetLogsByUser(userID uint) ([]model.Log, error) {
+func (s *LogService) GetLogsByUser(userID uint, scope OfficeScope) ([]model.Log, error) {
 	if userID == 0 {
 		return nil, errors.New("userID is required")
 	}
-	return s.repo.GetByUser(userID)
+	if err := s.CheckUserInScope(userID, scope); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByUser(userID, scope.Codes())
 }
 
 // ✅ ดึง Log ตามช่วงเวลา (Date Range)
-func (s *LogService) GetLogsByDateRange(startDate, endDate string) ([]model.Log, error) {
+func (s *LogService) GetLogsByDateRange(startDate, endDate string, scope OfficeScope) ([]model.Log, error) {
 	if startDate == "" || endDate == "" {
 		return nil, errors.New("startDate and endDate are required")
 	}
-	return s.repo.GetByDateRange(startDate, endDate)
+	return s.repo.GetByDateRange(startDate, endDate, scope.Codes())
 }
 
 // ✅ ลบ Log ตาม ID
@@ -83,6 +99,21 @@ func (s *LogService) DeleteLog(logID uint) error {
 }
 
 // ✅ Get logs by specific action type
-func (s *LogService) GetLogsByAction(action string) ([]model.Log, error) {
-	return s.repo.GetByAction(action)
+func (s *LogService) GetLogsByAction(action string, scope OfficeScope) ([]model.Log, error) {
+	return s.repo.GetByAction(action, scope.Codes())
+}
+
+// ✅ ตรวจว่า userID (พนักงานหรือช่าง) อยู่ในสำนักงานของขอบเขต
+func (s *LogService) CheckUserInScope(userID uint, scope OfficeScope) error {
+	if scope.All {
+		return nil
+	}
+	ok, err := s.repo.OwnerInOffices(userID, scope.Codes())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOutsideOffice
+	}
+	return nil
 }
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// MeterService จัดการทะเบียนมิเตอร์และประวัติซีลบนมิเตอร์
+type MeterService struct {
+	repo *repository.MeterRepository
+}
+
+func NewMeterService(repo *repository.MeterRepository) *MeterService {
+	return &MeterService{repo: repo}
+}
+
+// MeterSealHistory ซีลปัจจุบันและประวัติซีลทั้งหมดของมิเตอร์
+type MeterSealHistory struct {
+	Meter   *model.Meter      `json:"meter"`
+	Current []model.MeterSeal `json:"current"` // ซีลที่ยังไม่ถูกถอดและอยู่ในสถานะ 'ติดตั้งแล้ว'
+	History []model.MeterSeal `json:"history"` // ทุกครั้งที่เคยติดตั้ง (ล่าสุดก่อน)
+	Flags   []model.MeterSeal `json:"flags"`   // ครั้งที่ติดตั้งซ้อนซีลเดิม
+}
+
+// RegisterMeter เพิ่มมิเตอร์ใหม่ หรืออัปเดตข้อมูลถ้า serial มีอยู่แล้ว
+func (s *MeterService) RegisterMeter(input *model.Meter) (*model.Meter, error) {
+	input.SerialNumber = strings.TrimSpace(input.SerialNumber)
+	if input.SerialNumber == "" {
+		return nil, errors.New("serial_number is required")
+	}
+
+	meter, err := s.repo.FindBySerial(input.SerialNumber)
+	if err != nil {
+		if err := s.repo.Create(input); err != nil {
+			return nil, err
+		}
+		return input, nil
+	}
+
+	meter.Manufacturer = input.Manufacturer
+	meter.CustomerAccount = input.CustomerAccount
+	meter.Location = input.Location
+	if err := s.repo.Update(meter); err != nil {
+		return nil, err
+	}
+	return meter, nil
+}
+
+func (s *MeterService) GetMeters(keyword string) ([]model.Meter, error) {
+	return s.repo.Search(strings.TrimSpace(keyword))
+}
+
+func (s *MeterService) GetMeterBySerial(serial string) (*model.Meter, error) {
+	return s.repo.FindBySerial(serial)
+}
+
+// GetMeterSeals คืนซีลปัจจุบัน ประวัติ และรายการที่ถูก flag ของมิเตอร์
+func (s *MeterService) GetMeterSeals(serial string) (*MeterSealHistory, error) {
+	meter, err := s.repo.FindBySerial(serial)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.repo.FindSealLinks(meter.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &MeterSealHistory{
+		Meter:   meter,
+		Current: []model.MeterSeal{},
+		History: links,
+		Flags:   []model.MeterSeal{},
+	}
+	for _, link := range links {
+		if link.RemovedAt == nil && link.Seal != nil && link.Seal.Status == model.SealStatusInstalled {
+			history.Current = append(history.Current, link)
+		}
+		if link.Flagged {
+			history.Flags = append(history.Flags, link)
+		}
+	}
+	return history, nil
+}
+
+// GetFlaggedInstallations รายการติดตั้งทั้งหมดที่มิเตอร์มีซีลซ้อนกัน
+func (s *MeterService) GetFlaggedInstallations() ([]model.MeterSeal, error) {
+	return s.repo.FindFlaggedLinks()
+}
+
+// linkSealToMeter ผูกซีลที่เพิ่งติดตั้งเข้ากับมิเตอร์ (ลงทะเบียนมิเตอร์ให้อัตโนมัติถ้ายังไม่มี)
+// และ flag ไว้ถ้ามิเตอร์ยังมีซีลอื่นที่บันทึกว่า 'ติดตั้งแล้ว' ค้างอยู่
+func linkSealToMeter(tx *gorm.DB, seal *model.Seal, serial string, actor Actor, at time.Time) (*model.MeterSeal, error) {
+	serial = strings.TrimSpace(serial)
+	if serial == "" {
+		return nil, nil
+	}
+
+	var meter model.Meter
+	if err := tx.Where(model.Meter{SerialNumber: serial}).FirstOrCreate(&meter).Error; err != nil {
+		return nil, err
+	}
+
+	var stillInstalled []string
+	if err := tx.Model(&model.MeterSeal{}).
+		Joins("JOIN seals ON seals.id = meter_seals.seal_id").
+		Where("meter_seals.meter_id = ? AND meter_seals.removed_at IS NULL AND seals.status = ? AND seals.id <> ?",
+			meter.ID, model.SealStatusInstalled, seal.ID).
+		Pluck("seals.seal_number", &stillInstalled).Error; err != nil {
+		return nil, err
+	}
+
+	link := &model.MeterSeal{
+		MeterID:       meter.ID,
+		SealID:        seal.ID,
+		SealNumber:    seal.SealNumber,
+		InstalledBy:   actor.ID,
+		InstallerType: string(actor.Type),
+		InstalledAt:   at,
+	}
+	if len(stillInstalled) > 0 {
+		link.Flagged = true
+		link.FlagReason = fmt.Sprintf("มิเตอร์ %s ยังมีซีล %s บันทึกว่าติดตั้งอยู่", serial, strings.Join(stillInstalled, ", "))
+	}
+	if err := tx.Create(link).Error; err != nil {
+		return nil, err
+	}
+	return link, nil
+}
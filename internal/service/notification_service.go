@@ -0,0 +1,42 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+)
+
+type NotificationService struct {
+	repo *repository.NotificationRepository
+}
+
+func NewNotificationService(repo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// GetNotifications การแจ้งเตือนของสำนักงานในขอบเขต (admin เห็นทั้งหมด)
+func (s *NotificationService) GetNotifications(scope OfficeScope, unreadOnly bool) ([]model.Notification, error) {
+	return s.repo.FindByOffices(scope.Codes(), unreadOnly)
+}
+
+// MarkNotificationRead ทำเครื่องหมายว่าอ่านแล้ว (อ่านซ้ำไม่เปลี่ยนผู้อ่านคนแรก)
+func (s *NotificationService) MarkNotificationRead(id uint, actor Actor, scope OfficeScope) (*model.Notification, error) {
+	notification, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.Allows(notification.OfficeCode) {
+		return nil, ErrOutsideOffice
+	}
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+	now := time.Now()
+	notification.ReadBy = &actor.ID
+	notification.ReadAt = &now
+	if err := s.repo.Update(notification); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+)
+
+// ErrOutsideOffice ผู้เรียกพยายามเข้าถึงข้อมูลนอกสำนักงานของตัวเอง (controller ตอบ 403)
+var ErrOutsideOffice = errors.New("คุณไม่มีสิทธิ์เข้าถึงข้อมูลของสำนักงานอื่น")
+
+// OfficeScope ขอบเขตสำนักงานที่ผู้เรียกเห็นและแก้ไขข้อมูลได้
+// All = admin เห็นทุกสำนักงาน รวมถึงข้อมูลเดิมที่ยังไม่ระบุสำนักงาน
+type OfficeScope struct {
+	All     bool
+	Offices []string
+}
+
+// Allows ตรวจว่าสำนักงานนี้อยู่ในขอบเขต (ข้อมูลที่ไม่ระบุสำนักงานเป็นของ admin เท่านั้น)
+func (s OfficeScope) Allows(office string) bool {
+	if s.All {
+		return true
+	}
+	if office == "" {
+		return false
+	}
+	for _, code := range s.Offices {
+		if code == office {
+			return true
+		}
+	}
+	return false
+}
+
+// Codes รหัสสำนักงานสำหรับกรอง query (nil = ไม่กรอง)
+func (s OfficeScope) Codes() []string {
+	if s.All {
+		return nil
+	}
+	return append([]string{}, s.Offices...)
+}
+
+type OfficeService struct {
+	repo *repository.OfficeRepository
+}
+
+func NewOfficeService(repo *repository.OfficeRepository) *OfficeService {
+	return &OfficeService{repo: repo}
+}
+
+// ScopeFor admin เห็นทุกสำนักงาน พนักงานเห็นสำนักงานตัวเองและสำนักงานลูกทุกระดับตามทะเบียน
+func (s *OfficeService) ScopeFor(actor Actor) (OfficeScope, error) {
+	if actor.IsAdmin() {
+		return OfficeScope{All: true}, nil
+	}
+	if actor.Office == "" {
+		return OfficeScope{Offices: []string{}}, nil
+	}
+
+	offices := []string{actor.Office}
+	seen := map[string]bool{actor.Office: true}
+	level := []string{actor.Office}
+	for len(level) > 0 {
+		children, err := s.repo.FindChildCodes(level)
+		if err != nil {
+			return OfficeScope{}, err
+		}
+		level = nil
+		for _, code := range children {
+			if seen[code] {
+				continue
+			}
+			seen[code] = true
+			offices = append(offices, code)
+			level = append(level, code)
+		}
+	}
+	return OfficeScope{Offices: offices}, nil
+}
+
+// GetOffices ทะเบียนสำนักงานทั้งหมด
+func (s *OfficeService) GetOffices() ([]model.Office, error) {
+	return s.repo.FindAll()
+}
+
+// SaveOffice เพิ่ม/แก้ไขสำนักงาน สำนักงานแม่ต้องมีในทะเบียนและห้ามเป็นวงวน
+func (s *OfficeService) SaveOffice(office *model.Office) error {
+	office.Code = strings.TrimSpace(office.Code)
+	office.ParentCode = strings.TrimSpace(office.ParentCode)
+	if office.Code == "" {
+		return errors.New("กรุณาระบุรหัสสำนักงาน")
+	}
+
+	// ไล่ขึ้นไปตามสำนักงานแม่ ถ้าเจอตัวเองแปลว่าเป็นวงวน
+	for parent := office.ParentCode; parent != ""; {
+		if parent == office.Code {
+			return fmt.Errorf("สำนักงาน %s ไม่สามารถอยู่ใต้สำนักงานลูกของตัวเองได้", office.Code)
+		}
+		p, err := s.repo.FindByCode(parent)
+		if err != nil {
+			return fmt.Errorf("ไม่พบสำนักงานแม่รหัส %s", parent)
+		}
+		parent = p.ParentCode
+	}
+	return s.repo.Save(office)
+}
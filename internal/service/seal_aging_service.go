@@ -0,0 +1,353 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// -------------------------------------------------------------------
+// Seal aging: ซีล 'จ่าย' ที่ค้างอยู่กับช่างนานเกินกำหนดโดยยังไม่ติดตั้ง
+// เกิน WarnDays -> ขึ้นรายการเฝ้าระวัง, เกิน EscalateDays -> แจ้งสำนักงานที่จ่ายซีล
+// -------------------------------------------------------------------
+
+const (
+	DefaultSealAgingWarnDays     = 14
+	DefaultSealAgingEscalateDays = 30
+)
+
+// SealAgingLevel ระดับความค้างของซีล
+type SealAgingLevel string
+
+const (
+	SealAgingWarning   SealAgingLevel = "warning"
+	SealAgingEscalated SealAgingLevel = "escalated"
+)
+
+type SealAgingService struct {
+	repo *repository.SealAgingRepository
+	db   *gorm.DB
+}
+
+func NewSealAgingService(repo *repository.SealAgingRepository, db *gorm.DB) *SealAgingService {
+	return &SealAgingService{repo: repo, db: db}
+}
+
+// OverdueSeal ซีลที่ค้างเกินเกณฑ์หนึ่งเส้น
+type OverdueSeal struct {
+	SealNumber      string         `json:"seal_number"`
+	OfficeCode      string         `json:"office_code"`
+	IssuedAt        time.Time      `json:"issued_at"`
+	DaysOutstanding int            `json:"days_outstanding"`
+	Level           SealAgingLevel `json:"level"`
+}
+
+// OverdueTechnician ซีลค้างของช่างหนึ่งคน (TechnicianID nil = ซีลที่ไม่ได้ระบุช่าง)
+type OverdueTechnician struct {
+	TechnicianID   *uint         `json:"technician_id"`
+	TechnicianCode string        `json:"technician_code"`
+	Name           string        `json:"name"`
+	CompanyName    string        `json:"company_name"`
+	Warning        int           `json:"warning"`
+	Escalated      int           `json:"escalated"`
+	Seals          []OverdueSeal `json:"seals"`
+}
+
+// OverdueCompany สรุปซีลค้างรายบริษัท
+type OverdueCompany struct {
+	CompanyName string `json:"company_name"`
+	Technicians int    `json:"technicians"`
+	Warning     int    `json:"warning"`
+	Escalated   int    `json:"escalated"`
+}
+
+// SealAgingReport รายงานซีลค้างตามเกณฑ์ปัจจุบัน
+type SealAgingReport struct {
+	Policy      model.SealAgingPolicy `json:"policy"`
+	Technicians []OverdueTechnician   `json:"technicians"`
+	Companies   []OverdueCompany      `json:"companies"`
+}
+
+// GetPolicy เกณฑ์ปัจจุบัน (ยังไม่เคยตั้ง = 14/30 วัน)
+func (s *SealAgingService) GetPolicy() (*model.SealAgingPolicy, error) {
+	policy, err := s.repo.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &model.SealAgingPolicy{WarnDays: DefaultSealAgingWarnDays, EscalateDays: DefaultSealAgingEscalateDays}
+	}
+	return policy, nil
+}
+
+// SavePolicy ตั้งเกณฑ์ใหม่ (admin)
+func (s *SealAgingService) SavePolicy(warnDays int, escalateDays int, actor Actor) (*model.SealAgingPolicy, error) {
+	if warnDays <= 0 {
+		return nil, errors.New("จำนวนวันเฝ้าระวังต้องมากกว่า 0")
+	}
+	if escalateDays < warnDays {
+		return nil, errors.New("จำนวนวันแจ้งสำนักงานต้องไม่น้อยกว่าจำนวนวันเฝ้าระวัง")
+	}
+	policy := &model.SealAgingPolicy{WarnDays: warnDays, EscalateDays: escalateDays, UpdatedBy: actor.ID}
+	if err := s.repo.SavePolicy(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// GetOverdueSeals ซีลค้างเกินเกณฑ์ของสำนักงานในขอบเขต จัดกลุ่มรายช่างและรายบริษัท
+// company ไม่ว่าง = เฉพาะช่างของบริษัทนั้น
+func (s *SealAgingService) GetOverdueSeals(scope OfficeScope, company string) (*SealAgingReport, error) {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	seals, err := s.repo.FindIssuedBefore(now.AddDate(0, 0, -policy.WarnDays), scope.Codes())
+	if err != nil {
+		return nil, err
+	}
+	technicians, err := s.findSealTechnicians(seals)
+	if err != nil {
+		return nil, err
+	}
+
+	company = strings.TrimSpace(company)
+	report := &SealAgingReport{Policy: *policy, Technicians: []OverdueTechnician{}, Companies: []OverdueCompany{}}
+	byTechnician := map[uint]*OverdueTechnician{}
+	var unassigned *OverdueTechnician
+	for _, seal := range seals {
+		var group *OverdueTechnician
+		if seal.AssignedToTechnician == nil {
+			if company != "" {
+				continue
+			}
+			if unassigned == nil {
+				unassigned = &OverdueTechnician{Name: "(ไม่ระบุช่าง)"}
+			}
+			group = unassigned
+		} else {
+			techID := *seal.AssignedToTechnician
+			tech := technicians[techID]
+			if company != "" && tech.CompanyName != company {
+				continue
+			}
+			group = byTechnician[techID]
+			if group == nil {
+				group = &OverdueTechnician{
+					TechnicianID:   &techID,
+					TechnicianCode: tech.TechnicianCode,
+					Name:           strings.TrimSpace(tech.FirstName + " " + tech.LastName),
+					CompanyName:    tech.CompanyName,
+				}
+				byTechnician[techID] = group
+			}
+		}
+
+		overdue := OverdueSeal{
+			SealNumber:      seal.SealNumber,
+			OfficeCode:      seal.OfficeCode,
+			IssuedAt:        *seal.IssuedAt,
+			DaysOutstanding: daysSince(*seal.IssuedAt, now),
+			Level:           SealAgingWarning,
+		}
+		if overdue.DaysOutstanding >= policy.EscalateDays {
+			overdue.Level = SealAgingEscalated
+			group.Escalated++
+		} else {
+			group.Warning++
+		}
+		group.Seals = append(group.Seals, overdue)
+	}
+
+	for _, group := range byTechnician {
+		report.Technicians = append(report.Technicians, *group)
+	}
+	// ช่างที่มีซีลถึงขั้นแจ้งสำนักงานมากที่สุดขึ้นก่อน
+	sort.Slice(report.Technicians, func(i, j int) bool {
+		a, b := report.Technicians[i], report.Technicians[j]
+		if a.Escalated != b.Escalated {
+			return a.Escalated > b.Escalated
+		}
+		if len(a.Seals) != len(b.Seals) {
+			return len(a.Seals) > len(b.Seals)
+		}
+		return a.TechnicianCode < b.TechnicianCode
+	})
+	if unassigned != nil {
+		report.Technicians = append(report.Technicians, *unassigned)
+	}
+
+	byCompany := map[string]*OverdueCompany{}
+	for _, group := range report.Technicians {
+		if group.TechnicianID == nil {
+			continue
+		}
+		summary := byCompany[group.CompanyName]
+		if summary == nil {
+			summary = &OverdueCompany{CompanyName: group.CompanyName}
+			byCompany[group.CompanyName] = summary
+		}
+		summary.Technicians++
+		summary.Warning += group.Warning
+		summary.Escalated += group.Escalated
+	}
+	for _, summary := range byCompany {
+		report.Companies = append(report.Companies, *summary)
+	}
+	sort.Slice(report.Companies, func(i, j int) bool {
+		a, b := report.Companies[i], report.Companies[j]
+		if a.Escalated != b.Escalated {
+			return a.Escalated > b.Escalated
+		}
+		return a.CompanyName < b.CompanyName
+	})
+	return report, nil
+}
+
+// EscalateAgedSeals แจ้งสำนักงานที่จ่ายซีลเมื่อซีลค้างเกิน EscalateDays
+// ซีลแต่ละรอบการจ่ายแจ้งเพียงครั้งเดียว รวมเป็น notification เดียวต่อ (สำนักงาน, ช่าง)
+// คืนจำนวนซีลที่แจ้งใหม่
+func (s *SealAgingService) EscalateAgedSeals() (int, error) {
+	policy, err := s.GetPolicy()
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	seals, err := s.repo.FindIssuedBefore(now.AddDate(0, 0, -policy.EscalateDays), nil)
+	if err != nil {
+		return 0, err
+	}
+	if len(seals) == 0 {
+		return 0, nil
+	}
+
+	sealIDs := make([]uint, 0, len(seals))
+	for _, seal := range seals {
+		sealIDs = append(sealIDs, seal.ID)
+	}
+	escalations, err := s.repo.FindEscalations(sealIDs)
+	if err != nil {
+		return 0, err
+	}
+	notified := map[string]bool{}
+	for _, e := range escalations {
+		notified[sealAgingKey(e.SealID, e.IssuedAt)] = true
+	}
+
+	type escalationGroup struct {
+		office string
+		techID *uint
+		seals  []model.Seal
+	}
+	var groups []*escalationGroup
+	byKey := map[string]*escalationGroup{}
+	for _, seal := range seals {
+		if notified[sealAgingKey(seal.ID, *seal.IssuedAt)] {
+			continue
+		}
+		key := seal.OfficeCode + "|" + uintLabel(seal.AssignedToTechnician)
+		group := byKey[key]
+		if group == nil {
+			group = &escalationGroup{office: seal.OfficeCode, techID: seal.AssignedToTechnician}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.seals = append(group.seals, seal)
+	}
+	if len(groups) == 0 {
+		return 0, nil
+	}
+
+	var pending []model.Seal
+	for _, group := range groups {
+		pending = append(pending, group.seals...)
+	}
+	technicians, err := s.findSealTechnicians(pending)
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, group := range groups {
+		holder := "(ไม่ระบุช่าง)"
+		if group.techID != nil {
+			tech := technicians[*group.techID]
+			holder = fmt.Sprintf("ช่างรหัส %s %s %s", tech.TechnicianCode, tech.FirstName, tech.LastName)
+			if tech.CompanyName != "" {
+				holder += " (" + tech.CompanyName + ")"
+			}
+		}
+		numbers := make([]string, 0, len(group.seals))
+		for _, seal := range group.seals {
+			numbers = append(numbers, seal.SealNumber)
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			notification := model.Notification{
+				OfficeCode: group.office,
+				Type:       model.NotificationSealAging,
+				Title:      fmt.Sprintf("ซีลค้างเกิน %d วัน: %s", policy.EscalateDays, holder),
+				Message: fmt.Sprintf("ซีล %d เส้นจ่ายให้%s เกิน %d วันแล้วยังไม่ติดตั้ง: %s",
+					len(numbers), holder, policy.EscalateDays, strings.Join(numbers, ", ")),
+			}
+			if err := tx.Create(&notification).Error; err != nil {
+				return err
+			}
+			for _, seal := range group.seals {
+				escalation := model.SealAgingEscalation{
+					SealID:          seal.ID,
+					IssuedAt:        *seal.IssuedAt,
+					SealNumber:      seal.SealNumber,
+					TechnicianID:    seal.AssignedToTechnician,
+					OfficeCode:      seal.OfficeCode,
+					DaysOutstanding: daysSince(*seal.IssuedAt, now),
+					NotificationID:  &notification.ID,
+				}
+				if err := tx.Create(&escalation).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return escalated, err
+		}
+		escalated += len(group.seals)
+	}
+	return escalated, nil
+}
+
+func (s *SealAgingService) findSealTechnicians(seals []model.Seal) (map[uint]model.Technician, error) {
+	seen := map[uint]bool{}
+	var ids []uint
+	for _, seal := range seals {
+		if seal.AssignedToTechnician != nil && !seen[*seal.AssignedToTechnician] {
+			seen[*seal.AssignedToTechnician] = true
+			ids = append(ids, *seal.AssignedToTechnician)
+		}
+	}
+	technicians, err := s.repo.FindTechnicians(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]model.Technician, len(technicians))
+	for _, tech := range technicians {
+		byID[tech.ID] = tech
+	}
+	return byID, nil
+}
+
+func sealAgingKey(sealID uint, issuedAt time.Time) string {
+	return fmt.Sprintf("%d|%d", sealID, issuedAt.Unix())
+}
+
+func daysSince(t time.Time, now time.Time) int {
+	return int(now.Sub(t).Hours() / 24)
+}
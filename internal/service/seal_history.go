@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// recordSealHistory บันทึกการเปลี่ยนแปลงของซีลหนึ่งครั้งลงสมุดบัญชี transactions (แหล่งข้อมูลเดียวของประวัติซีล)
+// ต้องเรียกใน transaction เดียวกับการบันทึกซีล before คือสำเนาซีลก่อนแก้ไข
+func recordSealHistory(tx *gorm.DB, before *model.Seal, after *model.Seal, action SealAction, actor Actor, remark string) error {
+	return recordSealTransaction(tx, before, after, action, actor, sealLedgerMetadata{Remark: remark})
+}
+
+// SealHistoryEntry ประวัติการเปลี่ยนแปลงของซีลหนึ่งครั้ง มุมมองหนึ่งของแถวในสมุดบัญชี
+// ID คือรหัสแถวใน transactions รายการย้อนกลับชี้ไปยังรายการเดิมด้วย ReversesID
+type SealHistoryEntry struct {
+	ID         uint             `json:"id"`
+	SealID     uint             `json:"seal_id"`
+	SealNumber string           `json:"seal_number"`
+	Action     string           `json:"action"`
+	FromStatus model.SealStatus `json:"from_status"`
+	ToStatus   model.SealStatus `json:"to_status"`
+	ActorID    uint             `json:"actor_id"`
+	ActorType  string           `json:"actor_type"`
+	Remark     string           `json:"remark,omitempty"`
+	ReversesID *uint            `json:"reverses_id,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+
+	previous map[string]json.RawMessage
+}
+
+// sealHistoryEntries แปลงแถวสมุดบัญชีเป็นประวัติ (ลำดับตามที่ส่งเข้ามา)
+func sealHistoryEntries(transactions []model.Transaction) ([]SealHistoryEntry, error) {
+	entries := make([]SealHistoryEntry, 0, len(transactions))
+	for _, transaction := range transactions {
+		var metadata sealLedgerMetadata
+		if len(transaction.Metadata) > 0 {
+			if err := json.Unmarshal(transaction.Metadata, &metadata); err != nil {
+				return nil, fmt.Errorf("ข้อมูลสมุดบัญชีรายการ #%d ของซีล %s เสียหาย: %v", transaction.ID, transaction.SealNumber, err)
+			}
+		}
+		entries = append(entries, SealHistoryEntry{
+			ID:         transaction.ID,
+			SealID:     transaction.SealID,
+			SealNumber: transaction.SealNumber,
+			Action:     transaction.Action,
+			FromStatus: transaction.FromStatus,
+			ToStatus:   transaction.ToStatus,
+			ActorID:    transaction.ActorID,
+			ActorType:  transaction.ActorType,
+			Remark:     metadata.Remark,
+			ReversesID: metadata.ReversesID,
+			CreatedAt:  transaction.CreatedAt,
+			previous:   metadata.Previous,
+		})
+	}
+	return entries, nil
+}
+
+// -------------------------------------------------------------------
+// Seal ledger: สมุดบัญชี transactions หนึ่งแถวต่อการเปลี่ยนแปลงซีลหนึ่งครั้ง
+// Metadata.changes เก็บค่าใหม่ของฟิลด์ที่เปลี่ยน (แถว create เก็บครบทุกฟิลด์)
+// Metadata.previous เก็บค่าเดิมของฟิลด์เหล่านั้น ใช้ย้อนกลับรายการที่บันทึกผิด
+// เล่นซ้ำตามลำดับ (created_at, id) แล้วต้องได้สภาพซีลปัจจุบัน
+// -------------------------------------------------------------------
+
+// sealLedgerBatchSize จำนวนแถวต่อคำสั่ง insert ตอนสร้างซีลทีละมาก ๆ
+const sealLedgerBatchSize = 1000
+
+// sealLedgerState ฟิลด์ของซีลที่สมุดบัญชีติดตาม (สถานะแยกไว้ใน from_status/to_status)
+type sealLedgerState struct {
+	OfficeCode           string     `json:"office_code"`
+	SealLotID            *uint      `json:"seal_lot_id"`
+	IssuedBy             *uint      `json:"issued_by"`
+	IssuedTo             *uint      `json:"issued_to"`
+	IssuedAt             *time.Time `json:"issued_at"`
+	EmployeeCode         string     `json:"employee_code"`
+	IssueRemark          string     `json:"issue_remark"`
+	AssignedToTechnician *uint      `json:"assigned_to_technician"`
+	WorkOrderID          *uint      `json:"work_order_id"`
+	UsedBy               *uint      `json:"used_by"`
+	UsedAt               *time.Time `json:"used_at"`
+	InstalledSerial      string     `json:"installed_serial"`
+	ReturnedBy           *uint      `json:"returned_by"`
+	ReturnedAt           *time.Time `json:"returned_at"`
+	ReturnRemarks        string     `json:"return_remarks"`
+	ReservedBy           *uint      `json:"reserved_by"`
+	ReservedFor          *uint      `json:"reserved_for"`
+	ReservedUntil        *time.Time `json:"reserved_until"`
+	ReserveRemark        string     `json:"reserve_remark"`
+}
+
+// sealLedgerMetadata รายละเอียดใน Transaction.Metadata
+// HistoryID = รหัสแถวใน seal_histories เดิม (เฉพาะรายการที่ย้ายมาจากตารางเดิม)
+type sealLedgerMetadata struct {
+	HistoryID  *uint                      `json:"history_id,omitempty"`
+	ReversesID *uint                      `json:"reverses_id,omitempty"`
+	Remark     string                     `json:"remark,omitempty"`
+	Changes    map[string]json.RawMessage `json:"changes,omitempty"`
+	Previous   map[string]json.RawMessage `json:"previous,omitempty"`
+}
+
+func ledgerStateOf(seal *model.Seal) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(sealLedgerState{
+		OfficeCode:           seal.OfficeCode,
+		SealLotID:            seal.SealLotID,
+		IssuedBy:             seal.IssuedBy,
+		IssuedTo:             seal.IssuedTo,
+		IssuedAt:             seal.IssuedAt,
+		EmployeeCode:         seal.EmployeeCode,
+		IssueRemark:          seal.IssueRemark,
+		AssignedToTechnician: seal.AssignedToTechnician,
+		WorkOrderID:          seal.WorkOrderID,
+		UsedBy:               seal.UsedBy,
+		UsedAt:               seal.UsedAt,
+		InstalledSerial:      seal.InstalledSerial,
+		ReturnedBy:           seal.ReturnedBy,
+		ReturnedAt:           seal.ReturnedAt,
+		ReturnRemarks:        seal.ReturnRemarks,
+		ReservedBy:           seal.ReservedBy,
+		ReservedFor:          seal.ReservedFor,
+		ReservedUntil:        seal.ReservedUntil,
+		ReserveRemark:        seal.ReserveRemark,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// sealLedgerChanges ฟิลด์ที่ค่าเปลี่ยนระหว่าง before กับ after: ค่าใหม่ (changes) และค่าเดิม (previous)
+// before = nil คือซีลใหม่ คืนทุกฟิลด์และไม่มีค่าเดิม
+func sealLedgerChanges(before, after *model.Seal) (map[string]json.RawMessage, map[string]json.RawMessage, error) {
+	afterState, err := ledgerStateOf(after)
+	if err != nil || before == nil {
+		return afterState, nil, err
+	}
+	beforeState, err := ledgerStateOf(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes := make(map[string]json.RawMessage)
+	previous := make(map[string]json.RawMessage)
+	for field, value := range afterState {
+		if !bytes.Equal(beforeState[field], value) {
+			changes[field] = value
+			previous[field] = beforeState[field]
+		}
+	}
+	return changes, previous, nil
+}
+
+func newSealTransaction(before, after *model.Seal, action SealAction, actor Actor, metadata sealLedgerMetadata) (*model.Transaction, error) {
+	changes, previous, err := sealLedgerChanges(before, after)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > 0 {
+		metadata.Changes = changes
+	}
+	if len(previous) > 0 {
+		metadata.Previous = previous
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	entry := &model.Transaction{
+		SealID:     after.ID,
+		SealNumber: after.SealNumber,
+		Action:     string(action),
+		ActorID:    actor.ID,
+		ActorType:  string(actor.Type),
+		ToStatus:   after.Status,
+		Metadata:   data,
+	}
+	if before != nil {
+		entry.FromStatus = before.Status
+	}
+	return entry, nil
+}
+
+// recordSealTransaction เพิ่มแถวในสมุดบัญชีสำหรับการเปลี่ยนแปลงหนึ่งครั้ง ใน tx เดียวกับการบันทึกซีล
+func recordSealTransaction(tx *gorm.DB, before, after *model.Seal, action SealAction, actor Actor, metadata sealLedgerMetadata) error {
+	entry, err := newSealTransaction(before, after, action, actor, metadata)
+	if err != nil {
+		return err
+	}
+	return tx.Create(entry).Error
+}
+
+// recordSealCreations เพิ่มแถว create ของซีลที่เพิ่งสร้าง (ซีลต้องมี ID แล้ว)
+func recordSealCreations(tx *gorm.DB, seals []model.Seal, actor Actor, remark string) error {
+	if len(seals) == 0 {
+		return nil
+	}
+	entries := make([]model.Transaction, 0, len(seals))
+	for i := range seals {
+		entry, err := newSealTransaction(nil, &seals[i], SealActionCreate, actor, sealLedgerMetadata{Remark: remark})
+		if err != nil {
+			return err
+		}
+		entries = append(entries, *entry)
+	}
+	return tx.CreateInBatches(&entries, sealLedgerBatchSize).Error
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// -------------------------------------------------------------------
+// Seal incidents: ช่างแจ้งซีลสูญหาย/ชำรุด -> admin ยืนยันหรือปฏิเสธ
+// -------------------------------------------------------------------
+
+// incidentActions ผูกประเภทรายงานกับ action ในตาราง transition ที่ใช้ตอนยืนยัน
+var incidentActions = map[model.SealIncidentType]SealAction{
+	model.SealIncidentLost:    SealActionMarkLost,
+	model.SealIncidentDamaged: SealActionMarkDamaged,
+}
+
+// ReportSealIncident บันทึกรายงานซีลสูญหาย/ชำรุด สถานะซีลยังไม่เปลี่ยนจนกว่า admin จะยืนยัน
+func (s *SealService) ReportSealIncident(
+	sealNumber string,
+	actor Actor,
+	incidentType model.SealIncidentType,
+	reason string,
+	imageURL string,
+) (*model.SealIncident, error) {
+	action, ok := incidentActions[incidentType]
+	if !ok {
+		return nil, errors.New("ประเภทรายงานไม่ถูกต้อง (lost หรือ damaged)")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("กรุณาระบุสาเหตุ")
+	}
+	if incidentType == model.SealIncidentDamaged && imageURL == "" {
+		return nil, errors.New("กรุณาแนบรูปซีลที่ชำรุด")
+	}
+
+	seal, err := s.repo.FindByNumber(sealNumber)
+	if err != nil {
+		return nil, errors.New("ไม่พบซีลในระบบ")
+	}
+
+	// ซีลต้องอยู่ในสถานะที่ยืนยันได้ตามตาราง transition
+	if _, ok := findSealTransition(action, seal.Status); !ok {
+		return nil, fmt.Errorf("ซีล %s อยู่ในสถานะ '%s' ไม่สามารถแจ้งได้", sealNumber, seal.Status.Label())
+	}
+
+	// ช่างแจ้งได้เฉพาะซีลที่ตัวเองถือหรือติดตั้ง
+	if actor.IsTechnician() {
+		assigned := seal.AssignedToTechnician != nil && *seal.AssignedToTechnician == actor.ID
+		installed := seal.UsedBy != nil && *seal.UsedBy == actor.ID
+		if !assigned && !installed {
+			return nil, errors.New("คุณไม่มีสิทธิ์แจ้งซีลนี้")
+		}
+	}
+
+	pending, err := s.incidentRepo.FindPendingBySeal(seal.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return nil, fmt.Errorf("ซีล %s มีรายงานที่รอยืนยันอยู่แล้ว (#%d)", sealNumber, pending.ID)
+	}
+
+	incident := &model.SealIncident{
+		SealID:       seal.ID,
+		SealNumber:   seal.SealNumber,
+		Type:         incidentType,
+		Reason:       reason,
+		ImageURL:     imageURL,
+		ReportedBy:   actor.ID,
+		ReporterType: string(actor.Type),
+		SealStatus:   seal.Status,
+		Status:       model.SealIncidentPending,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(incident).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("แจ้งซีล %s %s (สาเหตุ: %s)", sealNumber, incidentTypeLabel(incidentType), reason),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return incident, nil
+}
+
+// GetSealIncidents ดึงรายงานตามสถานะ (ว่าง = ทั้งหมด) เฉพาะซีลของสำนักงานในขอบเขต
+func (s *SealService) GetSealIncidents(status model.SealIncidentStatus, scope OfficeScope) ([]model.SealIncident, error) {
+	return s.incidentRepo.FindByStatus(status, scope.Codes())
+}
+
+// ConfirmSealIncident admin ยืนยันรายงาน -> ซีลเปลี่ยนเป็น สูญหาย/ชำรุด และจ่ายซ้ำไม่ได้อีก
+func (s *SealService) ConfirmSealIncident(incidentID uint, actor Actor, remark string) (*model.SealIncident, error) {
+	incident, _, err := s.loadPendingIncident(incidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		seal, err := lockSealByNumber(tx, incident.SealNumber)
+		if err != nil {
+			return errors.New("ไม่พบซีลในระบบ")
+		}
+		to, err := checkSealTransition(seal, incidentActions[incident.Type], actor)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		before := *seal
+		seal.Status = to
+		incident.Status = model.SealIncidentConfirmed
+		incident.ReviewedBy = &actor.ID
+		incident.ReviewedAt = &now
+		incident.ReviewRemark = remark
+
+		if err := saveSeal(tx, seal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &before, seal, incidentActions[incident.Type], actor, remark); err != nil {
+			return err
+		}
+		if err := tx.Save(incident).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ยืนยันซีล %s %s (รายงาน #%d)", seal.SealNumber, incidentTypeLabel(incident.Type), incident.ID),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return incident, nil
+}
+
+// RejectSealIncident admin ปฏิเสธรายงาน สถานะซีลคงเดิม
+func (s *SealService) RejectSealIncident(incidentID uint, actor Actor, remark string) (*model.SealIncident, error) {
+	if !actor.IsAdmin() {
+		return nil, errors.New("เฉพาะ admin เท่านั้นที่ปฏิเสธรายงานได้")
+	}
+	incident, seal, err := s.loadPendingIncident(incidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	incident.Status = model.SealIncidentRejected
+	incident.ReviewedBy = &actor.ID
+	incident.ReviewedAt = &now
+	incident.ReviewRemark = remark
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(incident).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ปฏิเสธรายงานซีล %s (รายงาน #%d) - หมายเหตุ: %s", seal.SealNumber, incident.ID, remark),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return incident, nil
+}
+
+// VoidSeal admin ยกเลิกซีลที่ยังไม่ถูกติดตั้ง (เช่น ซีลเสียจากโรงงาน)
+func (s *SealService) VoidSeal(sealNumber string, actor Actor, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("กรุณาระบุสาเหตุ")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		seal, err := lockSealByNumber(tx, sealNumber)
+		if err != nil {
+			return errors.New("ไม่พบซีลในระบบ")
+		}
+		to, err := checkSealTransition(seal, SealActionVoid, actor)
+		if err != nil {
+			return err
+		}
+		before := *seal
+		seal.Status = to
+		if before.Status == model.SealStatusReserved {
+			clearSealReservation(seal)
+		}
+
+		if err := saveSeal(tx, seal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &before, seal, SealActionVoid, actor, reason); err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ยกเลิกใช้งานซีล %s (สาเหตุ: %s)", sealNumber, reason),
+		}
+		return tx.Create(&logEntry).Error
+	})
+}
+
+func (s *SealService) loadPendingIncident(incidentID uint) (*model.SealIncident, *model.Seal, error) {
+	incident, err := s.incidentRepo.FindByID(incidentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if incident.Status != model.SealIncidentPending {
+		return nil, nil, fmt.Errorf("รายงาน #%d ถูกพิจารณาไปแล้ว (%s)", incident.ID, incident.Status)
+	}
+	seal, err := s.repo.FindByNumber(incident.SealNumber)
+	if err != nil {
+		return nil, nil, errors.New("ไม่พบซีลในระบบ")
+	}
+	return incident, seal, nil
+}
+
+func incidentTypeLabel(t model.SealIncidentType) string {
+	if t == model.SealIncidentDamaged {
+		return "ชำรุด"
+	}
+	return "สูญหาย"
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// -------------------------------------------------------------------
+// Ledger check: เล่นซ้ำสมุดบัญชี transactions ของซีลแต่ละเส้น แล้วเทียบกับแถวในตาราง seals
+// ใช้หลังเกิดเหตุขัดข้องหรือมีคนแก้ฐานข้อมูลตรง ๆ รายงานฟิลด์ที่ไม่ตรง (drift) และปรับแก้ได้ถ้าสั่ง repair
+// -------------------------------------------------------------------
+
+// sealLedgerCheckBatchSize จำนวนซีลที่ตรวจต่อรอบ (โหลดซีลและสมุดบัญชีทีละชุด)
+const sealLedgerCheckBatchSize = 500
+
+// SealFieldDrift ฟิลด์ที่ค่าในตาราง seals ไม่ตรงกับผลเล่นซ้ำสมุดบัญชี
+type SealFieldDrift struct {
+	Field   string          `json:"field"`
+	Ledger  json.RawMessage `json:"ledger"`
+	Current json.RawMessage `json:"current"`
+}
+
+// SealDrift ซีลหนึ่งเส้นที่ไม่ตรงกับสมุดบัญชี
+// Partial = สมุดบัญชีเริ่มหลังจากสร้างซีล (ซีลเก่า) จึงเทียบเฉพาะฟิลด์ที่เคยถูกบันทึก
+type SealDrift struct {
+	SealID     uint             `json:"seal_id"`
+	SealNumber string           `json:"seal_number"`
+	Entries    int              `json:"entries"`
+	Partial    bool             `json:"partial"`
+	Fields     []SealFieldDrift `json:"fields"`
+	Repaired   bool             `json:"repaired"`
+}
+
+// SealLedgerReport ผลตรวจ
+//   - untracked: ซีลที่ยังไม่มีรายการในสมุดบัญชีเลย (สร้างก่อนเริ่มบันทึก) ตรวจไม่ได้
+//   - drifts: ซีลที่ไม่ตรง พร้อมฟิลด์ที่ต่าง
+type SealLedgerReport struct {
+	Checked    int         `json:"checked"`
+	Consistent int         `json:"consistent"`
+	Drifted    int         `json:"drifted"`
+	Repaired   int         `json:"repaired"`
+	Repair     bool        `json:"repair"`
+	Untracked  []string    `json:"untracked"`
+	Drifts     []SealDrift `json:"drifts"`
+}
+
+// CheckSealLedger ตรวจซีลตามรายการเลข (ว่าง = ทุกเส้น) ถ้า repair จะปรับแถวซีลให้ตรงกับสมุดบัญชี
+// การปรับแต่ละเส้นอยู่ใน tx ของตัวเอง (ล็อกแถวแล้วตรวจซ้ำ) และถูกบันทึกเป็นรายการ reconcile
+func (s *SealService) CheckSealLedger(sealNumbers []string, repair bool, actor Actor) (*SealLedgerReport, error) {
+	report := &SealLedgerReport{Repair: repair, Untracked: []string{}, Drifts: []SealDrift{}}
+
+	check := func(seals []model.Seal) error {
+		if len(seals) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(seals))
+		for i := range seals {
+			ids[i] = seals[i].ID
+		}
+		entries, err := s.transactionRepo.FindBySeals(ids)
+		if err != nil {
+			return err
+		}
+		entriesBySeal := make(map[uint][]model.Transaction)
+		for _, entry := range entries {
+			entriesBySeal[entry.SealID] = append(entriesBySeal[entry.SealID], entry)
+		}
+		for i := range seals {
+			seal := &seals[i]
+			report.Checked++
+			drift, err := compareSealLedger(seal, entriesBySeal[seal.ID])
+			if err != nil {
+				return err
+			}
+			if drift == nil {
+				if len(entriesBySeal[seal.ID]) == 0 {
+					report.Untracked = append(report.Untracked, seal.SealNumber)
+				} else {
+					report.Consistent++
+				}
+				continue
+			}
+			report.Drifted++
+			if repair {
+				if drift, err = s.repairSealFromLedger(seal, actor); err != nil {
+					return err
+				}
+				if drift.Repaired {
+					report.Repaired++
+				}
+			}
+			report.Drifts = append(report.Drifts, *drift)
+		}
+		return nil
+	}
+
+	if len(sealNumbers) > 0 {
+		for start := 0; start < len(sealNumbers); start += sealLedgerCheckBatchSize {
+			end := min(start+sealLedgerCheckBatchSize, len(sealNumbers))
+			var seals []model.Seal
+			if err := s.db.Where("seal_number IN ?", sealNumbers[start:end]).Order("id").Find(&seals).Error; err != nil {
+				return nil, err
+			}
+			if err := check(seals); err != nil {
+				return nil, err
+			}
+		}
+		return report, nil
+	}
+
+	var lastID uint
+	for {
+		var seals []model.Seal
+		if err := s.db.Where("id > ?", lastID).Order("id").Limit(sealLedgerCheckBatchSize).Find(&seals).Error; err != nil {
+			return nil, err
+		}
+		if len(seals) == 0 {
+			return report, nil
+		}
+		if err := check(seals); err != nil {
+			return nil, err
+		}
+		lastID = seals[len(seals)-1].ID
+	}
+}
+
+// repairSealFromLedger ล็อกซีล ตรวจซ้ำ แล้วปรับให้ตรงกับผลเล่นซ้ำ (ถ้าตรงแล้วไม่ทำอะไร)
+func (s *SealService) repairSealFromLedger(target *model.Seal, actor Actor) (*SealDrift, error) {
+	var drift *SealDrift
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var seal model.Seal
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seal, target.ID).Error; err != nil {
+			return err
+		}
+		var entries []model.Transaction
+		if err := tx.Where("seal_id = ?", seal.ID).Order("created_at, id").Find(&entries).Error; err != nil {
+			return err
+		}
+		var err error
+		if drift, err = compareSealLedger(&seal, entries); err != nil || drift == nil {
+			return err
+		}
+
+		replayed, _, err := replaySealLedger(entries)
+		if err != nil {
+			return err
+		}
+		before := seal
+		if err := applySealLedgerState(&seal, replayed); err != nil {
+			return err
+		}
+		if err := saveSeal(tx, &seal); err != nil {
+			return err
+		}
+		fields := make([]string, len(drift.Fields))
+		for i, field := range drift.Fields {
+			fields[i] = field.Field
+		}
+		remark := "ปรับตามสมุดบัญชี: " + strings.Join(fields, ", ")
+		if err := recordSealHistory(tx, &before, &seal, SealActionReconcile, actor, remark); err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ปรับซีล %s ให้ตรงกับสมุดบัญชี ('%s' -> '%s') ฟิลด์ %s",
+				seal.SealNumber, before.Status.Label(), seal.Status.Label(), strings.Join(fields, ", ")),
+		}
+		if err := tx.Create(&logEntry).Error; err != nil {
+			return err
+		}
+		drift.Repaired = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if drift == nil {
+		// มีคนแก้ให้ตรงแล้วระหว่างตรวจ
+		return &SealDrift{SealID: target.ID, SealNumber: target.SealNumber}, nil
+	}
+	return drift, nil
+}
+
+// replaySealLedger เล่นซ้ำรายการของซีลหนึ่งเส้น (เรียงตาม created_at, id) คืนค่าล่าสุดของฟิลด์ที่เคยถูกบันทึก
+// รวม "status" partial = รายการแรกไม่ใช่ create
+func replaySealLedger(entries []model.Transaction) (map[string]json.RawMessage, bool, error) {
+	state := make(map[string]json.RawMessage)
+	if len(entries) == 0 {
+		return state, false, nil
+	}
+	for _, entry := range entries {
+		status, err := json.Marshal(entry.ToStatus)
+		if err != nil {
+			return nil, false, err
+		}
+		state["status"] = status
+		if len(entry.Metadata) == 0 {
+			continue
+		}
+		var metadata sealLedgerMetadata
+		if err := json.Unmarshal(entry.Metadata, &metadata); err != nil {
+			return nil, false, fmt.Errorf("ข้อมูลสมุดบัญชีรายการ #%d ของซีล %s เสียหาย: %v", entry.ID, entry.SealNumber, err)
+		}
+		for field, value := range metadata.Changes {
+			state[field] = value
+		}
+	}
+	return state, entries[0].Action != string(SealActionCreate), nil
+}
+
+// compareSealLedger เทียบแถวซีลกับผลเล่นซ้ำ คืน nil ถ้าตรงกัน (หรือยังไม่มีสมุดบัญชี)
+func compareSealLedger(seal *model.Seal, entries []model.Transaction) (*SealDrift, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	replayed, partial, err := replaySealLedger(entries)
+	if err != nil {
+		return nil, err
+	}
+	current, err := ledgerStateOf(seal)
+	if err != nil {
+		return nil, err
+	}
+	if current["status"], err = json.Marshal(seal.Status); err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(replayed))
+	for field := range replayed {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	drift := &SealDrift{SealID: seal.ID, SealNumber: seal.SealNumber, Entries: len(entries), Partial: partial}
+	for _, field := range fields {
+		if !sameLedgerValue(replayed[field], current[field]) {
+			drift.Fields = append(drift.Fields, SealFieldDrift{Field: field, Ledger: replayed[field], Current: current[field]})
+		}
+	}
+	if len(drift.Fields) == 0 {
+		return nil, nil
+	}
+	return drift, nil
+}
+
+// sameLedgerValue ค่าเดียวกัน เวลาเทียบที่ระดับไมโครวินาที (ความละเอียดของ Postgres)
+func sameLedgerValue(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var ta, tb time.Time
+	if json.Unmarshal(a, &ta) != nil || json.Unmarshal(b, &tb) != nil {
+		return false
+	}
+	return ta.Truncate(time.Microsecond).Equal(tb.Truncate(time.Microsecond))
+}
+
+// applySealLedgerState เขียนค่าจากผลเล่นซ้ำลงซีล (ฟิลด์ที่ไม่เคยถูกบันทึกคงค่าเดิม)
+func applySealLedgerState(seal *model.Seal, replayed map[string]json.RawMessage) error {
+	state, err := ledgerStateOf(seal)
+	if err != nil {
+		return err
+	}
+	status, hasStatus := replayed["status"]
+	for field, value := range replayed {
+		if field != "status" {
+			state[field] = value
+		}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	var merged sealLedgerState
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return err
+	}
+	if hasStatus {
+		if err := json.Unmarshal(status, &seal.Status); err != nil {
+			return err
+		}
+	}
+	seal.OfficeCode = merged.OfficeCode
+	seal.SealLotID = merged.SealLotID
+	seal.IssuedBy = merged.IssuedBy
+	seal.IssuedTo = merged.IssuedTo
+	seal.IssuedAt = merged.IssuedAt
+	seal.EmployeeCode = merged.EmployeeCode
+	seal.IssueRemark = merged.IssueRemark
+	seal.AssignedToTechnician = merged.AssignedToTechnician
+	seal.WorkOrderID = merged.WorkOrderID
+	seal.UsedBy = merged.UsedBy
+	seal.UsedAt = merged.UsedAt
+	seal.InstalledSerial = merged.InstalledSerial
+	seal.ReturnedBy = merged.ReturnedBy
+	seal.ReturnedAt = merged.ReturnedAt
+	seal.ReturnRemarks = merged.ReturnRemarks
+	seal.ReservedBy = merged.ReservedBy
+	seal.ReservedFor = merged.ReservedFor
+	seal.ReservedUntil = merged.ReservedUntil
+	seal.ReserveRemark = merged.ReserveRemark
+	return nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+)
+
+// ทดสอบการเล่นซ้ำสมุดบัญชีและการเทียบกับแถวซีล (ไม่ต้องใช้ฐานข้อมูล)
+
+// testSealLedger สร้างซีลหนึ่งเส้นที่ผ่านการสร้าง -> จ่าย -> ติดตั้ง พร้อมรายการในสมุดบัญชีของแต่ละขั้น
+func testSealLedger(t *testing.T) (*model.Seal, []model.Transaction) {
+	t.Helper()
+	var entries []model.Transaction
+	record := func(before, after *model.Seal, action SealAction, actor Actor) {
+		entry, err := newSealTransaction(before, after, action, actor, sealLedgerMetadata{})
+		if err != nil {
+			t.Fatalf("newSealTransaction(%s): %v", action, err)
+		}
+		entry.ID = uint(len(entries) + 1)
+		entries = append(entries, *entry)
+	}
+
+	seal := &model.Seal{ID: 7, SealNumber: "F0000007", Status: model.SealStatusAvailable, OfficeCode: "A01"}
+	record(nil, seal, SealActionCreate, UserActor(1, "admin"))
+
+	issuedAt := time.Date(2026, time.October, 17, 9, 15, 30, 123456789, time.UTC)
+	before := *seal
+	seal.Status = model.SealStatusIssued
+	seal.IssuedBy = uintPtr(1)
+	seal.IssuedTo = uintPtr(2)
+	seal.IssuedAt = &issuedAt
+	seal.AssignedToTechnician = uintPtr(10)
+	seal.IssueRemark = "งานเปลี่ยนมิเตอร์"
+	record(&before, seal, SealActionIssue, UserActor(1, "user"))
+
+	before = *seal
+	seal.Status = model.SealStatusInstalled
+	seal.UsedBy = uintPtr(10)
+	seal.InstalledSerial = "M-123456"
+	record(&before, seal, SealActionInstall, TechnicianActor(10))
+	return seal, entries
+}
+
+func TestReplaySealLedger(t *testing.T) {
+	seal, entries := testSealLedger(t)
+
+	replayed, partial, err := replaySealLedger(entries)
+	if err != nil {
+		t.Fatalf("replaySealLedger: %v", err)
+	}
+	if partial {
+		t.Error("ledger starting with create reported as partial")
+	}
+	want, err := ledgerStateOf(seal)
+	if err != nil {
+		t.Fatalf("ledgerStateOf: %v", err)
+	}
+	want["status"], _ = json.Marshal(seal.Status)
+	if len(replayed) != len(want) {
+		t.Errorf("replayed %d fields, want %d", len(replayed), len(want))
+	}
+	for field, value := range want {
+		if string(replayed[field]) != string(value) {
+			t.Errorf("%s: replayed %s, want %s", field, replayed[field], value)
+		}
+	}
+
+	// สมุดบัญชีที่เริ่มหลังสร้างซีล: มีเฉพาะฟิลด์ที่เคยถูกบันทึก
+	replayed, partial, err = replaySealLedger(entries[2:])
+	if err != nil {
+		t.Fatalf("replaySealLedger partial: %v", err)
+	}
+	if !partial {
+		t.Error("ledger without create not reported as partial")
+	}
+	if _, ok := replayed["office_code"]; ok {
+		t.Error("partial replay contains a field no entry recorded")
+	}
+	if string(replayed["installed_serial"]) != `"M-123456"` || string(replayed["status"]) != `"installed"` {
+		t.Errorf("partial replay = %v", replayed)
+	}
+
+	if replayed, partial, err := replaySealLedger(nil); err != nil || partial || len(replayed) != 0 {
+		t.Errorf("empty ledger = %v, %v, %v", replayed, partial, err)
+	}
+
+	broken := append([]model.Transaction{}, entries...)
+	broken[1].Metadata = json.RawMessage(`{"changes":`)
+	if _, _, err := replaySealLedger(broken); err == nil {
+		t.Error("corrupt metadata: expected error")
+	}
+}
+
+func TestCompareSealLedger(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(seal *model.Seal)
+		entries func(entries []model.Transaction) []model.Transaction
+		fields  []string
+		partial bool
+	}{
+		{name: "consistent"},
+		{name: "postgres drops nanoseconds", tamper: func(seal *model.Seal) {
+			issuedAt := seal.IssuedAt.Truncate(time.Microsecond)
+			seal.IssuedAt = &issuedAt
+		}},
+		{name: "status edited directly", tamper: func(seal *model.Seal) { seal.Status = model.SealStatusAvailable },
+			fields: []string{"status"}},
+		{name: "several fields", tamper: func(seal *model.Seal) {
+			seal.OfficeCode = "B02"
+			seal.AssignedToTechnician = nil
+		}, fields: []string{"assigned_to_technician", "office_code"}},
+		{name: "untracked field ignored in partial ledger",
+			tamper:  func(seal *model.Seal) { seal.OfficeCode = "B02" },
+			entries: func(entries []model.Transaction) []model.Transaction { return entries[2:] }},
+		{name: "partial ledger drift",
+			tamper:  func(seal *model.Seal) { seal.InstalledSerial = "" },
+			entries: func(entries []model.Transaction) []model.Transaction { return entries[2:] },
+			fields:  []string{"installed_serial"}, partial: true},
+		{name: "no ledger", tamper: func(seal *model.Seal) { seal.Status = model.SealStatusLost },
+			entries: func([]model.Transaction) []model.Transaction { return nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seal, entries := testSealLedger(t)
+			if tt.tamper != nil {
+				tt.tamper(seal)
+			}
+			if tt.entries != nil {
+				entries = tt.entries(entries)
+			}
+			drift, err := compareSealLedger(seal, entries)
+			if err != nil {
+				t.Fatalf("compareSealLedger: %v", err)
+			}
+			if len(tt.fields) == 0 {
+				if drift != nil {
+					t.Fatalf("unexpected drift: %+v", drift.Fields)
+				}
+				return
+			}
+			if drift == nil {
+				t.Fatalf("expected drift in %v", tt.fields)
+			}
+			if drift.Partial != tt.partial || drift.Entries != len(entries) || drift.SealNumber != seal.SealNumber {
+				t.Errorf("drift = {partial %v entries %d seal %s}", drift.Partial, drift.Entries, drift.SealNumber)
+			}
+			var got []string
+			for _, field := range drift.Fields {
+				got = append(got, field.Field)
+			}
+			if len(got) != len(tt.fields) {
+				t.Fatalf("drifted fields = %v, want %v", got, tt.fields)
+			}
+			for i := range got {
+				if got[i] != tt.fields[i] {
+					t.Errorf("drifted fields = %v, want %v", got, tt.fields)
+				}
+			}
+
+			// ปรับตามผลเล่นซ้ำแล้วต้องไม่เหลือ drift
+			replayed, _, err := replaySealLedger(entries)
+			if err != nil {
+				t.Fatalf("replaySealLedger: %v", err)
+			}
+			if err := applySealLedgerState(seal, replayed); err != nil {
+				t.Fatalf("applySealLedgerState: %v", err)
+			}
+			if drift, err := compareSealLedger(seal, entries); err != nil || drift != nil {
+				t.Errorf("after repair: drift %+v, err %v", drift, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+)
+
+// SealLotService สถิติคุณภาพซีลรายล็อต ใช้หาล็อตที่มีปัญหาของผู้ผลิต
+type SealLotService struct {
+	repo *repository.SealLotRepository
+}
+
+func NewSealLotService(repo *repository.SealLotRepository) *SealLotService {
+	return &SealLotService{repo: repo}
+}
+
+// SealLotStats ล็อตพร้อมจำนวนและอัตราต่อจำนวนซีลทั้งล็อต
+//   - issued_rate: ซีลที่ออกจากคลังไปแล้ว (ทุกสถานะยกเว้น พร้อมใช้งาน/ระหว่างขนส่ง/ยกเลิก)
+//   - installed_rate: ติดตั้งแล้ว + ใช้งานแล้ว
+//   - damaged_rate / lost_rate / void_rate: ชำรุด / สูญหาย / ยกเลิก
+type SealLotStats struct {
+	Lot           model.SealLot     `json:"lot"`
+	Total         int64             `json:"total"`
+	ByStatus      []SealStatusCount `json:"by_status"`
+	IssuedRate    float64           `json:"issued_rate"`
+	InstalledRate float64           `json:"installed_rate"`
+	DamagedRate   float64           `json:"damaged_rate"`
+	LostRate      float64           `json:"lost_rate"`
+	VoidRate      float64           `json:"void_rate"`
+}
+
+// GetSealLots รายการล็อตพร้อมสถิติ เรียงตามอัตราชำรุดมากไปน้อย
+func (s *SealLotService) GetSealLots(supplier string, lotNumber string) ([]SealLotStats, error) {
+	lots, err := s.repo.Search(supplier, lotNumber)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.buildSealLotStats(lots)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].DamagedRate > stats[j].DamagedRate
+	})
+	return stats, nil
+}
+
+// GetSealLot สถิติของล็อตเดียว
+func (s *SealLotService) GetSealLot(id uint) (*SealLotStats, error) {
+	lot, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.buildSealLotStats([]model.SealLot{*lot})
+	if err != nil {
+		return nil, err
+	}
+	return &stats[0], nil
+}
+
+func (s *SealLotService) buildSealLotStats(lots []model.SealLot) ([]SealLotStats, error) {
+	lotIDs := make([]uint, 0, len(lots))
+	for _, lot := range lots {
+		lotIDs = append(lotIDs, lot.ID)
+	}
+	rows, err := s.repo.CountSealsByStatus(lotIDs)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]map[model.SealStatus]int64)
+	for _, row := range rows {
+		if counts[row.SealLotID] == nil {
+			counts[row.SealLotID] = make(map[model.SealStatus]int64)
+		}
+		counts[row.SealLotID][row.Status] = row.Count
+	}
+
+	stats := make([]SealLotStats, 0, len(lots))
+	for _, lot := range lots {
+		lotCounts := counts[lot.ID]
+		stat := SealLotStats{Lot: lot, ByStatus: []SealStatusCount{}}
+		for _, status := range model.AllSealStatuses() {
+			stat.Total += lotCounts[status]
+			stat.ByStatus = append(stat.ByStatus, SealStatusCount{
+				Status: status,
+				Label:  status.Label(),
+				Count:  lotCounts[status],
+			})
+		}
+		if stat.Total > 0 {
+			total := float64(stat.Total)
+			issued := stat.Total - lotCounts[model.SealStatusAvailable] - lotCounts[model.SealStatusInTransit] - lotCounts[model.SealStatusReserved] - lotCounts[model.SealStatusVoid]
+			stat.IssuedRate = float64(issued) / total
+			stat.InstalledRate = float64(lotCounts[model.SealStatusInstalled]+lotCounts[model.SealStatusUsed]) / total
+			stat.DamagedRate = float64(lotCounts[model.SealStatusDamaged]) / total
+			stat.LostRate = float64(lotCounts[model.SealStatusLost]) / total
+			stat.VoidRate = float64(lotCounts[model.SealStatusVoid]) / total
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
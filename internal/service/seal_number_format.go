@@ -0,0 +1,318 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+)
+
+// -------------------------------------------------------------------
+// Seal number registry: ตรวจและสร้างเลขซีลตามรูปแบบของผู้ผลิตที่ลงทะเบียนไว้
+// ยังไม่ลงทะเบียนรูปแบบใดเลย = ใช้รูปแบบเดิม (prefix ตัวอักษร + ตัวเลข)
+// ลงทะเบียนแล้ว: Parse ยังรับรูปแบบเดิมเป็นทางสำรอง (ซีลเก่าในระบบ) แต่เลขใหม่ต้องตรงรูปแบบที่ลงทะเบียน (ValidateNew/Next)
+// -------------------------------------------------------------------
+
+// ErrInvalidSealNumber เลขซีลไม่ตรงกับรูปแบบใดที่ลงทะเบียนไว้ หรือเลขตรวจสอบไม่ถูกต้อง
+var ErrInvalidSealNumber = errors.New("รูปแบบเลขซีลไม่ถูกต้อง")
+
+// legacySealNumberPattern รูปแบบเดิมก่อนมีการลงทะเบียนรูปแบบเลขซีล
+var legacySealNumberPattern = regexp.MustCompile(`^([A-Za-z]*)(\d+)$`)
+
+// ParsedSealNumber เลขซีลที่แยกส่วนแล้ว ใช้สร้างเลขถัดไปในรูปแบบเดียวกัน
+type ParsedSealNumber struct {
+	Format     *model.SealNumberFormat `json:"format,omitempty"` // nil = รูปแบบเดิม
+	Head       string                  `json:"head"`             // ส่วนก่อนเลขลำดับ (prefix, year code, ตัวคั่น)
+	YearCode   string                  `json:"year_code,omitempty"`
+	Serial     int64                   `json:"serial"`
+	CheckDigit string                  `json:"check_digit,omitempty"`
+	Tail       string                  `json:"tail,omitempty"` // ส่วนหลังเลขลำดับ (ตัวคั่น + suffix)
+
+	width int
+}
+
+// fixedWidth จำนวนหลักของเลขลำดับที่รูปแบบกำหนดไว้ (0 = ไม่กำหนด เช่น รูปแบบเดิม)
+func (p *ParsedSealNumber) fixedWidth() int {
+	if p.Format != nil && p.Format.Width > 0 {
+		return p.width
+	}
+	return 0
+}
+
+// WithSerial เลขซีลรูปแบบเดียวกันที่เลขลำดับ serial (คำนวณเลขตรวจสอบใหม่)
+func (p *ParsedSealNumber) WithSerial(serial int64) (string, error) {
+	digits := fmt.Sprintf("%0*d", p.width, serial)
+	if p.Format != nil && p.Format.Width > 0 && len(digits) > p.Format.Width {
+		return "", fmt.Errorf("เลขลำดับ %d เกิน %d หลักของรูปแบบ %s", serial, p.Format.Width, p.Format.Name)
+	}
+	check := ""
+	if p.Format != nil && p.Format.CheckDigit != model.SealCheckDigitNone {
+		c, err := sealCheckDigit(p.Format.CheckDigit, p.YearCode+digits)
+		if err != nil {
+			return "", err
+		}
+		check = c
+	}
+	return p.Head + digits + check + p.Tail, nil
+}
+
+type compiledSealNumberFormat struct {
+	format  model.SealNumberFormat
+	pattern *regexp.Regexp
+}
+
+// SealNumberRegistry รูปแบบเลขซีลที่เปิดใช้ ณ ตอนโหลด
+type SealNumberRegistry struct {
+	formats []compiledSealNumberFormat
+}
+
+// NewSealNumberRegistry สร้าง registry จากรูปแบบที่เปิดใช้
+func NewSealNumberRegistry(formats []model.SealNumberFormat) (*SealNumberRegistry, error) {
+	registry := &SealNumberRegistry{}
+	for _, format := range formats {
+		pattern, err := compileSealNumberFormat(format)
+		if err != nil {
+			return nil, err
+		}
+		registry.formats = append(registry.formats, compiledSealNumberFormat{format: format, pattern: pattern})
+	}
+	return registry, nil
+}
+
+// compileSealNumberFormat แปลงรูปแบบเป็น regexp: prefix, year, serial+check, suffix คั่นด้วย separator
+func compileSealNumberFormat(format model.SealNumberFormat) (*regexp.Regexp, error) {
+	if err := validateSealNumberFormat(format); err != nil {
+		return nil, err
+	}
+	var parts []string
+	if format.Prefix != "" {
+		parts = append(parts, regexp.QuoteMeta(format.Prefix))
+	}
+	if format.YearDigits > 0 {
+		parts = append(parts, fmt.Sprintf(`(?P<year>\d{%d})`, format.YearDigits))
+	}
+	serial := `(?P<serial>\d+)`
+	if format.Width > 0 {
+		serial = fmt.Sprintf(`(?P<serial>\d{%d})`, format.Width)
+	}
+	switch format.CheckDigit {
+	case model.SealCheckDigitMod10:
+		serial += `(?P<check>\d)`
+	case model.SealCheckDigitMod11:
+		serial += `(?P<check>[0-9X])`
+	}
+	parts = append(parts, serial)
+	if format.Suffix != "" {
+		parts = append(parts, regexp.QuoteMeta(format.Suffix))
+	}
+	return regexp.Compile("^" + strings.Join(parts, regexp.QuoteMeta(format.Separator)) + "$")
+}
+
+// validateSealNumberFormat ตรวจการตั้งค่ารูปแบบก่อนบันทึก/ใช้งาน
+func validateSealNumberFormat(format model.SealNumberFormat) error {
+	if strings.TrimSpace(format.Name) == "" {
+		return errors.New("กรุณาระบุชื่อรูปแบบเลขซีล")
+	}
+	if format.Width < 0 || format.Width > 18 {
+		return errors.New("จำนวนหลักของเลขลำดับต้องอยู่ระหว่าง 0-18 (0 = ไม่จำกัด)")
+	}
+	if format.YearDigits != 0 && format.YearDigits != 2 && format.YearDigits != 4 {
+		return errors.New("year code ต้องเป็น 0, 2 หรือ 4 หลัก")
+	}
+	switch format.CheckDigit {
+	case model.SealCheckDigitNone, model.SealCheckDigitMod10, model.SealCheckDigitMod11:
+	default:
+		return fmt.Errorf("ไม่รู้จักอัลกอริทึมเลขตรวจสอบ: %s", format.CheckDigit)
+	}
+	if len(format.Separator) > 1 || strings.ContainsAny(format.Separator, "0123456789") {
+		return errors.New("ตัวคั่นต้องเป็นอักขระเดียวที่ไม่ใช่ตัวเลข")
+	}
+	if format.Prefix == "" && format.Suffix == "" && format.YearDigits == 0 {
+		return errors.New("รูปแบบต้องมี prefix, suffix หรือ year code อย่างน้อยหนึ่งอย่าง")
+	}
+	return nil
+}
+
+// Parse แยกเลขซีลตามรูปแบบแรกที่ตรง และตรวจเลขตรวจสอบ
+// ไม่ตรงรูปแบบใดเลย หรือเลขตรวจสอบไม่ถูก จะลองรูปแบบเดิม (Format = nil) ดู Registered
+// ⚠️ รูปแบบที่ไม่กำหนดจำนวนหลักตรงกับเลขเดิมที่ prefix เดียวกันได้ (เช่น F0001234) จึงต้องลองรูปแบบเดิมหลังเลขตรวจสอบไม่ผ่าน
+func (r *SealNumberRegistry) Parse(sealNumber string) (*ParsedSealNumber, error) {
+	parsed, checkErr := r.matchRegistered(sealNumber)
+	if parsed != nil {
+		return parsed, nil
+	}
+	legacy, err := parseLegacySealNumber(sealNumber)
+	if err == nil {
+		return legacy, nil
+	}
+	if checkErr != nil {
+		return nil, checkErr
+	}
+	return nil, err
+}
+
+// matchRegistered แยกเลขซีลตามรูปแบบที่ลงทะเบียนไว้
+// ไม่ตรงรูปแบบใดคืน nil, nil / ตรงรูปแบบแต่เลขตรวจสอบผิดคืน nil พร้อม error ของเลขตรวจสอบ
+func (r *SealNumberRegistry) matchRegistered(sealNumber string) (*ParsedSealNumber, error) {
+	var checkErr error
+	for i := range r.formats {
+		compiled := &r.formats[i]
+		matches := compiled.pattern.FindStringSubmatchIndex(sealNumber)
+		if matches == nil {
+			continue
+		}
+		group := func(name string) (string, int, int) {
+			idx := compiled.pattern.SubexpIndex(name)
+			if idx < 0 || matches[2*idx] < 0 {
+				return "", -1, -1
+			}
+			return sealNumber[matches[2*idx]:matches[2*idx+1]], matches[2*idx], matches[2*idx+1]
+		}
+		year, _, _ := group("year")
+		digits, start, end := group("serial")
+		check, _, _ := group("check")
+		if check != "" {
+			expected, err := sealCheckDigit(compiled.format.CheckDigit, year+digits)
+			if err != nil {
+				return nil, err
+			}
+			if expected != check {
+				checkErr = fmt.Errorf("%w: %s เลขตรวจสอบไม่ถูกต้อง", ErrInvalidSealNumber, sealNumber)
+				continue
+			}
+			end += len(check)
+		}
+		serial, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			checkErr = fmt.Errorf("%w: %s", ErrInvalidSealNumber, sealNumber)
+			continue
+		}
+		return &ParsedSealNumber{
+			Format:     &compiled.format,
+			Head:       sealNumber[:start],
+			YearCode:   year,
+			Serial:     serial,
+			CheckDigit: check,
+			Tail:       sealNumber[end:],
+			width:      len(digits),
+		}, nil
+	}
+	return nil, checkErr
+}
+
+// Registered เลขที่แยกได้ตรงกับรูปแบบที่ลงทะเบียนไว้ (ยังไม่ลงทะเบียนรูปแบบใด = รูปแบบเดิมถือว่าตรง)
+func (r *SealNumberRegistry) Registered(parsed *ParsedSealNumber) bool {
+	return len(r.formats) == 0 || parsed.Format != nil
+}
+
+// parseNew แยกเลขซีลใหม่ ต้องตรงรูปแบบที่ลงทะเบียนไว้ (ไม่รับรูปแบบเดิมเมื่อมีการลงทะเบียนแล้ว)
+func (r *SealNumberRegistry) parseNew(sealNumber string) (*ParsedSealNumber, error) {
+	if len(r.formats) == 0 {
+		return parseLegacySealNumber(sealNumber)
+	}
+	parsed, checkErr := r.matchRegistered(sealNumber)
+	if parsed != nil {
+		return parsed, nil
+	}
+	if checkErr != nil {
+		return nil, checkErr
+	}
+	return nil, fmt.Errorf("%w: %s ไม่ตรงกับรูปแบบเลขซีลที่ลงทะเบียนไว้", ErrInvalidSealNumber, sealNumber)
+}
+
+func parseLegacySealNumber(sealNumber string) (*ParsedSealNumber, error) {
+	matches := legacySealNumberPattern.FindStringSubmatch(sealNumber)
+	if len(matches) != 3 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSealNumber, sealNumber)
+	}
+	serial, err := strconv.ParseInt(matches[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSealNumber, sealNumber)
+	}
+	return &ParsedSealNumber{Head: matches[1], Serial: serial, width: len(matches[2])}, nil
+}
+
+// Validate เลขซีลทุกเส้นต้องแยกได้ (รูปแบบที่ลงทะเบียนไว้ หรือรูปแบบเดิม) รวมเลขที่ผิดทั้งหมดไว้ใน error เดียว
+func (r *SealNumberRegistry) Validate(sealNumbers ...string) error {
+	return r.validate(r.Parse, sealNumbers)
+}
+
+// ValidateNew เลขซีลใหม่ทุกเส้นต้องตรงกับรูปแบบที่ลงทะเบียนไว้
+func (r *SealNumberRegistry) ValidateNew(sealNumbers ...string) error {
+	return r.validate(r.parseNew, sealNumbers)
+}
+
+func (r *SealNumberRegistry) validate(parse func(string) (*ParsedSealNumber, error), sealNumbers []string) error {
+	var invalid []string
+	var firstErr error
+	for _, sealNumber := range sealNumbers {
+		if _, err := parse(sealNumber); err != nil {
+			invalid = append(invalid, sealNumber)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if len(invalid) > 1 {
+		return fmt.Errorf("%w: %s", ErrInvalidSealNumber, strings.Join(invalid, ", "))
+	}
+	return firstErr
+}
+
+// Next เลขซีลต่อเนื่อง count เส้นเริ่มจาก start (รวม start) ในรูปแบบเดียวกับ start
+func (r *SealNumberRegistry) Next(start string, count int) ([]string, error) {
+	if count <= 0 {
+		return nil, errors.New("จำนวนซีลต้องมากกว่า 0")
+	}
+	parsed, err := r.parseNew(start)
+	if err != nil {
+		return nil, err
+	}
+	sealNumbers := make([]string, count)
+	for i := 0; i < count; i++ {
+		sealNumbers[i], err = parsed.WithSerial(parsed.Serial + int64(i))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sealNumbers, nil
+}
+
+// sealCheckDigit คำนวณเลขตรวจสอบของ digits
+func sealCheckDigit(algorithm model.SealCheckDigit, digits string) (string, error) {
+	switch algorithm {
+	case model.SealCheckDigitMod10:
+		// Luhn: คูณสองทุกหลักเว้นหลัก เริ่มจากหลักขวาสุด
+		sum := 0
+		for i := 0; i < len(digits); i++ {
+			d := int(digits[len(digits)-1-i] - '0')
+			if i%2 == 0 {
+				d *= 2
+				if d > 9 {
+					d -= 9
+				}
+			}
+			sum += d
+		}
+		return strconv.Itoa((10 - sum%10) % 10), nil
+	case model.SealCheckDigitMod11:
+		// น้ำหนัก 2,3,4,5,6,7 วนซ้ำจากหลักขวาสุด
+		sum := 0
+		for i := 0; i < len(digits); i++ {
+			d := int(digits[len(digits)-1-i] - '0')
+			sum += d * (2 + i%6)
+		}
+		switch check := 11 - sum%11; check {
+		case 11:
+			return "0", nil
+		case 10:
+			return "X", nil
+		default:
+			return strconv.Itoa(check), nil
+		}
+	}
+	return "", fmt.Errorf("ไม่รู้จักอัลกอริทึมเลขตรวจสอบ: %s", algorithm)
+}
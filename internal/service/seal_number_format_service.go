@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+)
+
+// sealNumberRegistryTTL อายุของ registry ที่ cache ไว้ (replica อื่นที่แก้รูปแบบจะเห็นผลภายในเวลานี้)
+const sealNumberRegistryTTL = time.Minute
+
+// SealNumberFormatService จัดการรูปแบบเลขซีลที่ลงทะเบียนไว้ และตรวจเลขซีลตามรูปแบบเหล่านั้น
+type SealNumberFormatService struct {
+	repo *repository.SealNumberFormatRepository
+
+	mu         sync.Mutex
+	registry   *SealNumberRegistry
+	registryAt time.Time
+}
+
+func NewSealNumberFormatService(repo *repository.SealNumberFormatRepository) *SealNumberFormatService {
+	return &SealNumberFormatService{repo: repo}
+}
+
+// SealNumberCheck ผลการตรวจเลขซีลหนึ่งเส้น
+type SealNumberCheck struct {
+	SealNumber string            `json:"seal_number"`
+	Valid      bool              `json:"valid"`
+	Parsed     *ParsedSealNumber `json:"parsed,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+func (s *SealNumberFormatService) GetFormats() ([]model.SealNumberFormat, error) {
+	return s.repo.FindAll()
+}
+
+// Registry รูปแบบที่เปิดใช้อยู่ในขณะนี้ (cache ไว้ ล้างเมื่อสร้าง/แก้ไข/ลบรูปแบบ หรือเมื่อครบ sealNumberRegistryTTL)
+func (s *SealNumberFormatService) Registry() (*SealNumberRegistry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.registry != nil && time.Since(s.registryAt) < sealNumberRegistryTTL {
+		return s.registry, nil
+	}
+	formats, err := s.repo.FindActive()
+	if err != nil {
+		return nil, err
+	}
+	registry, err := NewSealNumberRegistry(formats)
+	if err != nil {
+		return nil, err
+	}
+	s.registry, s.registryAt = registry, time.Now()
+	return registry, nil
+}
+
+// invalidateRegistry ให้ Registry ครั้งถัดไปโหลดรูปแบบใหม่
+func (s *SealNumberFormatService) invalidateRegistry() {
+	s.mu.Lock()
+	s.registry = nil
+	s.mu.Unlock()
+}
+
+// ValidateNewSealNumbers เลขซีลใหม่ทุกเส้นต้องตรงกับรูปแบบที่เปิดใช้ (error ห่อ ErrInvalidSealNumber)
+func (s *SealNumberFormatService) ValidateNewSealNumbers(sealNumbers ...string) error {
+	registry, err := s.Registry()
+	if err != nil {
+		return err
+	}
+	return registry.ValidateNew(sealNumbers...)
+}
+
+// CheckSealNumbers ผลการตรวจรายเส้น สำหรับหน้าจอสแกน/ตรวจก่อนบันทึก
+func (s *SealNumberFormatService) CheckSealNumbers(sealNumbers []string) ([]SealNumberCheck, error) {
+	registry, err := s.Registry()
+	if err != nil {
+		return nil, err
+	}
+	checks := make([]SealNumberCheck, 0, len(sealNumbers))
+	for _, sealNumber := range sealNumbers {
+		check := SealNumberCheck{SealNumber: sealNumber}
+		parsed, err := registry.Parse(sealNumber)
+		if err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Valid = true
+			check.Parsed = parsed
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// SaveFormat สร้าง (ID = 0) หรือแก้ไขรูปแบบเลขซีล
+func (s *SealNumberFormatService) SaveFormat(format *model.SealNumberFormat, actor Actor) error {
+	format.Name = strings.TrimSpace(format.Name)
+	if _, err := compileSealNumberFormat(*format); err != nil {
+		return err
+	}
+	if format.ID != 0 {
+		existing, err := s.repo.FindByID(format.ID)
+		if err != nil {
+			return errors.New("ไม่พบรูปแบบเลขซีล")
+		}
+		format.CreatedAt = existing.CreatedAt
+	}
+	format.UpdatedBy = actor.ID
+	if err := s.repo.Save(format); err != nil {
+		return err
+	}
+	s.invalidateRegistry()
+	return nil
+}
+
+func (s *SealNumberFormatService) DeleteFormat(id uint) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return errors.New("ไม่พบรูปแบบเลขซีล")
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidateRegistry()
+	return nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+)
+
+// ทดสอบ registry ของรูปแบบเลขซีล (ไม่ต้องใช้ฐานข้อมูล)
+
+func newTestSealNumberRegistry(t *testing.T, formats ...model.SealNumberFormat) *SealNumberRegistry {
+	t.Helper()
+	registry, err := NewSealNumberRegistry(formats)
+	if err != nil {
+		t.Fatalf("NewSealNumberRegistry: %v", err)
+	}
+	return registry
+}
+
+var (
+	testYearFormat = model.SealNumberFormat{
+		Name: "pea-year", Prefix: "PEA", Separator: "-", YearDigits: 2, Width: 6, CheckDigit: model.SealCheckDigitMod10,
+	}
+	testFixedMod11Format = model.SealNumberFormat{
+		Name: "k-mod11", Prefix: "K", Width: 5, CheckDigit: model.SealCheckDigitMod11,
+	}
+	// ไม่กำหนดจำนวนหลัก จึงตรงกับเลขเดิมที่ขึ้นต้นด้วย F ได้
+	testOpenMod11Format = model.SealNumberFormat{
+		Name: "f-open", Prefix: "F", CheckDigit: model.SealCheckDigitMod11,
+	}
+)
+
+func TestSealCheckDigit(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm model.SealCheckDigit
+		digits    string
+		want      string
+	}{
+		{"luhn textbook", model.SealCheckDigitMod10, "7992739871", "3"},
+		{"luhn zero", model.SealCheckDigitMod10, "00000", "0"},
+		{"luhn with year", model.SealCheckDigitMod10, "26000123", "5"},
+		{"mod11 digit", model.SealCheckDigitMod11, "00001", "9"},
+		{"mod11 remainder 10 is X", model.SealCheckDigitMod11, "00006", "X"},
+		{"mod11 remainder 11 is 0", model.SealCheckDigitMod11, "00000", "0"},
+		{"mod11 weights wrap after 7", model.SealCheckDigitMod11, "7992739871", "6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sealCheckDigit(tt.algorithm, tt.digits)
+			if err != nil {
+				t.Fatalf("sealCheckDigit: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("sealCheckDigit(%s, %q) = %q, want %q", tt.algorithm, tt.digits, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := sealCheckDigit("mod97", "123"); err == nil {
+		t.Error("unknown algorithm: expected error")
+	}
+}
+
+func TestSealNumberRegistryParse(t *testing.T) {
+	registry := newTestSealNumberRegistry(t, testYearFormat, testFixedMod11Format, testOpenMod11Format)
+	tests := []struct {
+		name       string
+		sealNumber string
+		format     string // ว่าง = รูปแบบเดิม
+		head       string
+		serial     int64
+		check      string
+		wantErr    bool
+	}{
+		{name: "year code and luhn", sealNumber: "PEA-26-0001235", format: "pea-year", head: "PEA-26-", serial: 123, check: "5"},
+		{name: "luhn mismatch", sealNumber: "PEA-26-0001236", wantErr: true},
+		{name: "wrong width", sealNumber: "PEA-26-00012355", wantErr: true},
+		{name: "mod11 X", sealNumber: "K00006X", format: "k-mod11", head: "K", serial: 6, check: "X"},
+		{name: "mod11 digit", sealNumber: "K000019", format: "k-mod11", head: "K", serial: 1, check: "9"},
+		{name: "open width mod11", sealNumber: "F0001236", format: "f-open", head: "F", serial: 123, check: "6"},
+		{name: "legacy fallback after check digit mismatch", sealNumber: "F0001234", head: "F", serial: 1234},
+		{name: "legacy fallback without matching format", sealNumber: "A42", head: "A", serial: 42},
+		{name: "garbage", sealNumber: "F-12", wantErr: true},
+		{name: "empty", sealNumber: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := registry.Parse(tt.sealNumber)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSealNumber) {
+					t.Fatalf("Parse(%q) error = %v, want ErrInvalidSealNumber", tt.sealNumber, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.sealNumber, err)
+			}
+			format := ""
+			if parsed.Format != nil {
+				format = parsed.Format.Name
+			}
+			if format != tt.format || parsed.Head != tt.head || parsed.Serial != tt.serial || parsed.CheckDigit != tt.check {
+				t.Errorf("Parse(%q) = {format %q head %q serial %d check %q}, want {%q %q %d %q}",
+					tt.sealNumber, format, parsed.Head, parsed.Serial, parsed.CheckDigit, tt.format, tt.head, tt.serial, tt.check)
+			}
+			if registry.Registered(parsed) != (tt.format != "") {
+				t.Errorf("Registered(%q) = %v", tt.sealNumber, registry.Registered(parsed))
+			}
+			again, err := parsed.WithSerial(parsed.Serial)
+			if err != nil || again != tt.sealNumber {
+				t.Errorf("WithSerial(%d) = %q, %v; want %q", parsed.Serial, again, err, tt.sealNumber)
+			}
+		})
+	}
+}
+
+func TestSealNumberRegistryValidateNewRejectsLegacy(t *testing.T) {
+	registry := newTestSealNumberRegistry(t, testOpenMod11Format)
+	if err := registry.Validate("F0001234"); err != nil {
+		t.Errorf("Validate legacy number: %v", err)
+	}
+	if err := registry.ValidateNew("F0001234"); !errors.Is(err, ErrInvalidSealNumber) {
+		t.Errorf("ValidateNew legacy number error = %v, want ErrInvalidSealNumber", err)
+	}
+	if err := registry.ValidateNew("F0001236"); err != nil {
+		t.Errorf("ValidateNew registered number: %v", err)
+	}
+}
+
+func TestSealNumberRegistryWithoutFormats(t *testing.T) {
+	registry := newTestSealNumberRegistry(t)
+	parsed, err := registry.Parse("F0001234")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if parsed.Format != nil || parsed.Head != "F" || parsed.Serial != 1234 || !registry.Registered(parsed) {
+		t.Errorf("Parse = %+v, want legacy F/1234 treated as registered", parsed)
+	}
+	next, err := registry.Next("F0001234", 2)
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if len(next) != 2 || next[0] != "F0001234" || next[1] != "F0001235" {
+		t.Errorf("Next = %v", next)
+	}
+}
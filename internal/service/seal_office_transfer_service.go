@@ -0,0 +1,206 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// -------------------------------------------------------------------
+// Office transfers: ส่งซีลพร้อมใช้งานไปกฟฟ.อื่น -> ซีล 'ระหว่างขนส่ง' -> ปลายทางตรวจรับ
+// -------------------------------------------------------------------
+
+// DispatchSealsToOffice ต้นทางส่งซีลไปสำนักงาน toOffice
+// ซีลทุกเส้นต้องเป็นของสำนักงานเดียวกันและผ่านตาราง transition (พนักงานต้นทางหรือ admin)
+func (s *SealService) DispatchSealsToOffice(sealNumbers []string, toOffice string, actor Actor, remark string) (*model.OfficeTransfer, error) {
+	toOffice = strings.TrimSpace(toOffice)
+	if len(sealNumbers) == 0 {
+		return nil, errors.New("กรุณาระบุซีลที่ต้องการส่ง")
+	}
+	if toOffice == "" {
+		return nil, errors.New("กรุณาระบุสำนักงานปลายทาง")
+	}
+	exists, err := s.officeTransferRepo.OfficeExists(toOffice)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("ไม่พบสำนักงานรหัส %s", toOffice)
+	}
+
+	var seals []*model.Seal
+	var transfer *model.OfficeTransfer
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		fromOffice := ""
+		for i, sn := range sealNumbers {
+			seal, err := lockSealByNumber(tx, sn)
+			if err != nil {
+				return fmt.Errorf("ซีล %s ไม่พบในระบบ", sn)
+			}
+			if i == 0 {
+				fromOffice = seal.OfficeCode
+			} else if seal.OfficeCode != fromOffice {
+				return fmt.Errorf("ซีล %s ไม่ได้อยู่ในสำนักงานเดียวกับซีลอื่นในรายการ", sn)
+			}
+			to, err := checkSealTransition(seal, SealActionDispatch, actor)
+			if err != nil {
+				return err
+			}
+			before := *seal
+			seal.Status = to
+			if err := saveSeal(tx, seal); err != nil {
+				return err
+			}
+			if err := recordSealHistory(tx, &before, seal, SealActionDispatch, actor, "ส่งไปสำนักงาน "+toOffice); err != nil {
+				return err
+			}
+			seals = append(seals, seal)
+		}
+		if fromOffice == toOffice {
+			return errors.New("สำนักงานปลายทางต้องไม่ใช่สำนักงานเดียวกับต้นทาง")
+		}
+
+		transfer = &model.OfficeTransfer{
+			FromOffice:   fromOffice,
+			ToOffice:     toOffice,
+			Status:       model.OfficeTransferInTransit,
+			Remark:       strings.TrimSpace(remark),
+			DispatchedBy: actor.ID,
+			DispatchedAt: time.Now(),
+		}
+		for _, seal := range seals {
+			transfer.Items = append(transfer.Items, model.OfficeTransferItem{
+				SealID:     seal.ID,
+				SealNumber: seal.SealNumber,
+			})
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ส่งซีล %s จากสำนักงาน %s ไปยังสำนักงาน %s (รายการส่ง #%d)",
+				joinOfficeTransferItems(transfer.Items), officeLabel(fromOffice), toOffice, transfer.ID),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	dispatched := make([]model.Seal, 0, len(seals))
+	for _, seal := range seals {
+		dispatched = append(dispatched, *seal)
+	}
+	s.checkSealStock(dispatched...)
+	return transfer, nil
+}
+
+// ReceiveOfficeTransfer ปลายทางตรวจรับ -> ซีลเป็นของสำนักงานปลายทางและกลับเป็น 'พร้อมใช้งาน'
+func (s *SealService) ReceiveOfficeTransfer(transferID uint, actor Actor) (*model.OfficeTransfer, error) {
+	transfer, err := s.loadInTransitOfficeTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.IsAdmin() && actor.Office != transfer.ToOffice {
+		return nil, errors.New("เฉพาะสำนักงานปลายทางเท่านั้นที่ตรวจรับได้")
+	}
+	return s.closeOfficeTransfer(transfer, actor, SealActionReceive, model.OfficeTransferReceived, transfer.ToOffice,
+		fmt.Sprintf("สำนักงาน %s ตรวจรับซีล %s จากสำนักงาน %s (รายการส่ง #%d)",
+			transfer.ToOffice, joinOfficeTransferItems(transfer.Items), officeLabel(transfer.FromOffice), transfer.ID))
+}
+
+// RecallOfficeTransfer ต้นทางเรียกคืนรายการส่งที่ปลายทางยังไม่รับ ซีลกลับเป็นของต้นทาง
+func (s *SealService) RecallOfficeTransfer(transferID uint, actor Actor) (*model.OfficeTransfer, error) {
+	transfer, err := s.loadInTransitOfficeTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	return s.closeOfficeTransfer(transfer, actor, SealActionRecall, model.OfficeTransferRecalled, transfer.FromOffice,
+		fmt.Sprintf("สำนักงาน %s เรียกคืนการส่งซีล %s (รายการส่ง #%d)",
+			officeLabel(transfer.FromOffice), joinOfficeTransferItems(transfer.Items), transfer.ID))
+}
+
+// GetOfficeTransfers รายการส่งของสำนักงาน (office ว่าง = ทุกสำนักงาน)
+func (s *SealService) GetOfficeTransfers(office string, incoming bool, status model.OfficeTransferStatus) ([]model.OfficeTransfer, error) {
+	return s.officeTransferRepo.FindByOffice(office, incoming, status)
+}
+
+func (s *SealService) closeOfficeTransfer(
+	transfer *model.OfficeTransfer,
+	actor Actor,
+	action SealAction,
+	status model.OfficeTransferStatus,
+	ownerOffice string,
+	logAction string,
+) (*model.OfficeTransfer, error) {
+	now := time.Now()
+	var seals []model.Seal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range transfer.Items {
+			var seal model.Seal
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seal, item.SealID).Error; err != nil {
+				return fmt.Errorf("ไม่พบซีล %s", item.SealNumber)
+			}
+			to, err := checkSealTransition(&seal, action, actor)
+			if err != nil {
+				return err
+			}
+			before := seal
+			seal.Status = to
+			seal.OfficeCode = ownerOffice
+			if err := saveSeal(tx, &seal); err != nil {
+				return err
+			}
+			if err := recordSealHistory(tx, &before, &seal, action, actor, fmt.Sprintf("รายการส่ง #%d", transfer.ID)); err != nil {
+				return err
+			}
+			seals = append(seals, seal)
+		}
+
+		transfer.Status = status
+		transfer.ReceivedBy = &actor.ID
+		transfer.ReceivedAt = &now
+		if err := tx.Omit("Items").Save(transfer).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{UserID: actor.ID, Action: logAction}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.checkSealStock(seals...)
+	return transfer, nil
+}
+
+func (s *SealService) loadInTransitOfficeTransfer(transferID uint) (*model.OfficeTransfer, error) {
+	transfer, err := s.officeTransferRepo.FindByID(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != model.OfficeTransferInTransit {
+		return nil, fmt.Errorf("รายการส่ง #%d ถูกดำเนินการไปแล้ว (%s)", transfer.ID, transfer.Status)
+	}
+	return transfer, nil
+}
+
+func joinOfficeTransferItems(items []model.OfficeTransferItem) string {
+	numbers := make([]string, 0, len(items))
+	for _, item := range items {
+		numbers = append(numbers, item.SealNumber)
+	}
+	return strings.Join(numbers, ", ")
+}
+
+// officeLabel ใช้แสดงใน log (ซีลเดิมที่ยังไม่ระบุสำนักงาน)
+func officeLabel(office string) string {
+	if office == "" {
+		return "(ไม่ระบุ)"
+	}
+	return office
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// -------------------------------------------------------------------
+// Seal ranges: รับรายการซีลเป็นช่วงเลข เช่น "F0001001-F0001050, F0001060"
+// และย่อรายการซีลในผลลัพธ์กลับเป็นช่วง เพื่อให้ใบจ่ายหลายร้อยเส้นยังอ่านได้
+// ช่วงเลขใช้รูปแบบเลขซีลที่ลงทะเบียนไว้ (ตัวคั่น "-" ในเลขซีลเองจึงไม่สับสนกับช่วง)
+// -------------------------------------------------------------------
+
+// MaxSealRangeSize จำนวนซีลสูงสุดที่ขยายจากช่วงเลขได้ในคำขอเดียว
+const MaxSealRangeSize = 10000
+
+// SealNumberList รายการเลขซีลจาก JSON รับได้ทั้ง string "A-B, C" และ array ["A-B", "C"]
+// แต่ละรายการยังเป็นช่วงเลขอยู่ ต้องขยายด้วย ExpandSealNumbers ก่อนใช้งาน
+type SealNumberList []string
+
+func (l *SealNumberList) UnmarshalJSON(data []byte) error {
+	var expression string
+	if err := json.Unmarshal(data, &expression); err == nil {
+		*l = SealNumberList{expression}
+		return nil
+	}
+	var items []string
+	if err := json.Unmarshal(data, &items); err != nil {
+		return errors.New("seal_numbers ต้องเป็นข้อความช่วงเลขหรือรายการเลขซีล")
+	}
+	*l = items
+	return nil
+}
+
+// ExpandRanges ขยายช่วงเลขเป็นรายการเลขซีล (ตัดเลขซ้ำ คงลำดับตามที่ระบุ)
+// error ทุกกรณีห่อ ErrInvalidSealNumber
+func (r *SealNumberRegistry) ExpandRanges(expressions ...string) ([]string, error) {
+	var sealNumbers []string
+	seen := make(map[string]bool)
+	for _, expression := range expressions {
+		for _, token := range strings.FieldsFunc(expression, func(c rune) bool {
+			return c == ',' || c == ';' || c == ' ' || c == '\n' || c == '\r' || c == '\t'
+		}) {
+			expanded, err := r.expandToken(token)
+			if err != nil {
+				return nil, err
+			}
+			for _, sn := range expanded {
+				if seen[sn] {
+					continue
+				}
+				seen[sn] = true
+				sealNumbers = append(sealNumbers, sn)
+				if len(sealNumbers) > MaxSealRangeSize {
+					return nil, fmt.Errorf("%w: ระบุซีลได้ไม่เกิน %d เส้นต่อครั้ง", ErrInvalidSealNumber, MaxSealRangeSize)
+				}
+			}
+		}
+	}
+	return sealNumbers, nil
+}
+
+// expandToken เลขซีลเส้นเดียว หรือช่วง "แรก-สุดท้าย" ที่ทั้งสองฝั่งอยู่ในรูปแบบเดียวกัน
+func (r *SealNumberRegistry) expandToken(token string) ([]string, error) {
+	first, last, err := r.parseRangeBounds(token)
+	if err != nil {
+		return nil, err
+	}
+	if first == last {
+		return []string{token}, nil
+	}
+	count := last.Serial - first.Serial + 1
+	if count > MaxSealRangeSize {
+		return nil, fmt.Errorf("%w: ช่วงเลขซีล %s เกิน %d เส้น", ErrInvalidSealNumber, token, MaxSealRangeSize)
+	}
+	sealNumbers := make([]string, 0, count)
+	for serial := first.Serial; serial <= last.Serial; serial++ {
+		sealNumber, err := first.WithSerial(serial)
+		if err != nil {
+			return nil, err
+		}
+		sealNumbers = append(sealNumbers, sealNumber)
+	}
+	return sealNumbers, nil
+}
+
+// parseRangeBounds แยกช่วง "แรก-สุดท้าย" (หรือเลขเดียว) เป็นเลขต้น/ท้ายที่อยู่ในชุดเดียวกัน
+func (r *SealNumberRegistry) parseRangeBounds(token string) (*ParsedSealNumber, *ParsedSealNumber, error) {
+	if token == "" {
+		return nil, nil, fmt.Errorf("%w: กรุณาระบุช่วงเลขซีล", ErrInvalidSealNumber)
+	}
+	single, singleErr := r.Parse(token)
+	if singleErr == nil {
+		return single, single, nil
+	}
+	for i := 0; i < len(token); i++ {
+		if token[i] != '-' {
+			continue
+		}
+		first, err := r.Parse(token[:i])
+		if err != nil {
+			continue
+		}
+		last, err := r.Parse(token[i+1:])
+		if err != nil {
+			continue
+		}
+		if !sameSealSeries(first, last) {
+			return nil, nil, fmt.Errorf("%w: ช่วงเลขซีล %s ต้องอยู่ในชุดเดียวกัน (prefix/รูปแบบเดียวกัน)", ErrInvalidSealNumber, token)
+		}
+		if last.Serial < first.Serial {
+			return nil, nil, fmt.Errorf("%w: ช่วงเลขซีล %s เลขเริ่มต้องไม่มากกว่าเลขสุดท้าย", ErrInvalidSealNumber, token)
+		}
+		return first, last, nil
+	}
+	return nil, nil, singleErr
+}
+
+// sameSealSeries เลขซีลสองเส้นอยู่ในชุดเดียวกัน (ต่างกันเฉพาะเลขลำดับ)
+// จำนวนหลักต้องเท่ากันเฉพาะรูปแบบที่กำหนด Width ไว้ เลขรูปแบบเดิมไม่เติมศูนย์จึงข้ามหลักได้ (F999-F1000)
+func sameSealSeries(a, b *ParsedSealNumber) bool {
+	sameFormat := a.Format == b.Format || (a.Format != nil && b.Format != nil && a.Format.ID == b.Format.ID)
+	return sameFormat && a.Head == b.Head && a.Tail == b.Tail && a.fixedWidth() == b.fixedWidth()
+}
+
+// CompressRanges ย่อรายการเลขซีลเป็นช่วงต่อเนื่อง เรียงตามชุดและเลขลำดับ
+// เลขที่แยกรูปแบบไม่ได้จะคงไว้ตามเดิมท้ายรายการ
+func (r *SealNumberRegistry) CompressRanges(sealNumbers []string) []string {
+	type seriesKey struct {
+		format string
+		head   string
+		tail   string
+		width  int
+	}
+	type entry struct {
+		key    seriesKey
+		parsed *ParsedSealNumber
+		number string
+	}
+	var entries []entry
+	var unparsed []string
+	seen := make(map[string]bool)
+	for _, sn := range sealNumbers {
+		if seen[sn] {
+			continue
+		}
+		seen[sn] = true
+		parsed, err := r.Parse(sn)
+		if err != nil {
+			unparsed = append(unparsed, sn)
+			continue
+		}
+		key := seriesKey{head: parsed.Head, tail: parsed.Tail, width: parsed.fixedWidth()}
+		if parsed.Format != nil {
+			key.format = parsed.Format.Name
+		}
+		entries = append(entries, entry{key: key, parsed: parsed, number: sn})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.key != b.key {
+			if a.key.head != b.key.head {
+				return a.key.head < b.key.head
+			}
+			if a.key.tail != b.key.tail {
+				return a.key.tail < b.key.tail
+			}
+			if a.key.width != b.key.width {
+				return a.key.width < b.key.width
+			}
+			return a.key.format < b.key.format
+		}
+		return a.parsed.Serial < b.parsed.Serial
+	})
+
+	ranges := []string{}
+	for i := 0; i < len(entries); {
+		j := i
+		// ✅ ต่อช่วงเฉพาะเลขที่ขยายกลับจากเลขต้นช่วงได้ตรงตัว (เลขรูปแบบเดิมที่เติมศูนย์ไม่เท่ากันจะแยกช่วง)
+		for j+1 < len(entries) && entries[j+1].key == entries[i].key {
+			next, err := entries[i].parsed.WithSerial(entries[j].parsed.Serial + 1)
+			if err != nil || next != entries[j+1].number {
+				break
+			}
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, entries[i].number)
+		} else {
+			ranges = append(ranges, entries[i].number+"-"+entries[j].number)
+		}
+		i = j + 1
+	}
+	return append(ranges, unparsed...)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+)
+
+// -------------------------------------------------------------------
+// Range analysis: ก่อนสั่งซื้อ/สร้างเลขซีล ดูว่าในช่วงเลขมีซีลอยู่แล้วเท่าไร (แยกตามสถานะ)
+// เลขไหนยังว่าง ลำดับขาดตรงไหน และทับกับล็อตที่ลงทะเบียนไว้หรือไม่
+// -------------------------------------------------------------------
+
+// MaxSealRangeAnalysisSize ช่วงเลขที่วิเคราะห์ได้ต่อครั้ง (ไม่ขยายเป็นรายการ จึงกว้างกว่า MaxSealRangeSize)
+const MaxSealRangeAnalysisSize = 1000000
+
+// SealNumberSpan ช่วงเลขซีลต่อเนื่อง
+type SealNumberSpan struct {
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Count int64  `json:"count"`
+}
+
+// SealRangeStatusGroup ซีลที่มีอยู่แล้วในช่วง ของสถานะหนึ่ง
+type SealRangeStatusGroup struct {
+	Status model.SealStatus `json:"status"`
+	Label  string           `json:"label"`
+	Count  int64            `json:"count"`
+	Spans  []SealNumberSpan `json:"spans"`
+}
+
+// SealRangeLotOverlap ล็อตที่ลงทะเบียนไว้ซึ่งช่วงเลขทับกับช่วงที่วิเคราะห์
+type SealRangeLotOverlap struct {
+	Lot     model.SealLot  `json:"lot"`
+	Overlap SealNumberSpan `json:"overlap"`
+}
+
+// SealRangeAnalysis ผลวิเคราะห์ช่วงเลขซีล
+//   - existing: ซีลที่มีในระบบแล้ว แยกตามสถานะ (ชนกันถ้าจะสร้างช่วงนี้)
+//   - missing: เลขที่ยังไม่มีในระบบ
+//   - holes: ช่วงที่ขาดหายระหว่างซีลที่มีอยู่ (ลำดับไม่ต่อเนื่อง)
+//   - lots: ล็อตผู้ผลิตที่ช่วงเลขทับกับช่วงนี้
+//   - clear: ไม่มีซีลและไม่ทับล็อตใดเลย สร้างช่วงนี้ได้ทันที
+type SealRangeAnalysis struct {
+	First         string                 `json:"first"`
+	Last          string                 `json:"last"`
+	Total         int64                  `json:"total"`
+	ExistingCount int64                  `json:"existing_count"`
+	MissingCount  int64                  `json:"missing_count"`
+	Existing      []SealRangeStatusGroup `json:"existing"`
+	Missing       []SealNumberSpan       `json:"missing"`
+	Holes         []SealNumberSpan       `json:"holes"`
+	Lots          []SealRangeLotOverlap  `json:"lots"`
+	Clear         bool                   `json:"clear"`
+}
+
+// AnalyzeSealRange วิเคราะห์ช่วงเลข "แรก-สุดท้าย" (หรือเลขเดียว) ตามรูปแบบเลขซีลที่ลงทะเบียนไว้
+func (s *SealService) AnalyzeSealRange(expression string) (*SealRangeAnalysis, error) {
+	registry, err := s.formatService.Registry()
+	if err != nil {
+		return nil, err
+	}
+	first, last, err := registry.parseRangeBounds(strings.TrimSpace(expression))
+	if err != nil {
+		return nil, err
+	}
+	total := last.Serial - first.Serial + 1
+	if total > MaxSealRangeAnalysisSize {
+		return nil, fmt.Errorf("%w: วิเคราะห์ได้ไม่เกิน %d เลขต่อครั้ง", ErrInvalidSealNumber, MaxSealRangeAnalysisSize)
+	}
+	firstNumber, err := first.WithSerial(first.Serial)
+	if err != nil {
+		return nil, err
+	}
+	lastNumber, err := last.WithSerial(last.Serial)
+	if err != nil {
+		return nil, err
+	}
+
+	// เลขชุดเดียวกันความยาวเท่ากัน เทียบแบบข้อความได้ตรงกับลำดับเลข แล้วกรองชุดเลขอีกชั้น
+	candidates, err := s.repo.FindByNumberRange(firstNumber, lastNumber)
+	if err != nil {
+		return nil, err
+	}
+	type existingSeal struct {
+		serial int64
+		status model.SealStatus
+	}
+	var existing []existingSeal
+	for _, seal := range candidates {
+		parsed, err := registry.Parse(seal.SealNumber)
+		if err != nil || !sameSealSeries(first, parsed) || parsed.Serial < first.Serial || parsed.Serial > last.Serial {
+			continue
+		}
+		existing = append(existing, existingSeal{serial: parsed.Serial, status: seal.Status})
+	}
+	sort.Slice(existing, func(i, j int) bool { return existing[i].serial < existing[j].serial })
+
+	span := func(from, to int64) SealNumberSpan {
+		firstSN, _ := first.WithSerial(from)
+		lastSN, _ := first.WithSerial(to)
+		return SealNumberSpan{First: firstSN, Last: lastSN, Count: to - from + 1}
+	}
+
+	analysis := &SealRangeAnalysis{
+		First:         firstNumber,
+		Last:          lastNumber,
+		Total:         total,
+		ExistingCount: int64(len(existing)),
+		MissingCount:  total - int64(len(existing)),
+		Existing:      []SealRangeStatusGroup{},
+		Missing:       []SealNumberSpan{},
+		Holes:         []SealNumberSpan{},
+		Lots:          []SealRangeLotOverlap{},
+	}
+
+	// ซีลที่มีอยู่ แยกตามสถานะเป็นช่วงต่อเนื่อง (เรียงตามลำดับสถานะใน model.AllSealStatuses)
+	serialsByStatus := make(map[model.SealStatus][]int64)
+	for _, seal := range existing {
+		serialsByStatus[seal.status] = append(serialsByStatus[seal.status], seal.serial)
+	}
+	for _, status := range model.AllSealStatuses() {
+		serials := serialsByStatus[status]
+		if len(serials) == 0 {
+			continue
+		}
+		group := SealRangeStatusGroup{Status: status, Label: status.Label(), Count: int64(len(serials))}
+		for i := 0; i < len(serials); {
+			j := i
+			for j+1 < len(serials) && serials[j+1] == serials[j]+1 {
+				j++
+			}
+			group.Spans = append(group.Spans, span(serials[i], serials[j]))
+			i = j + 1
+		}
+		analysis.Existing = append(analysis.Existing, group)
+	}
+
+	// เลขที่ยังว่าง และช่องว่างที่อยู่ระหว่างซีลที่มีอยู่
+	next := first.Serial
+	for i, seal := range existing {
+		if seal.serial > next {
+			gap := span(next, seal.serial-1)
+			analysis.Missing = append(analysis.Missing, gap)
+			if i > 0 {
+				analysis.Holes = append(analysis.Holes, gap)
+			}
+		}
+		next = seal.serial + 1
+	}
+	if next <= last.Serial {
+		analysis.Missing = append(analysis.Missing, span(next, last.Serial))
+	}
+
+	// ล็อตที่ทับช่วงนี้ (เทียบเฉพาะล็อตที่เลขอยู่ในชุดเดียวกัน)
+	var lots []model.SealLot
+	if err := s.db.Order("id").Find(&lots).Error; err != nil {
+		return nil, err
+	}
+	for _, lot := range lots {
+		lotFirst, err := registry.Parse(lot.FirstSealNumber)
+		if err != nil || !sameSealSeries(first, lotFirst) {
+			continue
+		}
+		lotLast, err := registry.Parse(lot.LastSealNumber)
+		if err != nil || !sameSealSeries(first, lotLast) {
+			continue
+		}
+		from, to := max(first.Serial, lotFirst.Serial), min(last.Serial, lotLast.Serial)
+		if from > to {
+			continue
+		}
+		analysis.Lots = append(analysis.Lots, SealRangeLotOverlap{Lot: lot, Overlap: span(from, to)})
+	}
+
+	analysis.Clear = analysis.ExistingCount == 0 && len(analysis.Lots) == 0
+	return analysis, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// ทดสอบการขยาย/ย่อช่วงเลขซีล (ไม่ต้องใช้ฐานข้อมูล)
+
+func TestExpandRanges(t *testing.T) {
+	legacy := newTestSealNumberRegistry(t)
+	registered := newTestSealNumberRegistry(t, testYearFormat, testFixedMod11Format)
+	tests := []struct {
+		name        string
+		registry    *SealNumberRegistry
+		expressions []string
+		want        []string
+		wantErr     bool
+	}{
+		{name: "single", registry: legacy, expressions: []string{"F0001001"}, want: []string{"F0001001"}},
+		{name: "range keeps padding", registry: legacy, expressions: []string{"F0001001-F0001003"},
+			want: []string{"F0001001", "F0001002", "F0001003"}},
+		{name: "digit boundary", registry: legacy, expressions: []string{"F998-F1001"},
+			want: []string{"F998", "F999", "F1000", "F1001"}},
+		{name: "list separators and duplicates", registry: legacy, expressions: []string{"F1-F2, F2;F5\nF1", "F7"},
+			want: []string{"F1", "F2", "F5", "F7"}},
+		{name: "registered format with separator inside the number", registry: registered,
+			expressions: []string{"PEA-26-0001235-PEA-26-0001243"},
+			want:        []string{"PEA-26-0001235", "PEA-26-0001243"}},
+		{name: "registered format recomputes check digits", registry: registered, expressions: []string{"K000019-K000035"},
+			want: []string{"K000019", "K000027", "K000035"}},
+		{name: "reversed range", registry: legacy, expressions: []string{"F0010-F0001"}, wantErr: true},
+		{name: "mixed prefixes", registry: legacy, expressions: []string{"F0001-G0005"}, wantErr: true},
+		{name: "mixed formats", registry: registered, expressions: []string{"K000019-F0005"}, wantErr: true},
+		{name: "invalid number", registry: legacy, expressions: []string{"F1-F2-F3"}, wantErr: true},
+		{name: "at MaxSealRangeSize", registry: legacy, expressions: []string{fmt.Sprintf("F1-F%d", MaxSealRangeSize)}},
+		{name: "over MaxSealRangeSize", registry: legacy, expressions: []string{fmt.Sprintf("F1-F%d", MaxSealRangeSize+1)}, wantErr: true},
+		{name: "total over MaxSealRangeSize", registry: legacy,
+			expressions: []string{fmt.Sprintf("F1-F%d", MaxSealRangeSize), "G1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.registry.ExpandRanges(tt.expressions...)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSealNumber) {
+					t.Fatalf("ExpandRanges(%q) error = %v, want ErrInvalidSealNumber", tt.expressions, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExpandRanges(%q): %v", tt.expressions, err)
+			}
+			if tt.want == nil {
+				if len(got) != MaxSealRangeSize {
+					t.Errorf("ExpandRanges(%q) = %d seals, want %d", tt.expressions, len(got), MaxSealRangeSize)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandRanges(%q) = %v, want %v", tt.expressions, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressRanges(t *testing.T) {
+	legacy := newTestSealNumberRegistry(t)
+	tests := []struct {
+		name        string
+		sealNumbers []string
+		want        []string
+	}{
+		{name: "empty", sealNumbers: nil, want: []string{}},
+		{name: "sorted into runs", sealNumbers: []string{"F0003", "F0001", "F0002", "F0005"},
+			want: []string{"F0001-F0003", "F0005"}},
+		{name: "digit boundary", sealNumbers: []string{"F999", "F1000"}, want: []string{"F999-F1000"}},
+		{name: "unequal padding splits runs", sealNumbers: []string{"F09", "F010"}, want: []string{"F09", "F010"}},
+		{name: "series grouped by prefix", sealNumbers: []string{"G1", "F2", "G2", "F1"}, want: []string{"F1-F2", "G1-G2"}},
+		{name: "unparsed kept at the end", sealNumbers: []string{"x-1", "F1"}, want: []string{"F1", "x-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legacy.CompressRanges(tt.sealNumbers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompressRanges(%v) = %v, want %v", tt.sealNumbers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressExpandRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry *SealNumberRegistry
+		ranges   []string
+	}{
+		{"legacy runs", newTestSealNumberRegistry(t), []string{"F0001001-F0001050", "F0001060", "G1-G3"}},
+		{"legacy digit boundary", newTestSealNumberRegistry(t), []string{"F990-F1010"}},
+		{"registered format", newTestSealNumberRegistry(t, testYearFormat, testFixedMod11Format),
+			[]string{"K000019-K00006X", "PEA-26-0001235-PEA-26-0001243"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expanded, err := tt.registry.ExpandRanges(tt.ranges...)
+			if err != nil {
+				t.Fatalf("ExpandRanges(%q): %v", tt.ranges, err)
+			}
+			compressed := tt.registry.CompressRanges(expanded)
+			if !reflect.DeepEqual(compressed, tt.ranges) {
+				t.Errorf("CompressRanges(ExpandRanges(%q)) = %v", tt.ranges, compressed)
+			}
+			again, err := tt.registry.ExpandRanges(compressed...)
+			if err != nil || !reflect.DeepEqual(again, expanded) {
+				t.Errorf("ExpandRanges(CompressRanges(x)) = %v, %v; want %v", again, err, expanded)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// -------------------------------------------------------------------
+// Seal replacement: ตัดซีลเดิมออกจากมิเตอร์และติดตั้งซีลใหม่ในธุรกรรมเดียว
+// -------------------------------------------------------------------
+
+// SealReplacementRequest ข้อมูลการเปลี่ยนซีล
+// NewMeterSerial ใช้กรณีเปลี่ยนมิเตอร์ทั้งลูก (ว่าง = ติดซีลใหม่บนมิเตอร์เดิม)
+type SealReplacementRequest struct {
+	MeterSerial    string
+	OldSealNumber  string
+	NewSealNumber  string
+	Reason         string
+	NewMeterSerial string
+}
+
+// SealReplacementResult แถวประวัติบนมิเตอร์ของซีลเดิม (ถูกถอด) และซีลใหม่
+type SealReplacementResult struct {
+	Removed   *model.MeterSeal `json:"removed"`
+	Installed *model.MeterSeal `json:"installed"`
+}
+
+// ReplaceSeal ถอดซีลเดิม (บันทึกเหตุผล ผู้ถอด เวลา) และติดตั้งซีลใหม่ พร้อมผูกลำดับซีลบนมิเตอร์
+func (s *SealService) ReplaceSeal(req SealReplacementRequest, actor Actor) (*SealReplacementResult, error) {
+	req.MeterSerial = strings.TrimSpace(req.MeterSerial)
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.MeterSerial == "" || req.OldSealNumber == "" || req.NewSealNumber == "" {
+		return nil, errors.New("meter_serial, old_seal_number และ new_seal_number จำเป็นต้องระบุ")
+	}
+	if req.Reason == "" {
+		return nil, errors.New("กรุณาระบุเหตุผลการถอดซีล")
+	}
+	if req.OldSealNumber == req.NewSealNumber {
+		return nil, errors.New("ซีลใหม่ต้องไม่ใช่ซีลเดิม")
+	}
+	newMeterSerial := strings.TrimSpace(req.NewMeterSerial)
+	if newMeterSerial == "" {
+		newMeterSerial = req.MeterSerial
+	}
+
+	result := &SealReplacementResult{}
+	now := time.Now()
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 0) ล็อกซีลทั้งสองเส้นแล้วตรวจสถานะจากค่าล่าสุด
+		oldSeal, err := lockSealByNumber(tx, req.OldSealNumber)
+		if err != nil {
+			return fmt.Errorf("ไม่พบซีลเดิม %s ในระบบ", req.OldSealNumber)
+		}
+		newSeal, err := lockSealByNumber(tx, req.NewSealNumber)
+		if err != nil {
+			return fmt.Errorf("ไม่พบซีลใหม่ %s ในระบบ", req.NewSealNumber)
+		}
+		removedStatus, err := checkSealTransition(oldSeal, SealActionRemove, actor)
+		if err != nil {
+			return err
+		}
+		installedStatus, err := checkSealTransition(newSeal, SealActionInstall, actor)
+		if err != nil {
+			return err
+		}
+
+		// 1) หาแถวประวัติของซีลเดิมบนมิเตอร์ที่ยังไม่ถูกถอด
+		var oldLink model.MeterSeal
+		if err := tx.Joins("JOIN meters ON meters.id = meter_seals.meter_id").
+			Where("meters.serial_number = ? AND meter_seals.seal_id = ? AND meter_seals.removed_at IS NULL", req.MeterSerial, oldSeal.ID).
+			First(&oldLink).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("ซีล %s ไม่ได้ติดตั้งอยู่บนมิเตอร์ %s", oldSeal.SealNumber, req.MeterSerial)
+			}
+			return err
+		}
+
+		// 2) ถอดซีลเดิม
+		oldBefore := *oldSeal
+		oldSeal.Status = removedStatus
+		oldSeal.ReturnedBy = &actor.ID
+		oldSeal.ReturnedAt = &now
+		oldSeal.ReturnRemarks = req.Reason
+		if err := saveSeal(tx, oldSeal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &oldBefore, oldSeal, SealActionRemove, actor, req.Reason); err != nil {
+			return err
+		}
+
+		// 3) ติดตั้งซีลใหม่และผูกเข้ากับมิเตอร์
+		newBefore := *newSeal
+		newSeal.Status = installedStatus
+		newSeal.UsedBy = &actor.ID
+		newSeal.UsedAt = &now
+		newSeal.InstalledSerial = newMeterSerial
+		if err := saveSeal(tx, newSeal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &newBefore, newSeal, SealActionInstall, actor, "แทนซีล "+oldSeal.SealNumber); err != nil {
+			return err
+		}
+		newLink, err := linkSealToMeter(tx, newSeal, newMeterSerial, actor, now)
+		if err != nil {
+			return err
+		}
+		newLink.ReplacesSealID = &oldSeal.ID
+		newLink.ReplacesSealNumber = oldSeal.SealNumber
+		if err := tx.Save(newLink).Error; err != nil {
+			return err
+		}
+
+		// 4) ปิดแถวของซีลเดิม พร้อมชี้ไปยังซีลที่มาแทน
+		oldLink.RemovedAt = &now
+		oldLink.RemovedBy = &actor.ID
+		oldLink.RemoverType = string(actor.Type)
+		oldLink.RemovalReason = req.Reason
+		oldLink.ReplacedBySealID = &newSeal.ID
+		oldLink.ReplacedBySealNumber = newSeal.SealNumber
+		if err := tx.Save(&oldLink).Error; err != nil {
+			return err
+		}
+
+		logEntries := []model.Log{
+			{
+				UserID: actor.ID,
+				Action: fmt.Sprintf("ถอดซีล %s ออกจากมิเตอร์ %s (เหตุผล: %s) แทนด้วยซีล %s", oldSeal.SealNumber, req.MeterSerial, req.Reason, newSeal.SealNumber),
+			},
+			{
+				UserID: actor.ID,
+				Action: fmt.Sprintf("ติดตั้งซิล %s (Serial: %s) แทนซีล %s", newSeal.SealNumber, newMeterSerial, oldSeal.SealNumber),
+			},
+		}
+		if err := tx.Create(&logEntries).Error; err != nil {
+			return err
+		}
+
+		result.Removed = &oldLink
+		result.Installed = newLink
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// -------------------------------------------------------------------
+// Reservations: หัวหน้างานจองซีลพร้อมใช้งานไว้ให้ช่างสำหรับงานล่วงหน้า
+// ซีลที่จองจ่าย/มอบหมายได้เฉพาะช่างที่จองไว้ให้ และถูกปล่อยอัตโนมัติเมื่อหมดเวลา
+// -------------------------------------------------------------------
+
+// MaxSealReservationPeriod ระยะเวลาจองนานที่สุด
+const MaxSealReservationPeriod = 7 * 24 * time.Hour
+
+// ReserveSeals จองซีลให้ช่าง techID จนถึง until (ทุกเส้นต้องผ่าน มิฉะนั้นไม่จองเลย)
+func (s *SealService) ReserveSeals(sealNumbers []string, techID uint, until time.Time, actor Actor, remark string) ([]model.Seal, error) {
+	if len(sealNumbers) == 0 {
+		return nil, errors.New("กรุณาระบุซีลที่ต้องการจอง")
+	}
+	now := time.Now()
+	if !until.After(now) {
+		return nil, errors.New("เวลาหมดอายุการจองต้องเป็นเวลาในอนาคต")
+	}
+	if until.Sub(now) > MaxSealReservationPeriod {
+		return nil, fmt.Errorf("จองได้นานไม่เกิน %d วัน", int(MaxSealReservationPeriod.Hours()/24))
+	}
+	if _, err := s.technicianRepo.FindByID(techID); err != nil {
+		return nil, fmt.Errorf("ไม่พบช่าง ID %d", techID)
+	}
+
+	var reserved []model.Seal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, sn := range sealNumbers {
+			var seal model.Seal
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("seal_number = ?", sn).First(&seal).Error; err != nil {
+				return fmt.Errorf("ซีล %s ไม่พบในระบบ", sn)
+			}
+			to, err := checkSealTransition(&seal, SealActionReserve, actor)
+			if err != nil {
+				return err
+			}
+			before := seal
+			seal.Status = to
+			seal.ReservedBy = &actor.ID
+			seal.ReservedFor = &techID
+			seal.ReservedUntil = &until
+			seal.ReserveRemark = strings.TrimSpace(remark)
+			if err := saveSeal(tx, &seal); err != nil {
+				return err
+			}
+			if err := recordSealHistory(tx, &before, &seal, SealActionReserve, actor, seal.ReserveRemark); err != nil {
+				return err
+			}
+			logEntry := model.Log{
+				UserID: actor.ID,
+				Action: fmt.Sprintf("จองซีล %s ให้ช่าง ID %d ถึง %s - หมายเหตุ: %s",
+					seal.SealNumber, techID, until.Format("2006-01-02 15:04"), seal.ReserveRemark),
+			}
+			if err := tx.Create(&logEntry).Error; err != nil {
+				return err
+			}
+			reserved = append(reserved, seal)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.checkSealStock(reserved...)
+	return reserved, nil
+}
+
+// ReleaseSealReservations ผู้จองหรือ admin ปล่อยการจอง ซีลกลับเป็น 'พร้อมใช้งาน'
+func (s *SealService) ReleaseSealReservations(sealNumbers []string, actor Actor, remark string) ([]model.Seal, error) {
+	if len(sealNumbers) == 0 {
+		return nil, errors.New("กรุณาระบุซีลที่ต้องการปล่อยการจอง")
+	}
+	var released []model.Seal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, sn := range sealNumbers {
+			var seal model.Seal
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("seal_number = ?", sn).First(&seal).Error; err != nil {
+				return fmt.Errorf("ซีล %s ไม่พบในระบบ", sn)
+			}
+			if err := releaseSealReservation(tx, &seal, actor,
+				fmt.Sprintf("ปล่อยการจองซีล %s (เดิมจองให้ช่าง ID %s) - หมายเหตุ: %s", seal.SealNumber, uintLabel(seal.ReservedFor), remark)); err != nil {
+				return err
+			}
+			released = append(released, seal)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.checkSealStock(released...)
+	return released, nil
+}
+
+// ReleaseExpiredReservations ปล่อยการจองที่หมดเวลาแล้วทั้งหมด คืนจำนวนซีลที่ปล่อย
+func (s *SealService) ReleaseExpiredReservations() (int, error) {
+	var expired []model.Seal
+	if err := s.db.Where("status = ? AND reserved_until < ?", model.SealStatusReserved, time.Now()).Find(&expired).Error; err != nil {
+		return 0, err
+	}
+	released := 0
+	for i := range expired {
+		seal := &expired[i]
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			return releaseSealReservation(tx, seal, SystemActor(),
+				fmt.Sprintf("ปล่อยการจองซีล %s อัตโนมัติ (จองให้ช่าง ID %s หมดเวลา %s)",
+					seal.SealNumber, uintLabel(seal.ReservedFor), seal.ReservedUntil.Format("2006-01-02 15:04")))
+		})
+		if err != nil {
+			log.Println("⚠️ ปล่อยการจองซีลไม่สำเร็จ:", seal.SealNumber, err)
+			continue
+		}
+		released++
+	}
+	s.checkSealStock(expired...)
+	return released, nil
+}
+
+// GetSealReservations ซีลที่จองอยู่ของสำนักงานในขอบเขต (techID 0 = ทุกช่าง)
+func (s *SealService) GetSealReservations(techID uint, scope OfficeScope) ([]model.Seal, error) {
+	var seals []model.Seal
+	query := s.db.Where("status = ?", model.SealStatusReserved).Order("reserved_until")
+	if techID != 0 {
+		query = query.Where("reserved_for = ?", techID)
+	}
+	if offices := scope.Codes(); offices != nil {
+		query = query.Where("office_code IN ?", offices)
+	}
+	err := query.Find(&seals).Error
+	return seals, err
+}
+
+// checkSealReservation ซีลที่จองไว้จ่าย/มอบหมายได้เฉพาะช่างที่จองไว้ให้
+// (การจองที่หมดเวลาแต่ตัวปล่อยยังไม่ทำงาน ถือว่าปล่อยแล้ว)
+func checkSealReservation(seal *model.Seal, recipientID uint) error {
+	if !sealReservationActive(seal) {
+		return nil
+	}
+	if seal.ReservedFor != nil && *seal.ReservedFor == recipientID {
+		return nil
+	}
+	return sealReservedError(seal)
+}
+
+// checkSealNotReserved ใช้กับ flow ที่ไม่ได้ระบุช่างผู้รับ (ID ผู้เรียกเป็นพนักงาน เทียบกับ ReservedFor ที่เป็น ID ช่างไม่ได้)
+// ซีลที่ยังจองอยู่ต้องจ่ายผ่าน flow ที่ระบุช่าง หรือปล่อยการจองก่อน
+func checkSealNotReserved(seal *model.Seal) error {
+	if !sealReservationActive(seal) {
+		return nil
+	}
+	return sealReservedError(seal)
+}
+
+// sealReservationActive ซีลอยู่ในสถานะ 'จองแล้ว' และการจองยังไม่หมดเวลา
+func sealReservationActive(seal *model.Seal) bool {
+	if seal.Status != model.SealStatusReserved {
+		return false
+	}
+	return seal.ReservedUntil == nil || !seal.ReservedUntil.Before(time.Now())
+}
+
+func sealReservedError(seal *model.Seal) error {
+	until := "-"
+	if seal.ReservedUntil != nil {
+		until = seal.ReservedUntil.Format("2006-01-02 15:04")
+	}
+	return fmt.Errorf("ซีล %s ถูกจองไว้ให้ช่าง ID %s จนถึง %s", seal.SealNumber, uintLabel(seal.ReservedFor), until)
+}
+
+// clearSealReservation ล้างข้อมูลการจองเมื่อซีลออกจากสถานะ 'จองแล้ว'
+func clearSealReservation(seal *model.Seal) {
+	seal.ReservedBy = nil
+	seal.ReservedFor = nil
+	seal.ReservedUntil = nil
+	seal.ReserveRemark = ""
+}
+
+func releaseSealReservation(tx *gorm.DB, seal *model.Seal, actor Actor, logAction string) error {
+	to, err := checkSealTransition(seal, SealActionRelease, actor)
+	if err != nil {
+		return err
+	}
+	before := *seal
+	seal.Status = to
+	clearSealReservation(seal)
+	if err := saveSeal(tx, seal); err != nil {
+		return err
+	}
+	if err := recordSealHistory(tx, &before, seal, SealActionRelease, actor, ""); err != nil {
+		return err
+	}
+	logEntry := model.Log{UserID: actor.ID, Action: logAction}
+	return tx.Create(&logEntry).Error
+}
+
+func uintLabel(id *uint) string {
+	if id == nil {
+		return "-"
+	}
+	return fmt.Sprint(*id)
+}
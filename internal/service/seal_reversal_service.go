@@ -0,0 +1,285 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// -------------------------------------------------------------------
+// Seal reversals: ย้อนกลับรายการล่าสุดของซีลที่บันทึกผิด (จ่ายผิดช่วง / ติดตั้งผิดเส้น)
+// admin ขอพร้อมเหตุผล -> admin อีกคนอนุมัติ -> คืนสภาพซีลจากค่าเดิม (previous) ในสมุดบัญชี
+// และบันทึกรายการชดเชย (ไม่ลบประวัติเดิม)
+// -------------------------------------------------------------------
+
+// reversibleSealActions รายการที่ย้อนกลับได้ การส่งระหว่างสำนักงาน/โอนระหว่างช่าง/ถอดซีล
+// มีเอกสารประกอบของตัวเอง ต้องใช้ขั้นตอนของรายการนั้น (เรียกคืน/โอนกลับ/ติดตั้งใหม่) แทน
+var reversibleSealActions = map[SealAction]bool{
+	SealActionIssue:       true,
+	SealActionAssign:      true,
+	SealActionInstall:     true,
+	SealActionReturn:      true,
+	SealActionCancel:      true,
+	SealActionReserve:     true,
+	SealActionRelease:     true,
+	SealActionRestock:     true,
+	SealActionMarkLost:    true,
+	SealActionMarkDamaged: true,
+	SealActionVoid:        true,
+}
+
+// GetSealHistory ประวัติการเปลี่ยนแปลงของซีล (ล่าสุดก่อน) อ่านจากสมุดบัญชี transactions
+func (s *SealService) GetSealHistory(sealNumber string) ([]SealHistoryEntry, error) {
+	seal, err := s.repo.FindByNumber(sealNumber)
+	if err != nil {
+		return nil, errors.New("ไม่พบซีลในระบบ")
+	}
+	return sealHistoryOf(s.db, seal)
+}
+
+func sealHistoryOf(db *gorm.DB, seal *model.Seal) ([]SealHistoryEntry, error) {
+	var transactions []model.Transaction
+	if err := db.Where("seal_id = ?", seal.ID).Order("created_at DESC, id DESC").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return sealHistoryEntries(transactions)
+}
+
+// RequestSealReversal admin ขอย้อนกลับรายการล่าสุดของซีล (ต้องระบุเหตุผล)
+func (s *SealService) RequestSealReversal(sealNumber string, reason string, actor Actor) (*model.SealReversal, error) {
+	if !actor.IsAdmin() {
+		return nil, errors.New("เฉพาะ admin เท่านั้นที่ขอย้อนกลับรายการได้")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("กรุณาระบุเหตุผลการย้อนกลับ")
+	}
+	seal, err := s.repo.FindByNumber(sealNumber)
+	if err != nil {
+		return nil, errors.New("ไม่พบซีลในระบบ")
+	}
+	last, err := lastSealTransition(s.db, seal)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending int64
+	if err := s.db.Model(&model.SealReversal{}).
+		Where("seal_id = ? AND status = ?", seal.ID, model.SealReversalPending).
+		Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, fmt.Errorf("ซีล %s มีคำขอย้อนกลับที่รออนุมัติอยู่แล้ว", seal.SealNumber)
+	}
+
+	reversal := &model.SealReversal{
+		SealID:        seal.ID,
+		SealNumber:    seal.SealNumber,
+		TransactionID: last.ID,
+		Action:        last.Action,
+		FromStatus:    last.FromStatus,
+		ToStatus:      last.ToStatus,
+		Reason:        reason,
+		Status:        model.SealReversalPending,
+		RequestedBy:   actor.ID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(reversal).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ขอย้อนกลับรายการ '%s' ของซีล %s ('%s' -> '%s') คำขอ #%d - เหตุผล: %s",
+				SealAction(last.Action).Label(), seal.SealNumber, last.FromStatus.Label(), last.ToStatus.Label(), reversal.ID, reason),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reversal, nil
+}
+
+// ApproveSealReversal admin คนที่สองอนุมัติ -> คืนสภาพซีลก่อนรายการนั้นพร้อมบันทึกรายการชดเชย
+// ถ้าซีลมีรายการใหม่เกิดขึ้นหลังจากขอ คำขอนี้ใช้ไม่ได้แล้ว
+func (s *SealService) ApproveSealReversal(reversalID uint, actor Actor, remark string) (*model.SealReversal, error) {
+	reversal, err := s.loadPendingSealReversal(reversalID, actor)
+	if err != nil {
+		return nil, err
+	}
+	if reversal.RequestedBy == actor.ID {
+		return nil, errors.New("ผู้ขอย้อนกลับไม่สามารถอนุมัติคำขอของตัวเองได้ ต้องเป็น admin คนอื่น")
+	}
+
+	now := time.Now()
+	var restored model.Seal
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var seal model.Seal
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seal, reversal.SealID).Error; err != nil {
+			return fmt.Errorf("ไม่พบซีล %s", reversal.SealNumber)
+		}
+		last, err := lastSealTransition(tx, &seal)
+		if err != nil {
+			return err
+		}
+		if last.ID != reversal.TransactionID || seal.Status != last.ToStatus {
+			return fmt.Errorf("ซีล %s มีการเปลี่ยนแปลงหลังจากขอย้อนกลับ กรุณาขอใหม่", seal.SealNumber)
+		}
+
+		// คืนค่าเดิมของฟิลด์ที่รายการนั้นเปลี่ยน ฟิลด์อื่นและเวอร์ชันคงเดิม (เวอร์ชันต้องเดินหน้าต่อ)
+		previous := make(map[string]json.RawMessage, len(last.previous)+1)
+		for field, value := range last.previous {
+			previous[field] = value
+		}
+		status, err := json.Marshal(last.FromStatus)
+		if err != nil {
+			return err
+		}
+		previous["status"] = status
+		before := seal
+		restored = seal
+		if err := applySealLedgerState(&restored, previous); err != nil {
+			return fmt.Errorf("ข้อมูลก่อนเปลี่ยนของซีล %s เสียหาย: %v", seal.SealNumber, err)
+		}
+		if err := saveSeal(tx, &restored); err != nil {
+			return err
+		}
+
+		// ย้อนการติดตั้ง: ปิดแถวประวัติบนมิเตอร์ที่เปิดไว้ตอนติดตั้ง
+		if SealAction(last.Action) == SealActionInstall {
+			if err := tx.Model(&model.MeterSeal{}).
+				Where("seal_id = ? AND removed_at IS NULL", seal.ID).
+				Updates(map[string]interface{}{
+					"removed_at":     now,
+					"removed_by":     actor.ID,
+					"remover_type":   string(actor.Type),
+					"removal_reason": "ย้อนกลับการติดตั้ง: " + reversal.Reason,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		entry, err := newSealTransaction(&before, &restored, SealActionReverse, actor, sealLedgerMetadata{
+			ReversesID: &last.ID,
+			Remark:     fmt.Sprintf("คำขอ #%d: %s", reversal.ID, reversal.Reason),
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+
+		reversal.Status = model.SealReversalApproved
+		reversal.ReviewedBy = &actor.ID
+		reversal.ReviewedAt = &now
+		reversal.ReviewRemark = remark
+		reversal.ReversalTransactionID = &entry.ID
+		if err := tx.Save(reversal).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("อนุมัติย้อนกลับรายการ '%s' ของซีล %s ('%s' -> '%s') คำขอ #%d ขอโดย ID %d",
+				SealAction(last.Action).Label(), seal.SealNumber, before.Status.Label(), restored.Status.Label(),
+				reversal.ID, reversal.RequestedBy),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.checkSealStock(restored)
+	return reversal, nil
+}
+
+// RejectSealReversal admin ไม่อนุมัติ ซีลคงเดิม
+func (s *SealService) RejectSealReversal(reversalID uint, actor Actor, remark string) (*model.SealReversal, error) {
+	reversal, err := s.loadPendingSealReversal(reversalID, actor)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	reversal.Status = model.SealReversalRejected
+	reversal.ReviewedBy = &actor.ID
+	reversal.ReviewedAt = &now
+	reversal.ReviewRemark = remark
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(reversal).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ไม่อนุมัติย้อนกลับรายการของซีล %s (คำขอ #%d) - หมายเหตุ: %s", reversal.SealNumber, reversal.ID, remark),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reversal, nil
+}
+
+// GetSealReversals คำขอย้อนกลับตามสถานะ (ว่าง = ทั้งหมด) ล่าสุดก่อน
+func (s *SealService) GetSealReversals(status model.SealReversalStatus) ([]model.SealReversal, error) {
+	var reversals []model.SealReversal
+	query := s.db.Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&reversals).Error
+	return reversals, err
+}
+
+func (s *SealService) loadPendingSealReversal(reversalID uint, actor Actor) (*model.SealReversal, error) {
+	if !actor.IsAdmin() {
+		return nil, errors.New("เฉพาะ admin เท่านั้นที่พิจารณาคำขอย้อนกลับได้")
+	}
+	var reversal model.SealReversal
+	if err := s.db.First(&reversal, reversalID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("ไม่พบคำขอย้อนกลับ")
+		}
+		return nil, err
+	}
+	if reversal.Status != model.SealReversalPending {
+		return nil, fmt.Errorf("คำขอย้อนกลับ #%d ถูกดำเนินการไปแล้ว (%s)", reversal.ID, reversal.Status)
+	}
+	return &reversal, nil
+}
+
+// lastSealTransition รายการล่าสุดของซีลที่ยังมีผลอยู่ (ข้ามคู่รายการที่ถูกย้อนกลับไปแล้ว)
+// จึงย้อนกลับต่อเนื่องได้ทีละรายการจากใหม่ไปเก่า จนถึงรายการสร้างซีล
+func lastSealTransition(db *gorm.DB, seal *model.Seal) (*SealHistoryEntry, error) {
+	entries, err := sealHistoryOf(db, seal)
+	if err != nil {
+		return nil, err
+	}
+	reversed := map[uint]bool{}
+	for i := range entries {
+		entry := &entries[i]
+		if entry.ReversesID != nil {
+			reversed[*entry.ReversesID] = true
+			continue
+		}
+		if reversed[entry.ID] {
+			continue
+		}
+		if SealAction(entry.Action) == SealActionCreate {
+			break
+		}
+		if !reversibleSealActions[SealAction(entry.Action)] {
+			return nil, fmt.Errorf("รายการล่าสุดของซีล %s คือ '%s' ซึ่งย้อนกลับด้วยวิธีนี้ไม่ได้",
+				seal.SealNumber, SealAction(entry.Action).Label())
+		}
+		return entry, nil
+	}
+	return nil, fmt.Errorf("ไม่พบประวัติการเปลี่ยนแปลงของซีล %s ที่ย้อนกลับได้", seal.SealNumber)
+}
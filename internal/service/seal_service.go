@@ -4,8 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +21,30 @@ type SealService struct {
 
 	// เพิ่มฟิลด์ technicianRepo เพื่อเรียก FindByTechCode
 	technicianRepo *repository.TechnicianRepository
+
+	// รายงานซีลสูญหาย/ชำรุด
+	incidentRepo *repository.SealIncidentRepository
+
+	// การโอนซีลระหว่างช่าง
+	transferRepo *repository.SealTransferRepository
+
+	// คำขอคืนซีลเข้าคลังของช่าง
+	storeReturnRepo *repository.SealStoreReturnRepository
+
+	// ใบงานที่ผูกกับการจ่าย/ติดตั้งซีล
+	workOrderRepo *repository.WorkOrderRepository
+
+	// การส่งซีลระหว่างสำนักงาน
+	officeTransferRepo *repository.OfficeTransferRepository
+
+	// ขั้นต่ำซีลพร้อมใช้งานและการแจ้งเตือนซีลใกล้หมด
+	stockService *SealStockService
+
+	// รอบการตรวจนับซีลในคลัง
+	stocktakeRepo *repository.StocktakeRepository
+
+	// รูปแบบเลขซีลที่ลงทะเบียนไว้ (ตรวจ/สร้างเลขซีล)
+	formatService *SealNumberFormatService
 }
 
 // NewSealService รับ repository ต่าง ๆ จากภายนอก
@@ -32,6 +54,14 @@ func NewSealService(
 	logRepo *repository.LogRepository,
 	db *gorm.DB,
 	technicianRepo *repository.TechnicianRepository, // <<-- เพิ่มพารามิเตอร์นี้
+	incidentRepo *repository.SealIncidentRepository,
+	transferRepo *repository.SealTransferRepository,
+	storeReturnRepo *repository.SealStoreReturnRepository,
+	workOrderRepo *repository.WorkOrderRepository,
+	officeTransferRepo *repository.OfficeTransferRepository,
+	stockService *SealStockService,
+	stocktakeRepo *repository.StocktakeRepository,
+	formatService *SealNumberFormatService,
 ) *SealService {
 	return &SealService{
 		repo:            repo,
@@ -39,6 +69,15 @@ func NewSealService(
 		logRepo:         logRepo,
 		db:              db,
 		technicianRepo:  technicianRepo, // <<-- เซตเข้าฟิลด์
+		incidentRepo:    incidentRepo,
+		transferRepo:    transferRepo,
+		storeReturnRepo: storeReturnRepo,
+		workOrderRepo:   workOrderRepo,
+
+		officeTransferRepo: officeTransferRepo,
+		stockService:       stockService,
+		stocktakeRepo:      stocktakeRepo,
+		formatService:      formatService,
 	}
 }
 
@@ -57,17 +96,21 @@ func (s *SealService) GetLatestSealNumber() (string, error) {
 	return latestSeal.SealNumber, nil
 }
 
-func (s *SealService) GetSealsByStatus(status string) ([]model.Seal, error) {
+func (s *SealService) GetSealsByStatus(status model.SealStatus, scope OfficeScope) ([]model.Seal, error) {
 	log.Println("🎬 กำลังดึงซีลสถานะ:", status)
 	var seals []model.Seal
-	if err := s.db.Where("status = ?", status).Find(&seals).Error; err != nil {
+	query := s.db.Where("status = ?", status)
+	if offices := scope.Codes(); offices != nil {
+		query = query.Where("office_code IN ?", offices)
+	}
+	if err := query.Find(&seals).Error; err != nil {
 		return nil, err
 	}
 	log.Println("🔍 เจอซีลจำนวน:", len(seals))
 	return seals, nil
 }
 
-func (s *SealService) GetSealByIDAndStatus(sealID uint, status string) (*model.Seal, error) {
+func (s *SealService) GetSealByIDAndStatus(sealID uint, status model.SealStatus) (*model.Seal, error) {
 	log.Println("🔍 กำลังดึงซีลจาก ID:", sealID, " และสถานะ:", status)
 
 	var seal model.Seal
@@ -84,7 +127,11 @@ func (s *SealService) GetSealByNumber(sealNumber string) (*model.Seal, error) {
 	return s.repo.FindByNumber(sealNumber)
 }
 
-func (s *SealService) CreateSeal(seal *model.Seal, userID uint) error {
+// CreateSeal สร้างซีลหนึ่งเส้นเข้าคลังของสำนักงานผู้สร้าง (actor.Office)
+func (s *SealService) CreateSeal(seal *model.Seal, actor Actor) error {
+	if err := s.formatService.ValidateNewSealNumbers(seal.SealNumber); err != nil {
+		return err
+	}
 	exists, err := s.repo.CheckSealExists(seal.SealNumber)
 	if err != nil {
 		return err
@@ -94,28 +141,33 @@ func (s *SealService) CreateSeal(seal *model.Seal, userID uint) error {
 	}
 
 	now := time.Now()
-	seal.Status = "พร้อมใช้งาน"
+	seal.Status = model.SealStatusAvailable
+	seal.OfficeCode = actor.Office
 	seal.CreatedAt = now
 	seal.UpdatedAt = now
 
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := s.repo.Create(seal); err != nil {
+		if err := tx.Create(seal).Error; err != nil {
+			return err
+		}
+		if err := recordSealCreations(tx, []model.Seal{*seal}, actor, ""); err != nil {
 			return err
 		}
 		logEntry := model.Log{
-			UserID: userID,
+			UserID: actor.ID,
 			Action: fmt.Sprintf("สร้างซีล %s", seal.SealNumber),
 		}
-		return s.logRepo.Create(&logEntry)
+		return tx.Create(&logEntry).Error
 	})
 }
 
-func (s *SealService) GenerateAndCreateSeals(count int, userID uint) ([]model.Seal, error) {
+// GenerateAndCreateSeals สร้างซีลต่อจากเลขล่าสุดเข้าคลังของสำนักงานผู้สร้าง (actor.Office)
+func (s *SealService) GenerateAndCreateSeals(count int, actor Actor) ([]model.Seal, error) {
 	latestSealNumber, err := s.GetLatestSealNumber()
 	if err != nil {
 		return nil, err
 	}
-	sealNumbers, err := GenerateNextSealNumbers(latestSealNumber, count)
+	sealNumbers, err := s.nextSealNumbers(latestSealNumber, count)
 	if err != nil {
 		return nil, err
 	}
@@ -124,20 +176,24 @@ func (s *SealService) GenerateAndCreateSeals(count int, userID uint) ([]model.Se
 	for i, sn := range sealNumbers {
 		seals[i] = model.Seal{
 			SealNumber: sn,
-			Status:     "พร้อมใช้งาน",
+			Status:     model.SealStatusAvailable,
+			OfficeCode: actor.Office,
 			CreatedAt:  now,
 			UpdatedAt:  now,
 		}
 	}
 	err = s.db.Transaction(func(tx *gorm.DB) error {
-		if err := s.repo.CreateMultiple(seals); err != nil {
+		if err := s.repo.CreateMultiple(tx, seals); err != nil {
+			return err
+		}
+		if err := recordSealCreations(tx, seals, actor, ""); err != nil {
 			return err
 		}
 		logEntry := model.Log{
-			UserID: userID,
+			UserID: actor.ID,
 			Action: fmt.Sprintf("สร้างซิลใหม่ %d อัน", count),
 		}
-		return s.logRepo.Create(&logEntry)
+		return tx.Create(&logEntry).Error
 	})
 	if err != nil {
 		return nil, err
@@ -145,243 +201,619 @@ func (s *SealService) GenerateAndCreateSeals(count int, userID uint) ([]model.Se
 	return seals, nil
 }
 
-func (s *SealService) GenerateAndCreateSealsFromNumber(startingSealNumber string, count int, userID uint) ([]model.Seal, error) {
-	sealNumbers, err := GenerateNextSealNumbers(startingSealNumber, count)
+// GenerateAndCreateSealsFromNumber สร้างซีลเข้าคลังของสำนักงาน officeCode
+func (s *SealService) GenerateAndCreateSealsFromNumber(startingSealNumber string, count int, userID uint, officeCode string) ([]model.Seal, error) {
+	return s.GenerateSealsForLot(startingSealNumber, count, userID, officeCode, nil)
+}
+
+// GenerateSealsForLot สร้างซีลต่อเนื่องจากเลขเริ่ม ถ้ามี lot จะบันทึกล็อตผู้ผลิต (จำนวน + ช่วงเลข)
+// และผูกซีลทุกเส้นเข้ากับล็อตในธุรกรรมเดียวกัน
+func (s *SealService) GenerateSealsForLot(startingSealNumber string, count int, userID uint, officeCode string, lot *model.SealLot) ([]model.Seal, error) {
+	sealNumbers, err := s.nextSealNumbers(startingSealNumber, count)
 	if err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
 	var newSeals []model.Seal
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		newSeals, err = s.createSealBatch(tx, startingSealNumber, sealNumbers, userID, officeCode, lot)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.checkSealStock(newSeals...)
+	return newSeals, nil
+}
 
+// createSealBatch สร้างซีลหนึ่งชุด (+ ล็อตและ log) ภายใน tx ที่ผู้เรียกเปิดไว้
+func (s *SealService) createSealBatch(tx *gorm.DB, startingSealNumber string, sealNumbers []string, userID uint, officeCode string, lot *model.SealLot) ([]model.Seal, error) {
+	now := time.Now()
+	newSeals := make([]model.Seal, 0, len(sealNumbers))
 	for _, sn := range sealNumbers {
-		exists, err := s.repo.CheckSealExists(sn)
-		if err != nil {
-			return nil, err
-		}
-		if exists {
-			return nil, fmt.Errorf("Security seal ซ้ำกรุณากรอกเลขใหม่ด้วยค่ะ: %s", sn)
-		}
-
 		newSeals = append(newSeals, model.Seal{
 			SealNumber: sn,
-			Status:     "พร้อมใช้งาน",
+			Status:     model.SealStatusAvailable,
+			OfficeCode: officeCode,
 			CreatedAt:  now,
 			UpdatedAt:  now,
 		})
 	}
 
-	err = s.db.Transaction(func(tx *gorm.DB) error {
-		if err := s.repo.CreateMultiple(newSeals); err != nil {
-			return err
+	action := fmt.Sprintf("สร้างซีลใหม่ %d อัน จากเลขเริ่ม %s", len(newSeals), startingSealNumber)
+	if lot != nil {
+		lot.Quantity = len(newSeals)
+		lot.FirstSealNumber = sealNumbers[0]
+		lot.LastSealNumber = sealNumbers[len(sealNumbers)-1]
+		lot.CreatedBy = userID
+		if err := tx.Create(lot).Error; err != nil {
+			return nil, err
 		}
-		logEntry := model.Log{
-			UserID: userID,
-			Action: fmt.Sprintf("สร้างซีลใหม่ %d อัน จากเลขเริ่ม %s", count, startingSealNumber),
+		for i := range newSeals {
+			newSeals[i].SealLotID = &lot.ID
 		}
-		return s.logRepo.Create(&logEntry)
+		action += fmt.Sprintf(" (ล็อต %s ผู้ผลิต %s)", lot.LotNumber, lot.Supplier)
+	}
+	if err := s.repo.CreateMultiple(tx, newSeals); err != nil {
+		return nil, err
+	}
+	if err := recordSealCreations(tx, newSeals, UserActor(userID, ""), action); err != nil {
+		return nil, err
+	}
+	logEntry := model.Log{
+		UserID: userID,
+		Action: action,
+	}
+	if err := tx.Create(&logEntry).Error; err != nil {
+		return nil, err
+	}
+	return newSeals, nil
+}
+
+// SealBatchRequest ชุดซีลหนึ่งชุดในคำขอสร้างหลายชุด
+type SealBatchRequest struct {
+	SealNumber string `json:"seal_number"`
+	Count      int    `json:"count"`
+	LotNumber  string `json:"lot_number"`
+}
+
+// GenerateSealBatchesRequest คำขอสร้างซีลหลายชุด (ระบุ supplier = บันทึกล็อตผู้ผลิตของแต่ละชุด)
+type GenerateSealBatchesRequest struct {
+	Supplier      string             `json:"supplier"`
+	PurchaseOrder string             `json:"purchase_order"`
+	LotNumber     string             `json:"lot_number"`
+	DeliveryDate  string             `json:"delivery_date"`
+	Batches       []SealBatchRequest `json:"batches"`
+	DryRun        bool               `json:"dry_run,omitempty"` // true = แสดงตัวอย่างเท่านั้น ไม่สร้างจริง
+}
+
+// GenerateSealBatchesResult ซีลที่สร้างแยกตามชุด ล็อตที่บันทึก และช่วงเลขที่สร้างทั้งหมด
+type GenerateSealBatchesResult struct {
+	Results [][]model.Seal   `json:"results"`
+	Lots    []*model.SealLot `json:"lots"`
+	Ranges  []string         `json:"ranges"`
+}
+
+// Validate ตรวจสอบคำขอก่อนสร้างหรือส่งเข้าคิวอนุมัติ
+func (r GenerateSealBatchesRequest) Validate() error {
+	if len(r.Batches) == 0 {
+		return errors.New("No batches provided")
+	}
+	if _, err := r.deliveryDate(); err != nil {
+		return err
+	}
+	for _, batch := range r.Batches {
+		if batch.SealNumber == "" {
+			return errors.New("Seal number is required in each batch")
+		}
+		if batch.Count <= 0 {
+			return fmt.Errorf("Invalid count (%d) in batch for seal_number=%s", batch.Count, batch.SealNumber)
+		}
+		if r.Supplier != "" && batch.LotNumber == "" && r.LotNumber == "" {
+			return fmt.Errorf("lot_number is required for batch seal_number=%s", batch.SealNumber)
+		}
+	}
+	return nil
+}
+
+// TotalCount จำนวนซีลทั้งหมดในคำขอ
+func (r GenerateSealBatchesRequest) TotalCount() int {
+	total := 0
+	for _, batch := range r.Batches {
+		total += batch.Count
+	}
+	return total
+}
+
+func (r GenerateSealBatchesRequest) deliveryDate() (*time.Time, error) {
+	if r.DeliveryDate == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse("2006-01-02", r.DeliveryDate)
+	if err != nil {
+		return nil, errors.New("Invalid delivery_date format, use YYYY-MM-DD")
+	}
+	return &parsed, nil
+}
+
+// ErrSealBatchConflict เลขซีลในคำขอสร้างหลายชุดชนกับซีลที่มีอยู่ หรือชนกันเองระหว่างชุด
+var ErrSealBatchConflict = errors.New("เลขซีลในคำขอซ้ำกับซีลที่มีอยู่หรือซ้ำกันเองระหว่างชุด")
+
+// SealBatchPreview ผลตรวจของซีลหนึ่งชุดก่อนสร้าง
+//   - ranges: ช่วงเลขที่จะสร้าง
+//   - existing: เลขที่มีในระบบแล้ว (ชน)
+//   - overlaps: เลขที่ซ้ำกับชุดก่อนหน้าในคำขอเดียวกัน
+type SealBatchPreview struct {
+	SealNumber string   `json:"seal_number"`
+	Count      int      `json:"count"`
+	LotNumber  string   `json:"lot_number,omitempty"`
+	First      string   `json:"first"`
+	Last       string   `json:"last"`
+	Ranges     []string `json:"ranges"`
+	Existing   []string `json:"existing"`
+	Overlaps   []string `json:"overlaps"`
+
+	sealNumbers []string
+}
+
+// SealBatchPlan ผลตรวจคำขอสร้างหลายชุด (dry_run คืนค่านี้ ไม่สร้างจริง)
+type SealBatchPlan struct {
+	Batches    []SealBatchPreview `json:"batches"`
+	Total      int                `json:"total"`
+	Ranges     []string           `json:"ranges"`     // ช่วงเลขที่จะสร้างทั้งหมด
+	Collisions []string           `json:"collisions"` // ช่วงเลขที่ชน (มีแล้ว + ซ้ำระหว่างชุด)
+	OK         bool               `json:"ok"`         // ไม่มีเลขชน สร้างได้ทั้งหมด
+}
+
+// PlanSealBatches คำนวณเลขซีลของทุกชุดและตรวจการชน โดยไม่เขียนฐานข้อมูล
+func (s *SealService) PlanSealBatches(request GenerateSealBatchesRequest) (*SealBatchPlan, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	plan := &SealBatchPlan{Batches: []SealBatchPreview{}}
+	var all, collisions []string
+	batchOf := make(map[string]int)
+	for i, batch := range request.Batches {
+		sealNumbers, err := s.nextSealNumbers(batch.SealNumber, batch.Count)
+		if err != nil {
+			return nil, fmt.Errorf("batch seal_number=%s: %w", batch.SealNumber, err)
+		}
+		preview := SealBatchPreview{
+			SealNumber:  batch.SealNumber,
+			Count:       batch.Count,
+			LotNumber:   batch.LotNumber,
+			First:       sealNumbers[0],
+			Last:        sealNumbers[len(sealNumbers)-1],
+			Ranges:      s.CompressSealNumbers(sealNumbers),
+			Existing:    []string{},
+			Overlaps:    []string{},
+			sealNumbers: sealNumbers,
+		}
+		var overlaps []string
+		for _, sn := range sealNumbers {
+			if _, dup := batchOf[sn]; dup {
+				overlaps = append(overlaps, sn)
+				continue
+			}
+			batchOf[sn] = i
+		}
+		if len(overlaps) > 0 {
+			preview.Overlaps = s.CompressSealNumbers(overlaps)
+			collisions = append(collisions, overlaps...)
+		}
+		if request.Supplier != "" && preview.LotNumber == "" {
+			preview.LotNumber = request.LotNumber
+		}
+		plan.Batches = append(plan.Batches, preview)
+		all = append(all, sealNumbers...)
+	}
+
+	existing, err := s.repo.FindExistingNumbers(all)
+	if err != nil {
+		return nil, err
+	}
+	existingByBatch := make(map[int][]string)
+	for _, sn := range existing {
+		existingByBatch[batchOf[sn]] = append(existingByBatch[batchOf[sn]], sn)
+	}
+	for i, numbers := range existingByBatch {
+		plan.Batches[i].Existing = s.CompressSealNumbers(numbers)
+	}
+	collisions = append(collisions, existing...)
+
+	plan.Total = len(all)
+	plan.Ranges = s.CompressSealNumbers(all)
+	plan.Collisions = s.CompressSealNumbers(collisions)
+	plan.OK = len(collisions) == 0
+	return plan, nil
+}
+
+// GenerateSealBatches สร้างซีลทุกชุดในธุรกรรมเดียว ชุดใดชนหรือผิดพลาด จะไม่มีชุดใดถูกสร้าง
+// (error ห่อ ErrSealBatchConflict เมื่อเลขชน ผู้เรียกดูรายละเอียดได้จาก PlanSealBatches)
+func (s *SealService) GenerateSealBatches(request GenerateSealBatchesRequest, userID uint, officeCode string) (*GenerateSealBatchesResult, error) {
+	plan, err := s.PlanSealBatches(request)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.OK {
+		return nil, fmt.Errorf("%w: %s", ErrSealBatchConflict, strings.Join(plan.Collisions, ", "))
+	}
+	deliveryDate, _ := request.deliveryDate()
+
+	result := &GenerateSealBatchesResult{Ranges: plan.Ranges}
+	var created []model.Seal
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i, batch := range request.Batches {
+			// บันทึกล็อตผู้ผลิตเมื่อระบุ supplier (เลขล็อตของ batch ทับค่าเริ่มต้นได้)
+			var lot *model.SealLot
+			if request.Supplier != "" {
+				lot = &model.SealLot{
+					Supplier:      request.Supplier,
+					PurchaseOrder: request.PurchaseOrder,
+					LotNumber:     plan.Batches[i].LotNumber,
+					DeliveryDate:  deliveryDate,
+				}
+			}
+
+			seals, err := s.createSealBatch(tx, batch.SealNumber, plan.Batches[i].sealNumbers, userID, officeCode, lot)
+			if err != nil {
+				return fmt.Errorf("batch seal_number=%s: %w", batch.SealNumber, err)
+			}
+			result.Results = append(result.Results, seals)
+			if lot != nil {
+				result.Lots = append(result.Lots, lot)
+			}
+			created = append(created, seals...)
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	return newSeals, nil
+	s.checkSealStock(created...)
+	return result, nil
 }
 
 // -------------------------------------------------------------------
 // Legacy Mechanics: IssueSeal, UseSeal, ReturnSeal
 // -------------------------------------------------------------------
 func (s *SealService) IssueSeal(sealNumber string, userID uint) error {
-	return s.UpdateSealStatus(sealNumber, "จ่าย", userID)
+	return s.UpdateSealStatus(sealNumber, model.SealStatusIssued, userID)
 }
 func (s *SealService) UseSeal(sealNumber string, userID uint) error {
-	return s.UpdateSealStatus(sealNumber, "ติดตั้งแล้ว", userID)
+	return s.UpdateSealStatus(sealNumber, model.SealStatusInstalled, userID)
 }
 func (s *SealService) ReturnSeal(sealNumber string, userID uint) error {
-	return s.UpdateSealStatus(sealNumber, "ใช้งานแล้ว", userID)
+	return s.UpdateSealStatus(sealNumber, model.SealStatusUsed, userID)
 }
 
-func (s *SealService) UpdateSealStatus(sealNumber string, newStatus string, userID uint) error {
-	seal, err := s.repo.FindByNumber(sealNumber)
-	if err != nil {
-		return errors.New("ไม่พบซิลในระบบ")
-	}
-	now := time.Now()
-	logAction := ""
+func (s *SealService) UpdateSealStatus(sealNumber string, newStatus model.SealStatus, userID uint) error {
+	var action SealAction
 	switch newStatus {
-	case "จ่าย":
-		if seal.Status != "พร้อมใช้งาน" {
-			return errors.New("ซิลต้องอยู่ในสถานะ 'พร้อมใช้งาน' เท่านั้นจึงจะจ่ายได้")
-		}
-		seal.Status = "จ่าย"
-		seal.IssuedBy = &userID
-		seal.IssuedAt = &now
-		logAction = fmt.Sprintf("จ่ายซิล %s", sealNumber)
-	case "ติดตั้งแล้ว":
-		if seal.Status != "จ่าย" {
-			return errors.New("ซิลต้องอยู่ในสถานะ 'จ่าย' เท่านั้นจึงจะติดตั้งได้")
-		}
-		seal.Status = "ติดตั้งแล้ว"
-		seal.UsedBy = &userID
-		seal.UsedAt = &now
-		logAction = fmt.Sprintf("ติดตั้งซิล %s", sealNumber)
-	case "ใช้งานแล้ว":
-		if seal.Status != "ติดตั้งแล้ว" {
-			return errors.New("ซิลต้องอยู่ในสถานะ 'ติดตั้งแล้ว' เท่านั้นจึงจะใช้งานได้")
-		}
-		seal.Status = "ใช้งานแล้ว"
-		seal.ReturnedBy = &userID
-		seal.ReturnedAt = &now
-		logAction = fmt.Sprintf("ซิล %s ถูกตั้งค่าว่าใช้งานแล้ว", sealNumber)
+	case model.SealStatusIssued:
+		action = SealActionIssue
+	case model.SealStatusInstalled:
+		action = SealActionInstall
+	case model.SealStatusUsed:
+		action = SealActionReturn
 	default:
 		return errors.New("สถานะไม่ถูกต้อง")
 	}
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := s.repo.Update(seal); err != nil {
+
+	var seal *model.Seal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if seal, err = lockSealByNumber(tx, sealNumber); err != nil {
+			return errors.New("ไม่พบซิลในระบบ")
+		}
+		to, err := checkSealTransition(seal, action, UserActor(userID, ""))
+		if err != nil {
+			return err
+		}
+		if err := checkSealNotReserved(seal); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		logAction := ""
+		before := *seal
+		seal.Status = to
+		switch action {
+		case SealActionIssue:
+			clearSealReservation(seal)
+			seal.IssuedBy = &userID
+			seal.IssuedAt = &now
+			logAction = fmt.Sprintf("จ่ายซิล %s", sealNumber)
+		case SealActionInstall:
+			seal.UsedBy = &userID
+			seal.UsedAt = &now
+			logAction = fmt.Sprintf("ติดตั้งซิล %s", sealNumber)
+		case SealActionReturn:
+			seal.ReturnedBy = &userID
+			seal.ReturnedAt = &now
+			logAction = fmt.Sprintf("ซิล %s ถูกตั้งค่าว่าใช้งานแล้ว", sealNumber)
+		}
+
+		if err := saveSeal(tx, seal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &before, seal, action, UserActor(userID, ""), ""); err != nil {
 			return err
 		}
 		logEntry := model.Log{
 			UserID: userID,
 			Action: logAction,
 		}
-		return s.logRepo.Create(&logEntry)
+		return tx.Create(&logEntry).Error
 	})
+	if err != nil {
+		return err
+	}
+	if action == SealActionIssue {
+		s.checkSealStock(*seal)
+	}
+	return nil
 }
 
 // -------------------------------------------------------------------
 // New Methods: Support SerialNumber & Remarks
 // -------------------------------------------------------------------
 func (s *SealService) UseSealWithSerial(sealNumber string, userID uint, deviceSerial string) error {
-	return s.UpdateSealStatusWithExtra(sealNumber, "ติดตั้งแล้ว", userID, deviceSerial, "")
+	return s.UpdateSealStatusWithExtra(sealNumber, model.SealStatusInstalled, userID, deviceSerial, "")
 }
 
 func (s *SealService) ReturnSealWithRemarks(sealNumber string, userID uint, remarks string) error {
-	return s.UpdateSealStatusWithExtra(sealNumber, "ใช้งานแล้ว", userID, "", remarks)
+	return s.UpdateSealStatusWithExtra(sealNumber, model.SealStatusUsed, userID, "", remarks)
 }
 
-func (s *SealService) IssueSealWithDetails(sealNumber string, issuedTo uint, employeeCode string, remark string) error {
-	seal, err := s.repo.FindByNumber(sealNumber)
-	if err != nil {
-		return errors.New("ไม่พบซิลในระบบ")
-	}
-	if seal.Status != "พร้อมใช้งาน" {
-		return errors.New("ซิลต้องอยู่ในสถานะ 'พร้อมใช้งาน' เท่านั้นจึงจะจ่ายได้")
-	}
-	now := time.Now()
-	seal.Status = "จ่าย"
-	seal.IssuedTo = &issuedTo
-	seal.AssignedToTechnician = &issuedTo
-	seal.IssuedAt = &now
-	seal.EmployeeCode = employeeCode
-	seal.IssueRemark = remark
+// IssueSealWithDetails จ่ายซีลให้ช่าง issuedTo โดย actor (ผู้เรียกจาก JWT) เป็นผู้ทำรายการ
+func (s *SealService) IssueSealWithDetails(sealNumber string, issuedTo uint, employeeCode string, remark string, actor Actor) error {
+	var seal *model.Seal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if seal, err = lockSealByNumber(tx, sealNumber); err != nil {
+			return errors.New("ไม่พบซิลในระบบ")
+		}
+		to, err := checkSealTransition(seal, SealActionIssue, actor)
+		if err != nil {
+			return err
+		}
+		if err := checkSealReservation(seal, issuedTo); err != nil {
+			return err
+		}
+		now := time.Now()
+		before := *seal
+		seal.Status = to
+		clearSealReservation(seal)
+		seal.IssuedTo = &issuedTo
+		seal.AssignedToTechnician = &issuedTo
+		seal.IssuedAt = &now
+		seal.EmployeeCode = employeeCode
+		seal.IssueRemark = remark
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := s.repo.Update(seal); err != nil {
+		if err := saveSeal(tx, seal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &before, seal, SealActionIssue, actor, remark); err != nil {
 			return err
 		}
 		logEntry := model.Log{
-			UserID: issuedTo,
+			UserID: actor.ID,
 			Action: fmt.Sprintf("จ่ายซิล %s ให้พนักงาน %d (รหัส: %s) - หมายเหตุ: %s", sealNumber, issuedTo, employeeCode, remark),
 		}
-		return s.logRepo.Create(&logEntry)
+		return tx.Create(&logEntry).Error
 	})
-}
-
-func (s *SealService) UpdateSealStatusWithExtra(sealNumber string, newStatus string, userID uint, deviceSerial string, remarks string) error {
-	seal, err := s.repo.FindByNumber(sealNumber)
 	if err != nil {
-		return errors.New("ไม่พบซิลในระบบ")
+		return err
 	}
-	now := time.Now()
-	logAction := ""
+	s.checkSealStock(*seal)
+	return nil
+}
+
+func (s *SealService) UpdateSealStatusWithExtra(sealNumber string, newStatus model.SealStatus, userID uint, deviceSerial string, remarks string) error {
+	var action SealAction
 	switch newStatus {
-	case "ติดตั้งแล้ว":
-		if seal.Status != "จ่าย" {
-			return errors.New("ซิลต้องอยู่ในสถานะ 'จ่าย' เท่านั้นจึงจะติดตั้งได้")
-		}
-		seal.Status = "ติดตั้งแล้ว"
-		seal.UsedBy = &userID
-		seal.UsedAt = &now
-		seal.InstalledSerial = deviceSerial
-		logAction = fmt.Sprintf("ติดตั้งซิล %s (Serial: %s)", sealNumber, deviceSerial)
-	case "ใช้งานแล้ว":
-		if seal.Status != "ติดตั้งแล้ว" {
-			return errors.New("ซิลต้องอยู่ในสถานะ 'ติดตั้งแล้ว' เท่านั้นจึงจะใช้งานได้")
-		}
-		seal.Status = "ใช้งานแล้ว"
-		seal.ReturnedBy = &userID
-		seal.ReturnedAt = &now
-		seal.ReturnRemarks = remarks
-		logAction = fmt.Sprintf("ซิล %s ถูกตั้งค่าว่าใช้งานแล้ว (หมายเหตุ: %s)", sealNumber, remarks)
+	case model.SealStatusInstalled:
+		action = SealActionInstall
+	case model.SealStatusUsed:
+		action = SealActionReturn
 	default:
 		return errors.New("สถานะไม่ถูกต้อง (version Extra)")
 	}
+
 	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := s.repo.Update(seal); err != nil {
+		seal, err := lockSealByNumber(tx, sealNumber)
+		if err != nil {
+			return errors.New("ไม่พบซิลในระบบ")
+		}
+		to, err := checkSealTransition(seal, action, UserActor(userID, ""))
+		if err != nil {
 			return err
 		}
+
+		now := time.Now()
+		logAction := ""
+		before := *seal
+		seal.Status = to
+		switch action {
+		case SealActionInstall:
+			seal.UsedBy = &userID
+			seal.UsedAt = &now
+			seal.InstalledSerial = deviceSerial
+			logAction = fmt.Sprintf("ติดตั้งซิล %s (Serial: %s)", sealNumber, deviceSerial)
+		case SealActionReturn:
+			seal.ReturnedBy = &userID
+			seal.ReturnedAt = &now
+			seal.ReturnRemarks = remarks
+			logAction = fmt.Sprintf("ซิล %s ถูกตั้งค่าว่าใช้งานแล้ว (หมายเหตุ: %s)", sealNumber, remarks)
+		}
+
+		if err := saveSeal(tx, seal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &before, seal, action, UserActor(userID, ""), remarks); err != nil {
+			return err
+		}
+		if action == SealActionInstall {
+			if _, err := linkSealToMeter(tx, seal, deviceSerial, UserActor(userID, ""), now); err != nil {
+				return err
+			}
+		}
 		logEntry := model.Log{
 			UserID: userID,
 			Action: logAction,
 		}
-		return s.logRepo.Create(&logEntry)
+		return tx.Create(&logEntry).Error
 	})
 }
 
 // -------------------------------------------------------------------
-// GenerateNextSealNumbers
+// nextSealNumbers / ValidateSealNumbers / ช่วงเลขซีล: ตามรูปแบบเลขซีลที่ลงทะเบียนไว้
 // -------------------------------------------------------------------
-func GenerateNextSealNumbers(latest string, count int) ([]string, error) {
-	if latest == "" {
-		latest = "F000000000001"
-	}
-	re := regexp.MustCompile(`^([A-Za-z]*)(\d+)$`)
-	matches := re.FindStringSubmatch(latest)
-	if len(matches) != 3 {
-		return nil, errors.New("รูปแบบเลขซิลไม่ถูกต้อง")
-	}
-	prefix := matches[1]
-	numberPart := matches[2]
-	lastInt, err := strconv.ParseInt(numberPart, 10, 64)
+
+// nextSealNumbers เลขซีลต่อเนื่อง count เส้นเริ่มจาก start ในรูปแบบเดียวกับ start
+func (s *SealService) nextSealNumbers(start string, count int) ([]string, error) {
+	if start == "" {
+		start = "F000000000001"
+	}
+	registry, err := s.formatService.Registry()
+	if err != nil {
+		return nil, err
+	}
+	return registry.Next(start, count)
+}
+
+// ValidateSealNumbers เลขซีลที่อ้างถึงซีลเดิมต้องมีอยู่ในระบบแล้ว หรือตรงกับรูปแบบที่ลงทะเบียนไว้ (รวมเลขตรวจสอบ)
+// ซีลที่มีอยู่ในระบบรับได้เสมอ เลขรูปแบบเดิม (ก่อนลงทะเบียนรูปแบบ) อาจบังเอิญตรง prefix ของรูปแบบใหม่แต่เลขตรวจสอบไม่ผ่าน
+func (s *SealService) ValidateSealNumbers(sealNumbers ...string) error {
+	registry, err := s.formatService.Registry()
 	if err != nil {
-		return nil, errors.New("เลขซิลไม่ถูกต้อง")
+		return err
 	}
-	sealNumbers := make([]string, count)
-	numberLength := len(numberPart)
-	for i := 0; i < count; i++ {
-		newNum := lastInt + int64(i)
-		sealNumbers[i] = fmt.Sprintf("%s%0*d", prefix, numberLength, newNum)
+	if len(sealNumbers) == 0 {
+		return nil
 	}
-	return sealNumbers, nil
+	existing, err := s.repo.FindExistingNumbers(sealNumbers)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, sn := range existing {
+		known[sn] = true
+	}
+	var unknown []string
+	for _, sn := range sealNumbers {
+		if !known[sn] {
+			unknown = append(unknown, sn)
+		}
+	}
+	return registry.ValidateNew(unknown...)
+}
+
+// ExpandSealNumbers ขยายช่วงเลขซีล ("F0001001-F0001050, F0001060") เป็นรายการเลขซีล
+func (s *SealService) ExpandSealNumbers(expressions ...string) ([]string, error) {
+	registry, err := s.formatService.Registry()
+	if err != nil {
+		return nil, err
+	}
+	return registry.ExpandRanges(expressions...)
+}
+
+// CompressSealNumbers ย่อรายการเลขซีลเป็นช่วง สำหรับแสดงในผลลัพธ์ (โหลดรูปแบบไม่ได้ = คืนรายการเดิม)
+func (s *SealService) CompressSealNumbers(sealNumbers []string) []string {
+	registry, err := s.formatService.Registry()
+	if err != nil {
+		return sealNumbers
+	}
+	return registry.CompressRanges(sealNumbers)
 }
 
 // -------------------------------------------------------------------
-// GetSealReport (4 statuses)
+// GetSealReport (นับตามทุกสถานะใน model.AllSealStatuses)
 // -------------------------------------------------------------------
-func (s *SealService) GetSealReport() (map[string]interface{}, error) {
-	var total, ready, paid, installed, used int64
-	if err := s.db.Model(&model.Seal{}).Where("status = ?", "พร้อมใช้งาน").Count(&ready).Error; err != nil {
-		return nil, err
+
+// SealStatusCount จำนวนซีลของแต่ละสถานะในรายงาน
+type SealStatusCount struct {
+	Status model.SealStatus `json:"status"`
+	Label  string           `json:"label"`
+	Count  int64            `json:"count"`
+}
+
+// OfficeSealReport ยอดซีลของสำนักงานหนึ่ง แยกตามสถานะ (office_code ว่าง = ซีลที่ยังไม่ระบุสำนักงาน)
+type OfficeSealReport struct {
+	OfficeCode string            `json:"office_code"`
+	Total      int64             `json:"total"`
+	ByStatus   []SealStatusCount `json:"by_status"`
+}
+
+func (s *SealService) GetSealReport(scope OfficeScope) (map[string]interface{}, error) {
+	var rows []struct {
+		OfficeCode string
+		Status     model.SealStatus
+		Count      int64
 	}
-	if err := s.db.Model(&model.Seal{}).Where("status = ?", "จ่าย").Count(&paid).Error; err != nil {
-		return nil, err
+	query := s.db.Model(&model.Seal{})
+	if offices := scope.Codes(); offices != nil {
+		query = query.Where("office_code IN ?", offices)
 	}
-	if err := s.db.Model(&model.Seal{}).Where("status = ?", "ติดตั้งแล้ว").Count(&installed).Error; err != nil {
+	if err := query.
+		Select("office_code, status, COUNT(*) AS count").
+		Group("office_code, status").
+		Order("office_code").
+		Scan(&rows).Error; err != nil {
 		return nil, err
 	}
-	if err := s.db.Model(&model.Seal{}).Where("status = ?", "ใช้งานแล้ว").Count(&used).Error; err != nil {
+	counts := make(map[model.SealStatus]int64)
+	officeCounts := make(map[string]map[model.SealStatus]int64)
+	var offices []string
+	for _, row := range rows {
+		counts[row.Status] += row.Count
+		if officeCounts[row.OfficeCode] == nil {
+			officeCounts[row.OfficeCode] = make(map[model.SealStatus]int64)
+			offices = append(offices, row.OfficeCode)
+		}
+		officeCounts[row.OfficeCode][row.Status] = row.Count
+	}
+
+	total, byStatus := countSealStatuses(counts)
+	byOffice := []OfficeSealReport{}
+	for _, office := range offices {
+		officeTotal, officeByStatus := countSealStatuses(officeCounts[office])
+		byOffice = append(byOffice, OfficeSealReport{
+			OfficeCode: office,
+			Total:      officeTotal,
+			ByStatus:   officeByStatus,
+		})
+	}
+	pendingIncidents, err := s.incidentRepo.CountByStatus(model.SealIncidentPending, scope.Codes())
+	if err != nil {
 		return nil, err
 	}
-	total = ready + paid + installed + used
 	report := map[string]interface{}{
-		"total_seals": total,
-		"พร้อมใช้งาน": ready,
-		"จ่าย":        paid,
-		"ติดตั้งแล้ว": installed,
-		"ใช้งานแล้ว":  used,
+		"total_seals":       total,
+		"by_status":         byStatus,
+		"by_office":         byOffice,
+		"pending_incidents": pendingIncidents,
 	}
 	return report, nil
 }
 
+// countSealStatuses เรียงยอดตามลำดับสถานะในวงจรชีวิต พร้อมยอดรวม
+func countSealStatuses(counts map[model.SealStatus]int64) (int64, []SealStatusCount) {
+	var total int64
+	byStatus := []SealStatusCount{}
+	for _, status := range model.AllSealStatuses() {
+		total += counts[status]
+		byStatus = append(byStatus, SealStatusCount{
+			Status: status,
+			Label:  status.Label(),
+			Count:  counts[status],
+		})
+	}
+	return total, byStatus
+}
+
 func (s *SealService) GetSealsByTechnician(techID uint) ([]model.Seal, error) {
 	var seals []model.Seal
 	if err := s.db.Where("assigned_to_technician = ?", techID).Find(&seals).Error; err != nil {
@@ -390,97 +822,126 @@ func (s *SealService) GetSealsByTechnician(techID uint) ([]model.Seal, error) {
 	return seals, nil
 }
 
-// ✅ ฟังก์ชันตรวจสอบว่าหมายเลข Seal มีอยู่หรือไม่
-func (s *SealService) CheckSealBeforeGenerate(sealPrefix string, lastNumbers []int) (bool, error) {
-	missingSeals := []int{}
-
-	for _, num := range lastNumbers {
-		sealNumber := fmt.Sprintf("%s%02d", sealPrefix, num)
-		exists, err := s.repo.CheckSealExists(sealNumber)
-		if err != nil {
-			return false, err
-		}
-		if !exists {
-			missingSeals = append(missingSeals, num)
-		}
+// ✅ ฟังก์ชันตรวจสอบว่าหมายเลข Seal มีอยู่หรือไม่ (เลขต้องแยกได้ตามรูปแบบที่ลงทะเบียนไว้ หรือรูปแบบเดิม)
+func (s *SealService) SealNumberExists(sealNumber string) (bool, error) {
+	registry, err := s.formatService.Registry()
+	if err != nil {
+		return false, err
 	}
-	if len(missingSeals) > 0 {
-		return false, fmt.Errorf("หมายเลข Seal ไม่พบในระบบ: %v", missingSeals)
+	if _, err := registry.Parse(sealNumber); err != nil {
+		return false, err
 	}
-	return true, nil
+	return s.repo.CheckSealExists(sealNumber)
 }
 
-func (s *SealService) AssignSealToTechnician(sealNumber string, techID uint, issuedBy uint, remark string) error {
-	seal, err := s.repo.FindByNumber(sealNumber)
+// AssignSealToTechnician มอบหมายซีลให้ช่าง (jobNumber ว่าง = ไม่ผูกใบงาน)
+func (s *SealService) AssignSealToTechnician(sealNumber string, techID uint, issuedBy uint, remark string, jobNumber string) error {
+	workOrder, err := s.resolveWorkOrder(jobNumber, techID)
 	if err != nil {
 		return err
 	}
 
-	if seal.Status != "พร้อมใช้งาน" && seal.Status != "จ่าย" {
-		return errors.New("ซิลต้องอยู่ในสถานะ 'พร้อมใช้งาน' หรือ 'จ่าย' เท่านั้นจึงจะ Assign ได้")
-	}
+	var seal *model.Seal
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if seal, err = lockSealByNumber(tx, sealNumber); err != nil {
+			return err
+		}
+		to, err := checkSealAssignment(seal, techID, UserActor(issuedBy, ""))
+		if err != nil {
+			return err
+		}
 
-	now := time.Now()
+		now := time.Now()
+		before := *seal
 
-	if seal.Status == "พร้อมใช้งาน" {
-		seal.Status = "จ่าย"
-		seal.IssuedAt = &now
-		seal.IssuedBy = &issuedBy
-	}
+		if seal.Status != to {
+			seal.Status = to
+			seal.IssuedAt = &now
+			seal.IssuedBy = &issuedBy
+			clearSealReservation(seal)
+		}
 
-	seal.AssignedToTechnician = &techID
-	seal.IssueRemark = remark
+		seal.AssignedToTechnician = &techID
+		seal.IssueRemark = remark
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(seal).Error; err != nil {
+		action := fmt.Sprintf("Assigned seal %s to technician ID %d", sealNumber, techID)
+		if workOrder != nil {
+			seal.WorkOrderID = &workOrder.ID
+			action += fmt.Sprintf(" for work order %s", workOrder.JobNumber)
+		}
+
+		if err := saveSeal(tx, seal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &before, seal, SealActionAssign, UserActor(issuedBy, ""), remark); err != nil {
 			return err
 		}
 		log := model.Log{
 			UserID:    issuedBy,
-			Action:    fmt.Sprintf("Assigned seal %s to technician ID %d", sealNumber, techID),
+			Action:    action,
 			Timestamp: now,
 		}
-		if err := tx.Create(&log).Error; err != nil {
-			return err
-		}
-		return nil
+		return tx.Create(&log).Error
 	})
+	if err != nil {
+		return err
+	}
+	s.checkSealStock(*seal)
+	return nil
 }
 
-func (s *SealService) InstallSeal(sealNumber string, techID uint, serialNumber string) error {
-	// ค้นหาซิลจากฐานข้อมูล
-	seal, err := s.repo.FindByNumber(sealNumber)
+// InstallSeal ช่างติดตั้งซีล (jobNumber ว่าง = ใช้ใบงานที่ผูกไว้ตอนมอบหมาย ถ้ามี)
+func (s *SealService) InstallSeal(sealNumber string, techID uint, serialNumber string, jobNumber string) error {
+	workOrder, err := s.resolveWorkOrder(jobNumber, techID)
 	if err != nil {
-		return errors.New("ไม่พบซิลในระบบ")
+		return err
 	}
 
-	// ตรวจสอบว่าซิลถูกมอบหมายให้ช่างคนนี้หรือไม่
-	if seal.AssignedToTechnician == nil || *seal.AssignedToTechnician != techID {
-		return errors.New("คุณไม่มีสิทธิ์ติดตั้งซีลนี้")
-	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// ค้นหาซิลจากฐานข้อมูล (ล็อกแถวไว้จนจบ tx)
+		seal, err := lockSealByNumber(tx, sealNumber)
+		if err != nil {
+			return errors.New("ไม่พบซิลในระบบ")
+		}
 
-	// ✅ เพิ่ม Debug Log และ Trim ช่องว่างใน status
-	log.Printf("🛠 [InstallSeal] sealNumber=%s, DB status='%s'", sealNumber, seal.Status)
-	actualStatus := strings.TrimSpace(seal.Status)
-	if actualStatus != "จ่าย" {
-		return errors.New("ซิลต้องอยู่ในสถานะ 'จ่าย' เท่านั้นจึงจะติดตั้งได้")
-	}
+		// ตรวจสอบสถานะและสิทธิ์ของช่าง (ต้องเป็นช่างที่ได้รับมอบหมาย) ผ่านตาราง transition
+		log.Printf("🛠 [InstallSeal] sealNumber=%s, DB status='%s'", sealNumber, seal.Status)
+		to, err := checkSealTransition(seal, SealActionInstall, TechnicianActor(techID))
+		if err != nil {
+			return err
+		}
 
-	now := time.Now()
-	seal.Status = "ติดตั้งแล้ว"
-	seal.UsedBy = &techID
-	seal.UsedAt = &now
-	seal.InstalledSerial = serialNumber
+		now := time.Now()
+		before := *seal
+		seal.Status = to
+		seal.UsedBy = &techID
+		seal.UsedAt = &now
+		seal.InstalledSerial = serialNumber
+		if workOrder != nil {
+			seal.WorkOrderID = &workOrder.ID
+		}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := s.repo.Update(seal); err != nil {
+		action := fmt.Sprintf("ติดตั้งซิล %s (Serial: %s)", sealNumber, serialNumber)
+		if workOrder != nil {
+			action += fmt.Sprintf(" ใบงาน %s", workOrder.JobNumber)
+		}
+
+		if err := saveSeal(tx, seal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &before, seal, SealActionInstall, TechnicianActor(techID), ""); err != nil {
+			return err
+		}
+		// ผูกซีลเข้ากับมิเตอร์ตาม serial ที่ติดตั้ง
+		if _, err := linkSealToMeter(tx, seal, serialNumber, TechnicianActor(techID), now); err != nil {
 			return err
 		}
 		logEntry := model.Log{
 			UserID: techID,
-			Action: fmt.Sprintf("ติดตั้งซิล %s (Serial: %s)", sealNumber, serialNumber),
+			Action: action,
 		}
-		return s.logRepo.Create(&logEntry)
+		return tx.Create(&logEntry).Error
 	})
 }
 
@@ -493,64 +954,92 @@ func (s *SealService) GetSealLogs(sealNumber string) ([]model.Log, error) {
 	return logs, nil
 }
 
+// SealNumbersFromBase เลขซีลชุดเดียวกับ base ที่เลขลำดับ lastNumbers (รูปแบบเดิมของ issue-multiple)
+func (s *SealService) SealNumbersFromBase(base string, lastNumbers []int) ([]string, error) {
+	registry, err := s.formatService.Registry()
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := registry.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	sealNumbers := make([]string, 0, len(lastNumbers))
+	for _, num := range lastNumbers {
+		sealNumber, err := parsed.WithSerial(int64(num))
+		if err != nil {
+			return nil, err
+		}
+		sealNumbers = append(sealNumbers, sealNumber)
+	}
+	return sealNumbers, nil
+}
+
 func (s *SealService) IssueMultipleSeals(
-	prefix string,
-	baseNumStr string,
-	lastNumbers []int,
+	sealNumbers []string,
 	issuedTo uint,
 	employeeCode string,
 	remark string,
+	actor Actor,
+	scope OfficeScope,
 ) ([]model.Seal, error) {
 
-	digitCount := len(baseNumStr)
 	var sealsToIssue []model.Seal
 
-	for _, num := range lastNumbers {
-		fullSealNumber := fmt.Sprintf("%s%0*d", prefix, digitCount, num)
-
-		seal, err := s.repo.FindByNumber(fullSealNumber)
-		if err != nil {
-			return nil, fmt.Errorf("ไม่พบซีลในระบบ: %s", fullSealNumber)
-		}
-		if seal.Status != "พร้อมใช้งาน" {
-			return nil, fmt.Errorf("ซีล %s ไม่ได้อยู่ในสถานะ 'พร้อมใช้งาน'", fullSealNumber)
-		}
-		sealsToIssue = append(sealsToIssue, *seal)
-	}
-
 	now := time.Now()
 	err := s.db.Transaction(func(tx *gorm.DB) error {
-		for i := range sealsToIssue {
-			sealsToIssue[i].Status = "จ่าย"
-			sealsToIssue[i].IssuedTo = &issuedTo
-			sealsToIssue[i].AssignedToTechnician = &issuedTo
-			sealsToIssue[i].IssuedAt = &now
-			sealsToIssue[i].EmployeeCode = employeeCode
-			sealsToIssue[i].IssueRemark = remark
-
-			if err := s.repo.Update(&sealsToIssue[i]); err != nil {
+		for _, fullSealNumber := range sealNumbers {
+			seal, err := lockSealByNumber(tx, fullSealNumber)
+			if err != nil {
+				return fmt.Errorf("ไม่พบซีลในระบบ: %s", fullSealNumber)
+			}
+			if err := checkSealInScope(scope, seal); err != nil {
+				return err
+			}
+			if _, err := checkSealTransition(seal, SealActionIssue, actor); err != nil {
+				return err
+			}
+			if err := checkSealReservation(seal, issuedTo); err != nil {
+				return err
+			}
+
+			before := *seal
+			seal.Status = model.SealStatusIssued
+			clearSealReservation(seal)
+			seal.IssuedTo = &issuedTo
+			seal.AssignedToTechnician = &issuedTo
+			seal.IssuedAt = &now
+			seal.EmployeeCode = employeeCode
+			seal.IssueRemark = remark
+
+			if err := saveSeal(tx, seal); err != nil {
+				return err
+			}
+			if err := recordSealHistory(tx, &before, seal, SealActionIssue, actor, remark); err != nil {
 				return err
 			}
 
 			logEntry := model.Log{
-				UserID: issuedTo,
+				UserID: actor.ID,
 				Action: fmt.Sprintf(
 					"จ่ายซิล %s ให้พนักงาน %d (รหัส: %s) - หมายเหตุ: %s",
-					sealsToIssue[i].SealNumber,
+					seal.SealNumber,
 					issuedTo,
 					employeeCode,
 					remark,
 				),
 			}
-			if err := s.logRepo.Create(&logEntry); err != nil {
+			if err := tx.Create(&logEntry).Error; err != nil {
 				return err
 			}
+			sealsToIssue = append(sealsToIssue, *seal)
 		}
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	s.checkSealStock(sealsToIssue...)
 	return sealsToIssue, nil
 }
 
@@ -573,7 +1062,7 @@ func (s *SealService) CheckSealAvailability(sealNumbers []string) ([]string, []s
 	var missingSeals []string
 
 	var seals []model.Seal
-	if err := s.db.Where("seal_number IN ? AND status = ?", sealNumbers, "พร้อมใช้งาน").Find(&seals).Error; err != nil {
+	if err := s.db.Where("seal_number IN ? AND status = ?", sealNumbers, model.SealStatusAvailable).Find(&seals).Error; err != nil {
 		return nil, nil, err
 	}
 	sealMap := make(map[string]bool)
@@ -590,7 +1079,8 @@ func (s *SealService) CheckSealAvailability(sealNumbers []string) ([]string, []s
 	return foundSeals, missingSeals, nil
 }
 
-func (s *SealService) AssignSealsByTechCode(techCode string, sealNumbers []string, remark string) error {
+// AssignSealsByTechCode มอบหมายซีลทั้งชุดให้ช่างรหัส techCode ในธุรกรรมเดียว (เส้นใดไม่ผ่าน จะไม่มอบหมายเลย)
+func (s *SealService) AssignSealsByTechCode(techCode string, sealNumbers []string, remark string, actor Actor) error {
 	// 1) หา Technician
 	technician, err := s.technicianRepo.FindByTechCode(techCode)
 	if err != nil {
@@ -599,67 +1089,128 @@ func (s *SealService) AssignSealsByTechCode(techCode string, sealNumbers []strin
 
 	now := time.Now()
 
-	// 2) วนลูปซีล
-	for _, sn := range sealNumbers {
-		seal, err := s.repo.FindByNumber(sn)
-		if err != nil {
-			return fmt.Errorf("ซีล %s ไม่พบในระบบ", sn)
-		}
-		// ตรวจสอบสถานะ
-		if seal.Status != "พร้อมใช้งาน" && seal.Status != "จ่าย" {
-			return fmt.Errorf("ซีล %s ไม่ได้อยู่ในสถานะที่อนุญาตให้ assign", sn)
-		}
-		// ถ้าเป็น “พร้อมใช้งาน” -> เปลี่ยนเป็น “จ่าย”
-		if seal.Status == "พร้อมใช้งาน" {
-			seal.Status = "จ่าย"
-			seal.IssuedAt = &now
-		}
-		// ใส่ technician ลงในฟิลด์ AssignedToTechnician
-		seal.AssignedToTechnician = &technician.ID
-		seal.IssueRemark = remark
+	// 2) วนลูปซีลใน tx เดียว: ตรวจสถานะ + บันทึกซีล + history + log ทุกเส้น
+	var assigned []model.Seal
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, sn := range sealNumbers {
+			seal, err := lockSealByNumber(tx, sn)
+			if err != nil {
+				return fmt.Errorf("ซีล %s ไม่พบในระบบ", sn)
+			}
+			// ตรวจสอบสถานะตามตาราง transition (ซีลที่อยู่กับช่างคนอื่นต้องใช้การโอน)
+			to, err := checkSealAssignment(seal, technician.ID, actor)
+			if err != nil {
+				return err
+			}
+			// ถ้าเป็น “พร้อมใช้งาน” -> เปลี่ยนเป็น “จ่าย”
+			before := *seal
+			if seal.Status != to {
+				seal.Status = to
+				seal.IssuedAt = &now
+				clearSealReservation(seal)
+			}
+			// ใส่ technician ลงในฟิลด์ AssignedToTechnician
+			seal.AssignedToTechnician = &technician.ID
+			seal.IssueRemark = remark
 
-		// Update DB
-		if err := s.repo.Update(seal); err != nil {
-			return err
-		}
-		// log
-		logEntry := model.Log{
-			UserID: technician.ID,
-			Action: fmt.Sprintf("Assigned seal %s to technician_code=%s", sn, techCode),
-		}
-		if err := s.logRepo.Create(&logEntry); err != nil {
-			return err
+			if err := saveSeal(tx, seal); err != nil {
+				return err
+			}
+			if err := recordSealHistory(tx, &before, seal, SealActionAssign, actor, remark); err != nil {
+				return err
+			}
+			logEntry := model.Log{
+				UserID: actor.ID,
+				Action: fmt.Sprintf("Assigned seal %s to technician_code=%s", sn, techCode),
+			}
+			if err := tx.Create(&logEntry).Error; err != nil {
+				return err
+			}
+			assigned = append(assigned, *seal)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	s.checkSealStock(assigned...)
 	return nil
 }
 func (s *SealService) CancelSeal(sealNumber string, userID uint) error {
-	seal, err := s.repo.FindByNumber(sealNumber)
-	if err != nil {
-		return errors.New("ไม่พบซิลในระบบ")
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		seal, err := lockSealByNumber(tx, sealNumber)
+		if err != nil {
+			return errors.New("ไม่พบซิลในระบบ")
+		}
+		return cancelSeal(tx, seal, userID)
+	})
+}
+
+// CancelSeals คืนซีลหลายเส้นกลับเข้าคลังในธุรกรรมเดียว (เส้นใดคืนไม่ได้ = ไม่คืนเลย)
+func (s *SealService) CancelSeals(sealNumbers []string, userID uint) error {
+	if len(sealNumbers) == 0 {
+		return errors.New("กรุณาระบุเลขซีล")
 	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, sealNumber := range sealNumbers {
+			seal, err := lockSealByNumber(tx, sealNumber)
+			if err != nil {
+				return fmt.Errorf("ไม่พบซีล %s ในระบบ", sealNumber)
+			}
+			if err := cancelSeal(tx, seal, userID); err != nil {
+				return fmt.Errorf("ซีล %s: %w", seal.SealNumber, err)
+			}
+		}
+		return nil
+	})
+}
 
+// cancelSeal คืนซีลกลับเป็นสถานะ 'พร้อมใช้งาน' ภายใน tx
+func cancelSeal(tx *gorm.DB, seal *model.Seal, userID uint) error {
 	// เช็กว่าซีลสามารถคืนได้หรือไม่
-	if seal.Status == "ติดตั้งแล้ว" || seal.Status == "ใช้งานแล้ว" {
-		return errors.New("ซีลถูกใช้งานไปแล้ว ไม่สามารถคืนได้")
+	to, err := checkSealTransition(seal, SealActionCancel, UserActor(userID, ""))
+	if err != nil {
+		return err
+	}
+
+	// บันทึกผู้ที่ถือซีลอยู่ก่อนคืน (ช่าง/พนักงาน) ไว้ใน log
+	holder := ""
+	if seal.AssignedToTechnician != nil {
+		holder = fmt.Sprintf(" จากช่าง ID %d", *seal.AssignedToTechnician)
+	} else if seal.IssuedTo != nil {
+		holder = fmt.Sprintf(" จากพนักงาน ID %d", *seal.IssuedTo)
 	}
 
 	now := time.Now()
-	seal.Status = "พร้อมใช้งาน"
+	before := *seal
+	seal.Status = to
 	seal.IssuedBy = nil
 	seal.IssuedTo = nil
 	seal.IssuedAt = nil
+	seal.AssignedToTechnician = nil
+	seal.EmployeeCode = ""
+	seal.WorkOrderID = nil
 	seal.ReturnedBy = &userID
 	seal.ReturnedAt = &now
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
-		if err := s.repo.Update(seal); err != nil {
-			return err
-		}
-		logEntry := model.Log{
-			UserID: userID,
-			Action: fmt.Sprintf("คืนซีล %s กลับเป็นสถานะ 'พร้อมใช้งาน'", sealNumber),
-		}
-		return s.logRepo.Create(&logEntry)
-	})
+	if err := saveSeal(tx, seal); err != nil {
+		return err
+	}
+	if err := recordSealHistory(tx, &before, seal, SealActionCancel, UserActor(userID, ""), ""); err != nil {
+		return err
+	}
+	logEntry := model.Log{
+		UserID: userID,
+		Action: fmt.Sprintf("คืนซีล %s%s กลับเป็นสถานะ 'พร้อมใช้งาน'", seal.SealNumber, holder),
+	}
+	return tx.Create(&logEntry).Error
+}
+
+// GetSealTransitions คืนซีลพร้อมรายการ action ถัดไปที่ผู้เรียกทำได้ (scope = ขอบเขตสำนักงานของพนักงาน)
+func (s *SealService) GetSealTransitions(sealNumber string, actor Actor, scope OfficeScope) (*model.Seal, []SealTransitionOption, error) {
+	seal, err := s.repo.FindByNumber(sealNumber)
+	if err != nil {
+		return nil, nil, errors.New("ไม่พบซีลในระบบ")
+	}
+	return seal, availableSealTransitions(seal, actor, scope), nil
 }
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+// -------------------------------------------------------------------
+// Seal stock: ขั้นต่ำซีล 'พร้อมใช้งาน' ต่อสำนักงาน/prefix และแจ้งเตือนเมื่อยอดต่ำกว่าขั้นต่ำ
+// ประเมินใหม่ทุกครั้งที่มีการจ่าย/มอบหมาย/สร้าง/ส่งซีลระหว่างสำนักงาน
+// -------------------------------------------------------------------
+
+var (
+	sealNumberPrefixPattern = regexp.MustCompile(`^([A-Za-z]*)\d+$`) // แยก prefix ตัวอักษรออกจากเลขซีล
+	sealPrefixPattern       = regexp.MustCompile(`^[A-Za-z]*$`)
+)
+
+type SealStockService struct {
+	repo *repository.SealStockRepository
+	db   *gorm.DB
+}
+
+func NewSealStockService(repo *repository.SealStockRepository, db *gorm.DB) *SealStockService {
+	return &SealStockService{repo: repo, db: db}
+}
+
+// LowStockOffice สำนักงาน/prefix ที่ยอดพร้อมใช้งานต่ำกว่าขั้นต่ำอยู่ในขณะนี้
+type LowStockOffice struct {
+	ThresholdID  uint   `json:"threshold_id"`
+	OfficeCode   string `json:"office_code"`
+	Prefix       string `json:"prefix"`
+	MinAvailable int    `json:"min_available"`
+	Available    int64  `json:"available"`
+	Shortage     int64  `json:"shortage"`
+}
+
+// SaveThreshold ตั้งขั้นต่ำของ (สำนักงาน, prefix) แล้วประเมินทันที
+func (s *SealStockService) SaveThreshold(officeCode string, prefix string, minAvailable int, actor Actor) (*model.SealStockThreshold, error) {
+	officeCode = strings.TrimSpace(officeCode)
+	prefix = strings.TrimSpace(prefix)
+	if officeCode == "" {
+		return nil, errors.New("กรุณาระบุสำนักงาน")
+	}
+	if !sealPrefixPattern.MatchString(prefix) {
+		return nil, errors.New("prefix ต้องเป็นตัวอักษรภาษาอังกฤษเท่านั้น")
+	}
+	if minAvailable < 0 {
+		return nil, errors.New("ขั้นต่ำต้องไม่ติดลบ")
+	}
+
+	threshold := &model.SealStockThreshold{
+		OfficeCode:   officeCode,
+		Prefix:       prefix,
+		MinAvailable: minAvailable,
+		UpdatedBy:    actor.ID,
+	}
+	if err := s.repo.SaveThreshold(threshold); err != nil {
+		return nil, err
+	}
+	if err := s.evaluateThreshold(threshold); err != nil {
+		return nil, err
+	}
+	return threshold, nil
+}
+
+// DeleteThreshold ลบขั้นต่ำ และปิดการแจ้งเตือนที่ค้างอยู่
+func (s *SealStockService) DeleteThreshold(id uint) error {
+	if _, err := s.repo.FindThresholdByID(id); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := resolveOpenStockAlerts(tx, id); err != nil {
+			return err
+		}
+		return tx.Delete(&model.SealStockThreshold{}, id).Error
+	})
+}
+
+// GetThresholds ขั้นต่ำของสำนักงานในขอบเขต
+func (s *SealStockService) GetThresholds(scope OfficeScope) ([]model.SealStockThreshold, error) {
+	return s.repo.FindThresholds(scope.Codes())
+}
+
+// GetAlerts ประวัติการแจ้งเตือนของสำนักงานในขอบเขต
+func (s *SealStockService) GetAlerts(status model.SealStockAlertStatus, scope OfficeScope) ([]model.SealStockAlert, error) {
+	return s.repo.FindAlerts(status, scope.Codes())
+}
+
+// GetLowStock สำนักงานที่ยอดพร้อมใช้งานต่ำกว่าขั้นต่ำในขณะนี้ (นับสดจากตารางซีล)
+func (s *SealStockService) GetLowStock(scope OfficeScope) ([]LowStockOffice, error) {
+	thresholds, err := s.repo.FindThresholds(scope.Codes())
+	if err != nil {
+		return nil, err
+	}
+	low := []LowStockOffice{}
+	for _, t := range thresholds {
+		available, err := s.repo.CountAvailable(t.OfficeCode, t.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		if available >= int64(t.MinAvailable) {
+			continue
+		}
+		low = append(low, LowStockOffice{
+			ThresholdID:  t.ID,
+			OfficeCode:   t.OfficeCode,
+			Prefix:       t.Prefix,
+			MinAvailable: t.MinAvailable,
+			Available:    available,
+			Shortage:     int64(t.MinAvailable) - available,
+		})
+	}
+	return low, nil
+}
+
+// Evaluate ประเมินขั้นต่ำทุกรายการที่เกี่ยวกับซีล prefix นี้ของสำนักงาน
+func (s *SealStockService) Evaluate(officeCode string, prefix string) error {
+	thresholds, err := s.repo.FindThresholdsFor(officeCode, prefix)
+	if err != nil {
+		return err
+	}
+	for i := range thresholds {
+		if err := s.evaluateThreshold(&thresholds[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateThreshold ต่ำกว่าขั้นต่ำ -> เปิดการแจ้งเตือน (ถ้ายังไม่มี) พร้อม notification ถึงสำนักงาน
+// กลับมาถึงขั้นต่ำ -> ปิดการแจ้งเตือนที่ค้างอยู่
+func (s *SealStockService) evaluateThreshold(t *model.SealStockThreshold) error {
+	available, err := s.repo.CountAvailable(t.OfficeCode, t.Prefix)
+	if err != nil {
+		return err
+	}
+	open, err := s.repo.FindOpenAlert(t.ID)
+	if err != nil {
+		return err
+	}
+
+	if available >= int64(t.MinAvailable) {
+		if open == nil {
+			return nil
+		}
+		return resolveOpenStockAlerts(s.db, t.ID)
+	}
+	if open != nil {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		alert := model.SealStockAlert{
+			ThresholdID:  t.ID,
+			OfficeCode:   t.OfficeCode,
+			Prefix:       t.Prefix,
+			MinAvailable: t.MinAvailable,
+			Available:    available,
+			Status:       model.SealStockAlertOpen,
+		}
+		if err := tx.Create(&alert).Error; err != nil {
+			return err
+		}
+		notification := model.Notification{
+			OfficeCode: t.OfficeCode,
+			Type:       model.NotificationLowStock,
+			Title:      fmt.Sprintf("ซีลใกล้หมด: สำนักงาน %s", t.OfficeCode),
+			Message: fmt.Sprintf("ซีล%s พร้อมใช้งานเหลือ %d เส้น ต่ำกว่าขั้นต่ำ %d เส้น กรุณาสั่งซื้อเพิ่ม",
+				prefixLabel(t.Prefix), available, t.MinAvailable),
+			ReferenceID: &alert.ID,
+		}
+		return tx.Create(&notification).Error
+	})
+}
+
+func resolveOpenStockAlerts(db *gorm.DB, thresholdID uint) error {
+	return db.Model(&model.SealStockAlert{}).
+		Where("threshold_id = ? AND status = ?", thresholdID, model.SealStockAlertOpen).
+		Updates(map[string]interface{}{"status": model.SealStockAlertResolved, "resolved_at": time.Now()}).Error
+}
+
+func prefixLabel(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	return " prefix " + prefix
+}
+
+// checkSealStock ประเมินขั้นต่ำของทุก (สำนักงาน, prefix) ที่ซีลเหล่านี้สังกัด
+// ถูกเรียกหลังธุรกรรมสำเร็จแล้ว error จึงแค่ log ไว้ ไม่ทำให้การจ่าย/สร้างซีลล้มเหลว
+func (s *SealService) checkSealStock(seals ...model.Seal) {
+	if s.stockService == nil {
+		return
+	}
+	seen := map[string]bool{}
+	for _, seal := range seals {
+		if seal.OfficeCode == "" {
+			continue
+		}
+		prefix := ""
+		if m := sealNumberPrefixPattern.FindStringSubmatch(seal.SealNumber); m != nil {
+			prefix = m[1]
+		}
+		key := seal.OfficeCode + "|" + prefix
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := s.stockService.Evaluate(seal.OfficeCode, prefix); err != nil {
+			log.Println("⚠️ ประเมินขั้นต่ำซีลไม่สำเร็จ:", seal.OfficeCode, prefix, err)
+		}
+	}
+}
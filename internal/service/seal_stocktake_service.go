@@ -0,0 +1,441 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// -------------------------------------------------------------------
+// Stocktakes: ผู้ตรวจนับเปิดรอบของสำนักงาน -> สแกนซีลเป็นชุด -> ปิดรอบ -> รายงานผลต่าง
+// ผลต่างปรับสถานะซีลได้ผ่านตาราง transition (admin) พร้อม log ทุกเส้น
+// -------------------------------------------------------------------
+
+// stocktakeInStoreStatuses สถานะที่ซีลควรอยู่ในคลังของสำนักงาน
+var stocktakeInStoreStatuses = []model.SealStatus{model.SealStatusAvailable, model.SealStatusReserved}
+
+// StocktakeReport รายงานผลต่างของรอบการตรวจนับ
+// รอบที่ยังเปิดอยู่คำนวณสดจากสถานะซีลปัจจุบัน รอบที่ปิดแล้วใช้ผลที่บันทึกไว้ตอนปิด
+type StocktakeReport struct {
+	Stocktake   model.Stocktake           `json:"stocktake"`
+	Expected    int                       `json:"expected"`
+	Scanned     int                       `json:"scanned"`
+	Matched     int                       `json:"matched"`
+	Missing     []model.StocktakeVariance `json:"missing"`
+	Unexpected  []model.StocktakeVariance `json:"unexpected"`
+	WrongStatus []model.StocktakeVariance `json:"wrong_status"`
+}
+
+// StocktakeScanResult ผลการสแกนหนึ่งชุด
+type StocktakeScanResult struct {
+	Accepted  int      `json:"accepted"`
+	Duplicate []string `json:"duplicate"`
+	Invalid   []string `json:"invalid"` // เลขผิดรูปแบบ/เลขตรวจสอบไม่ถูกต้อง ไม่ถูกบันทึก ให้สแกนใหม่
+	Scanned   int64    `json:"scanned"` // จำนวนที่สแกนแล้วทั้งรอบ
+}
+
+// OpenStocktake เปิดรอบตรวจนับของสำนักงาน (สำนักงานละหนึ่งรอบที่เปิดอยู่)
+func (s *SealService) OpenStocktake(office string, actor Actor, remark string) (*model.Stocktake, error) {
+	office = strings.TrimSpace(office)
+	if !actor.IsUser() {
+		return nil, errors.New("เฉพาะเจ้าหน้าที่เท่านั้นที่เปิดรอบตรวจนับได้")
+	}
+	if office == "" {
+		office = actor.Office
+	}
+	if office == "" {
+		return nil, errors.New("กรุณาระบุสำนักงาน")
+	}
+	open, err := s.stocktakeRepo.FindOpenByOffice(office)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		return nil, fmt.Errorf("สำนักงาน %s มีรอบตรวจนับที่ยังเปิดอยู่ (#%d)", office, open.ID)
+	}
+
+	stocktake := &model.Stocktake{
+		OfficeCode: office,
+		Status:     model.StocktakeOpen,
+		Remark:     strings.TrimSpace(remark),
+		OpenedBy:   actor.ID,
+		OpenedAt:   time.Now(),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(stocktake).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("เปิดรอบตรวจนับซีล #%d ของสำนักงาน %s", stocktake.ID, office),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stocktake, nil
+}
+
+// ScanStocktakeSeals บันทึกเลขซีลที่สแกนพบ (สแกนซ้ำนับครั้งเดียว)
+func (s *SealService) ScanStocktakeSeals(stocktakeID uint, sealNumbers []string, actor Actor) (*StocktakeScanResult, error) {
+	if len(sealNumbers) == 0 {
+		return nil, errors.New("กรุณาระบุซีลที่สแกน")
+	}
+	stocktake, err := s.loadOpenStocktake(stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+	scanned, err := s.stocktakeRepo.FindScannedNumbers(stocktake.ID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(scanned))
+	for _, sn := range scanned {
+		seen[sn] = true
+	}
+
+	registry, err := s.formatService.Registry()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StocktakeScanResult{Duplicate: []string{}, Invalid: []string{}}
+	var scans []model.StocktakeScan
+	for _, item := range sealNumbers {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		// แต่ละรายการเป็นเลขเดียวหรือช่วงเลข (นับทั้งกล่อง) รายการที่ผิดไม่กระทบรายการอื่น
+		expanded, err := registry.ExpandRanges(item)
+		if err != nil {
+			result.Invalid = append(result.Invalid, item)
+			continue
+		}
+		for _, sn := range expanded {
+			if seen[sn] {
+				result.Duplicate = append(result.Duplicate, sn)
+				continue
+			}
+			seen[sn] = true
+			scans = append(scans, model.StocktakeScan{StocktakeID: stocktake.ID, SealNumber: sn, ScannedBy: actor.ID})
+		}
+	}
+	if len(scans) > 0 {
+		if err := s.db.Create(&scans).Error; err != nil {
+			return nil, err
+		}
+	}
+	result.Accepted = len(scans)
+	if result.Scanned, err = s.stocktakeRepo.CountScans(stocktake.ID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CloseStocktake ปิดรอบ บันทึกผลต่าง ณ เวลาปิด แล้วคืนรายงาน
+func (s *SealService) CloseStocktake(stocktakeID uint, actor Actor) (*StocktakeReport, error) {
+	stocktake, err := s.loadOpenStocktake(stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+	expected, scanned, variances, err := s.computeStocktakeVariances(stocktake)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	stocktake.Status = model.StocktakeClosed
+	stocktake.ClosedBy = &actor.ID
+	stocktake.ClosedAt = &now
+	stocktake.Expected = expected
+	stocktake.Scanned = scanned
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Variances").Save(stocktake).Error; err != nil {
+			return err
+		}
+		for i := range variances {
+			variances[i].StocktakeID = stocktake.ID
+		}
+		if len(variances) > 0 {
+			if err := tx.Create(&variances).Error; err != nil {
+				return err
+			}
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ปิดรอบตรวจนับซีล #%d ของสำนักงาน %s: ตามระบบ %d สแกนพบ %d ผลต่าง %d รายการ",
+				stocktake.ID, stocktake.OfficeCode, expected, scanned, len(variances)),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	stocktake.Variances = variances
+	return buildStocktakeReport(stocktake, expected, scanned), nil
+}
+
+// GetStocktakeReport รายงานผลต่าง (รอบที่เปิดอยู่ = ผลต่าง ณ ขณะนี้)
+func (s *SealService) GetStocktakeReport(stocktakeID uint) (*StocktakeReport, error) {
+	stocktake, err := s.stocktakeRepo.FindByID(stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+	if stocktake.Status == model.StocktakeClosed {
+		return buildStocktakeReport(stocktake, stocktake.Expected, stocktake.Scanned), nil
+	}
+	expected, scanned, variances, err := s.computeStocktakeVariances(stocktake)
+	if err != nil {
+		return nil, err
+	}
+	stocktake.Variances = variances
+	return buildStocktakeReport(stocktake, expected, scanned), nil
+}
+
+// GetStocktake รอบการตรวจนับหนึ่งรอบ
+func (s *SealService) GetStocktake(stocktakeID uint) (*model.Stocktake, error) {
+	return s.stocktakeRepo.FindByID(stocktakeID)
+}
+
+// GetStocktakes รอบการตรวจนับของสำนักงานในขอบเขต
+func (s *SealService) GetStocktakes(status model.StocktakeStatus, scope OfficeScope) ([]model.Stocktake, error) {
+	return s.stocktakeRepo.FindAll(scope.Codes(), status)
+}
+
+// PostStocktakeAdjustments ปรับสถานะซีลตามผลต่างของรอบที่ปิดแล้ว (admin)
+// ไม่พบในคลัง -> ยืนยันสูญหาย, พบในคลังแต่ระบบว่าจ่ายแล้ว -> ปรับกลับเป็นพร้อมใช้งาน
+// sealNumbers ว่าง = ทุกรายการที่ปรับได้ รายการอื่น (ไม่มีในระบบ/ต่างสำนักงาน/สถานะสุดท้าย) ต้องตรวจสอบเอง
+func (s *SealService) PostStocktakeAdjustments(stocktakeID uint, sealNumbers []string, actor Actor, remark string) ([]model.StocktakeVariance, error) {
+	stocktake, err := s.stocktakeRepo.FindByID(stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+	if stocktake.Status != model.StocktakeClosed {
+		return nil, fmt.Errorf("รอบตรวจนับ #%d ยังไม่ปิด", stocktake.ID)
+	}
+
+	selected := map[string]bool{}
+	for _, sn := range sealNumbers {
+		selected[sn] = true
+	}
+	for sn := range selected {
+		if !stocktakeHasVariance(stocktake, sn) {
+			return nil, fmt.Errorf("ซีล %s ไม่อยู่ในผลต่างของรอบตรวจนับ #%d", sn, stocktake.ID)
+		}
+	}
+
+	now := time.Now()
+	var adjusted []model.StocktakeVariance
+	var changed []model.Seal
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range stocktake.Variances {
+			variance := &stocktake.Variances[i]
+			if variance.Adjusted || variance.SealID == nil {
+				continue
+			}
+			if len(selected) > 0 && !selected[variance.SealNumber] {
+				continue
+			}
+			action, ok := stocktakeAdjustmentAction(variance)
+			if !ok {
+				if len(selected) > 0 {
+					return fmt.Errorf("ซีล %s (%s) ไม่สามารถปรับสถานะอัตโนมัติได้", variance.SealNumber, variance.Kind)
+				}
+				continue
+			}
+
+			var seal model.Seal
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seal, *variance.SealID).Error; err != nil {
+				return fmt.Errorf("ไม่พบซีล %s", variance.SealNumber)
+			}
+			if seal.Status != variance.RecordedStatus {
+				return fmt.Errorf("ซีล %s เปลี่ยนสถานะเป็น '%s' หลังปิดรอบตรวจนับแล้ว", seal.SealNumber, seal.Status.Label())
+			}
+			to, err := checkSealTransition(&seal, action, actor)
+			if err != nil {
+				return err
+			}
+
+			before := seal
+			from := seal.Status
+			seal.Status = to
+			if from == model.SealStatusReserved {
+				clearSealReservation(&seal)
+			}
+			if action == SealActionRestock {
+				seal.AssignedToTechnician = nil
+				seal.IssuedTo = nil
+				seal.EmployeeCode = ""
+				seal.WorkOrderID = nil
+				seal.IssuedBy = nil
+				seal.IssuedAt = nil
+				seal.ReturnedBy = &actor.ID
+				seal.ReturnedAt = &now
+			}
+			if err := saveSeal(tx, &seal); err != nil {
+				return err
+			}
+			if err := recordSealHistory(tx, &before, &seal, action, actor, fmt.Sprintf("รอบตรวจนับ #%d", stocktake.ID)); err != nil {
+				return err
+			}
+
+			variance.Adjusted = true
+			variance.AdjustedAction = string(action)
+			variance.AdjustedBy = &actor.ID
+			variance.AdjustedAt = &now
+			if err := tx.Save(variance).Error; err != nil {
+				return err
+			}
+			logEntry := model.Log{
+				UserID: actor.ID,
+				Action: fmt.Sprintf("%s ซีล %s จาก '%s' เป็น '%s' ตามรอบตรวจนับ #%d - หมายเหตุ: %s",
+					action.Label(), seal.SealNumber, from.Label(), to.Label(), stocktake.ID, remark),
+			}
+			if err := tx.Create(&logEntry).Error; err != nil {
+				return err
+			}
+			adjusted = append(adjusted, *variance)
+			changed = append(changed, seal)
+		}
+		if len(adjusted) == 0 {
+			return errors.New("ไม่มีรายการที่ต้องปรับสถานะ")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.checkSealStock(changed...)
+	return adjusted, nil
+}
+
+// computeStocktakeVariances เทียบซีลในคลังตามระบบกับซีลที่สแกนพบ
+func (s *SealService) computeStocktakeVariances(stocktake *model.Stocktake) (int, int, []model.StocktakeVariance, error) {
+	var expected []model.Seal
+	if err := s.db.Where("office_code = ? AND status IN ?", stocktake.OfficeCode, stocktakeInStoreStatuses).
+		Order("seal_number").Find(&expected).Error; err != nil {
+		return 0, 0, nil, err
+	}
+	scannedNumbers, err := s.stocktakeRepo.FindScannedNumbers(stocktake.ID)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	scanned := make(map[string]bool, len(scannedNumbers))
+	for _, sn := range scannedNumbers {
+		scanned[sn] = true
+	}
+
+	var variances []model.StocktakeVariance
+	expectedNumbers := make(map[string]bool, len(expected))
+	for _, seal := range expected {
+		expectedNumbers[seal.SealNumber] = true
+		if scanned[seal.SealNumber] {
+			continue
+		}
+		sealID := seal.ID
+		variances = append(variances, model.StocktakeVariance{
+			Kind:           model.StocktakeMissing,
+			SealNumber:     seal.SealNumber,
+			SealID:         &sealID,
+			RecordedStatus: seal.Status,
+			RecordedOffice: seal.OfficeCode,
+		})
+	}
+
+	var extra []string
+	for _, sn := range scannedNumbers {
+		if !expectedNumbers[sn] {
+			extra = append(extra, sn)
+		}
+	}
+	found := map[string]model.Seal{}
+	if len(extra) > 0 {
+		var seals []model.Seal
+		if err := s.db.Where("seal_number IN ?", extra).Find(&seals).Error; err != nil {
+			return 0, 0, nil, err
+		}
+		for _, seal := range seals {
+			found[seal.SealNumber] = seal
+		}
+	}
+	for _, sn := range extra {
+		variance := model.StocktakeVariance{Kind: model.StocktakeUnexpected, SealNumber: sn}
+		if seal, ok := found[sn]; ok {
+			sealID := seal.ID
+			variance.SealID = &sealID
+			variance.RecordedStatus = seal.Status
+			variance.RecordedOffice = seal.OfficeCode
+			if seal.OfficeCode == stocktake.OfficeCode {
+				variance.Kind = model.StocktakeWrongStatus
+			}
+		}
+		variances = append(variances, variance)
+	}
+	return len(expected), len(scannedNumbers), variances, nil
+}
+
+func (s *SealService) loadOpenStocktake(stocktakeID uint) (*model.Stocktake, error) {
+	stocktake, err := s.stocktakeRepo.FindByID(stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+	if stocktake.Status != model.StocktakeOpen {
+		return nil, fmt.Errorf("รอบตรวจนับ #%d ปิดไปแล้ว", stocktake.ID)
+	}
+	return stocktake, nil
+}
+
+// stocktakeAdjustmentAction การปรับสถานะที่ใช้กับผลต่างแต่ละประเภท
+func stocktakeAdjustmentAction(variance *model.StocktakeVariance) (SealAction, bool) {
+	switch {
+	case variance.Kind == model.StocktakeMissing:
+		return SealActionMarkLost, true
+	case variance.Kind == model.StocktakeWrongStatus && variance.RecordedStatus == model.SealStatusIssued:
+		return SealActionRestock, true
+	}
+	return "", false
+}
+
+func buildStocktakeReport(stocktake *model.Stocktake, expected int, scanned int) *StocktakeReport {
+	report := &StocktakeReport{
+		Stocktake:   *stocktake,
+		Expected:    expected,
+		Scanned:     scanned,
+		Missing:     []model.StocktakeVariance{},
+		Unexpected:  []model.StocktakeVariance{},
+		WrongStatus: []model.StocktakeVariance{},
+	}
+	for _, variance := range stocktake.Variances {
+		switch variance.Kind {
+		case model.StocktakeMissing:
+			report.Missing = append(report.Missing, variance)
+		case model.StocktakeUnexpected:
+			report.Unexpected = append(report.Unexpected, variance)
+		case model.StocktakeWrongStatus:
+			report.WrongStatus = append(report.WrongStatus, variance)
+		}
+	}
+	report.Matched = expected - len(report.Missing)
+	for _, list := range [][]model.StocktakeVariance{report.Missing, report.Unexpected, report.WrongStatus} {
+		sort.Slice(list, func(i, j int) bool { return list[i].SealNumber < list[j].SealNumber })
+	}
+	report.Stocktake.Variances = nil
+	return report
+}
+
+func stocktakeHasVariance(stocktake *model.Stocktake, sealNumber string) bool {
+	for _, variance := range stocktake.Variances {
+		if variance.SealNumber == sealNumber {
+			return true
+		}
+	}
+	return false
+}
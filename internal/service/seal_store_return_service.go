@@ -0,0 +1,275 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// -------------------------------------------------------------------
+// Store returns: ช่างขอคืนซีลที่ยังไม่ได้ใช้ -> เจ้าหน้าที่คลังตรวจรับซีลจริงแล้วคืนเข้าคลัง
+// -------------------------------------------------------------------
+
+// RequestSealStoreReturn ช่างส่งคำขอคืนซีลที่ตัวเองถืออยู่ (สถานะ 'จ่าย') กลับเข้าคลัง
+func (s *SealService) RequestSealStoreReturn(sealNumbers []string, techID uint, remark string) (*model.SealStoreReturn, error) {
+	if len(sealNumbers) == 0 {
+		return nil, errors.New("กรุณาระบุซีลที่ต้องการคืน")
+	}
+
+	storeReturn := &model.SealStoreReturn{
+		TechnicianID: techID,
+		Status:       model.SealStoreReturnPending,
+		Remark:       strings.TrimSpace(remark),
+	}
+	var sealIDs []uint
+	seen := map[string]bool{}
+	for _, sn := range sealNumbers {
+		if seen[sn] {
+			continue
+		}
+		seen[sn] = true
+
+		seal, err := s.repo.FindByNumber(sn)
+		if err != nil {
+			return nil, fmt.Errorf("ซีล %s ไม่พบในระบบ", sn)
+		}
+		if seal.Status != model.SealStatusIssued {
+			return nil, fmt.Errorf("ซีล %s อยู่ในสถานะ '%s' ไม่สามารถคืนคลังได้", sn, seal.Status.Label())
+		}
+		if seal.AssignedToTechnician == nil || *seal.AssignedToTechnician != techID {
+			return nil, fmt.Errorf("ซีล %s ไม่ได้อยู่กับคุณ", sn)
+		}
+		sealIDs = append(sealIDs, seal.ID)
+		storeReturn.Items = append(storeReturn.Items, model.SealStoreReturnItem{
+			SealID:     seal.ID,
+			SealNumber: seal.SealNumber,
+		})
+	}
+
+	// ซีลที่อยู่ระหว่างโอนหรืออยู่ในคำขอคืนอื่นแล้ว ห้ามขอคืนซ้ำ
+	if err := s.checkSealsNotInHandover(sealIDs); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(storeReturn).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: techID,
+			Action: fmt.Sprintf("ช่าง ID %d ขอคืนซีล %s เข้าคลัง (คำขอคืน #%d)", techID, joinReturnItems(storeReturn.Items), storeReturn.ID),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storeReturn, nil
+}
+
+// ConfirmSealStoreReturn เจ้าหน้าที่คลังตรวจรับซีลจริง
+// เฉพาะซีลใน receivedSealNumbers เท่านั้นที่กลับเป็น 'พร้อมใช้งาน' และล้างข้อมูลผู้ถือ
+// ซีลที่ไม่ได้รับจริงยังคงอยู่กับช่างตามเดิม
+func (s *SealService) ConfirmSealStoreReturn(returnID uint, receivedSealNumbers []string, actor Actor, remark string) (*model.SealStoreReturn, error) {
+	if !actor.IsUser() {
+		return nil, errors.New("เฉพาะเจ้าหน้าที่คลังเท่านั้นที่ตรวจรับซีลได้")
+	}
+	if len(receivedSealNumbers) == 0 {
+		return nil, errors.New("กรุณาระบุซีลที่ได้รับจริง")
+	}
+	storeReturn, err := s.loadPendingStoreReturn(returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	received := map[string]bool{}
+	for _, sn := range receivedSealNumbers {
+		received[sn] = true
+	}
+	for sn := range received {
+		if !storeReturnHasSeal(storeReturn, sn) {
+			return nil, fmt.Errorf("ซีล %s ไม่อยู่ในคำขอคืน #%d", sn, storeReturn.ID)
+		}
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var checkedIn, missing []string
+		for i := range storeReturn.Items {
+			item := &storeReturn.Items[i]
+			if !received[item.SealNumber] {
+				missing = append(missing, item.SealNumber)
+				continue
+			}
+
+			var seal model.Seal
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seal, item.SealID).Error; err != nil {
+				return fmt.Errorf("ไม่พบซีล %s", item.SealNumber)
+			}
+			if seal.AssignedToTechnician == nil || *seal.AssignedToTechnician != storeReturn.TechnicianID {
+				return fmt.Errorf("ซีล %s ไม่ได้อยู่กับช่างผู้ขอคืนแล้ว", seal.SealNumber)
+			}
+			to, err := checkSealTransition(&seal, SealActionCancel, actor)
+			if err != nil {
+				return err
+			}
+
+			before := seal
+			seal.Status = to
+			seal.AssignedToTechnician = nil
+			seal.IssuedTo = nil
+			seal.EmployeeCode = ""
+			seal.WorkOrderID = nil
+			seal.IssuedBy = nil
+			seal.IssuedAt = nil
+			seal.ReturnedBy = &actor.ID
+			seal.ReturnedAt = &now
+			if err := saveSeal(tx, &seal); err != nil {
+				return err
+			}
+			if err := recordSealHistory(tx, &before, &seal, SealActionCancel, actor, fmt.Sprintf("คำขอคืน #%d", storeReturn.ID)); err != nil {
+				return err
+			}
+
+			item.CheckedIn = true
+			if err := tx.Save(item).Error; err != nil {
+				return err
+			}
+			checkedIn = append(checkedIn, seal.SealNumber)
+		}
+
+		storeReturn.Status = model.SealStoreReturnConfirmed
+		storeReturn.ConfirmedBy = &actor.ID
+		storeReturn.ConfirmedAt = &now
+		storeReturn.ConfirmRemark = remark
+		if err := tx.Omit("Items").Save(storeReturn).Error; err != nil {
+			return err
+		}
+
+		action := fmt.Sprintf("ตรวจรับซีลคืนคลัง %s จากช่าง ID %d (คำขอคืน #%d)",
+			strings.Join(checkedIn, ", "), storeReturn.TechnicianID, storeReturn.ID)
+		if len(missing) > 0 {
+			action += fmt.Sprintf(" - ไม่ได้รับซีล %s ยังอยู่กับช่าง", strings.Join(missing, ", "))
+		}
+		logEntry := model.Log{UserID: actor.ID, Action: action}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storeReturn, nil
+}
+
+// RejectSealStoreReturn เจ้าหน้าที่คลังปฏิเสธทั้งคำขอ ซีลทั้งหมดยังอยู่กับช่าง
+func (s *SealService) RejectSealStoreReturn(returnID uint, actor Actor, remark string) (*model.SealStoreReturn, error) {
+	if !actor.IsUser() {
+		return nil, errors.New("เฉพาะเจ้าหน้าที่คลังเท่านั้นที่ปฏิเสธคำขอคืนได้")
+	}
+	storeReturn, err := s.loadPendingStoreReturn(returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	storeReturn.Status = model.SealStoreReturnRejected
+	storeReturn.ConfirmedBy = &actor.ID
+	storeReturn.ConfirmedAt = &now
+	storeReturn.ConfirmRemark = remark
+	err = s.saveStoreReturnWithLog(storeReturn, actor.ID,
+		fmt.Sprintf("ปฏิเสธคำขอคืนซีล #%d ของช่าง ID %d - หมายเหตุ: %s", storeReturn.ID, storeReturn.TechnicianID, remark))
+	if err != nil {
+		return nil, err
+	}
+	return storeReturn, nil
+}
+
+// CancelSealStoreReturn ช่างยกเลิกคำขอคืนของตัวเองก่อนคลังตรวจรับ
+func (s *SealService) CancelSealStoreReturn(returnID uint, techID uint) (*model.SealStoreReturn, error) {
+	storeReturn, err := s.loadPendingStoreReturn(returnID)
+	if err != nil {
+		return nil, err
+	}
+	if storeReturn.TechnicianID != techID {
+		return nil, errors.New("คุณไม่มีสิทธิ์ยกเลิกคำขอคืนนี้")
+	}
+
+	storeReturn.Status = model.SealStoreReturnCancelled
+	err = s.saveStoreReturnWithLog(storeReturn, techID,
+		fmt.Sprintf("ช่าง ID %d ยกเลิกคำขอคืนซีล #%d", techID, storeReturn.ID))
+	if err != nil {
+		return nil, err
+	}
+	return storeReturn, nil
+}
+
+// GetTechnicianSealStoreReturns คำขอคืนของช่าง
+func (s *SealService) GetTechnicianSealStoreReturns(techID uint, status model.SealStoreReturnStatus) ([]model.SealStoreReturn, error) {
+	return s.storeReturnRepo.FindByTechnician(techID, status)
+}
+
+// GetSealStoreReturns คำขอคืนทั้งหมด (ฝั่งคลัง) เฉพาะช่างของสำนักงานในขอบเขต
+func (s *SealService) GetSealStoreReturns(status model.SealStoreReturnStatus, scope OfficeScope) ([]model.SealStoreReturn, error) {
+	return s.storeReturnRepo.FindByStatus(status, scope.Codes())
+}
+
+// checkSealsNotInHandover กันซีลที่อยู่ระหว่างโอนให้ช่างอื่นหรือรอคลังตรวจรับ
+func (s *SealService) checkSealsNotInHandover(sealIDs []uint) error {
+	transfers, err := s.transferRepo.FindPendingBySeals(sealIDs)
+	if err != nil {
+		return err
+	}
+	if len(transfers) > 0 {
+		return fmt.Errorf("ซีล %s มีรายการโอนที่รอยืนยันอยู่แล้ว (#%d)", transfers[0].SealNumber, transfers[0].ID)
+	}
+	items, err := s.storeReturnRepo.FindPendingItemsBySeals(sealIDs)
+	if err != nil {
+		return err
+	}
+	if len(items) > 0 {
+		return fmt.Errorf("ซีล %s อยู่ในคำขอคืนคลังที่รอตรวจรับ (#%d)", items[0].SealNumber, items[0].ReturnID)
+	}
+	return nil
+}
+
+func (s *SealService) loadPendingStoreReturn(returnID uint) (*model.SealStoreReturn, error) {
+	storeReturn, err := s.storeReturnRepo.FindByID(returnID)
+	if err != nil {
+		return nil, err
+	}
+	if storeReturn.Status != model.SealStoreReturnPending {
+		return nil, fmt.Errorf("คำขอคืน #%d ถูกดำเนินการไปแล้ว (%s)", storeReturn.ID, storeReturn.Status)
+	}
+	return storeReturn, nil
+}
+
+func (s *SealService) saveStoreReturnWithLog(storeReturn *model.SealStoreReturn, userID uint, action string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Save(storeReturn).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{UserID: userID, Action: action}
+		return tx.Create(&logEntry).Error
+	})
+}
+
+func storeReturnHasSeal(storeReturn *model.SealStoreReturn, sealNumber string) bool {
+	for _, item := range storeReturn.Items {
+		if item.SealNumber == sealNumber {
+			return true
+		}
+	}
+	return false
+}
+
+func joinReturnItems(items []model.SealStoreReturnItem) string {
+	numbers := make([]string, 0, len(items))
+	for _, item := range items {
+		numbers = append(numbers, item.SealNumber)
+	}
+	return strings.Join(numbers, ", ")
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// -------------------------------------------------------------------
+// Seal transfers: โอนซีลที่จ่ายแล้วระหว่างช่าง ผู้รับต้องกดยอมรับก่อนจึงย้ายผู้ถือซีล
+// -------------------------------------------------------------------
+
+// checkSealAssignment ตรวจตามตาราง transition และกันไม่ให้ assign ทับช่างคนอื่นแบบเงียบ ๆ
+// (ซีลที่จ่ายให้ช่างคนอื่นอยู่แล้วต้องใช้การโอนซีลแทน ซีลที่จองไว้ต้องเป็นช่างที่จองไว้ให้)
+func checkSealAssignment(seal *model.Seal, techID uint, actor Actor) (model.SealStatus, error) {
+	if seal.Status == model.SealStatusIssued && seal.AssignedToTechnician != nil && *seal.AssignedToTechnician != techID {
+		return "", fmt.Errorf("ซีล %s ถูกจ่ายให้ช่าง ID %d อยู่แล้ว กรุณาใช้การโอนซีล", seal.SealNumber, *seal.AssignedToTechnician)
+	}
+	if err := checkSealReservation(seal, techID); err != nil {
+		return "", err
+	}
+	return checkSealTransition(seal, SealActionAssign, actor)
+}
+
+// InitiateSealTransfer เริ่มโอนซีลไปยังช่างรหัส toTechCode (ผู้ถือซีลหรือ admin เป็นผู้เริ่ม)
+// ทุกซีลต้องผ่านการตรวจ มิฉะนั้นจะไม่สร้างรายการโอนเลย
+func (s *SealService) InitiateSealTransfer(sealNumbers []string, toTechCode string, actor Actor, remark string) ([]model.SealTransfer, error) {
+	if len(sealNumbers) == 0 {
+		return nil, errors.New("กรุณาระบุซีลที่ต้องการโอน")
+	}
+	receiver, err := s.technicianRepo.FindByTechCode(toTechCode)
+	if err != nil {
+		return nil, fmt.Errorf("ไม่พบช่างที่มีรหัส %s", toTechCode)
+	}
+
+	var transfers []model.SealTransfer
+	var sealIDs []uint
+	for _, sn := range sealNumbers {
+		seal, err := s.repo.FindByNumber(sn)
+		if err != nil {
+			return nil, fmt.Errorf("ซีล %s ไม่พบในระบบ", sn)
+		}
+		if _, err := checkSealTransition(seal, SealActionTransfer, actor); err != nil {
+			return nil, err
+		}
+		if seal.AssignedToTechnician == nil {
+			return nil, fmt.Errorf("ซีล %s ยังไม่มีช่างผู้ถือ ใช้การมอบหมายแทน", sn)
+		}
+		if *seal.AssignedToTechnician == receiver.ID {
+			return nil, fmt.Errorf("ซีล %s อยู่กับช่างรหัส %s อยู่แล้ว", sn, toTechCode)
+		}
+		sealIDs = append(sealIDs, seal.ID)
+		transfers = append(transfers, model.SealTransfer{
+			SealID:           seal.ID,
+			SealNumber:       seal.SealNumber,
+			FromTechnicianID: *seal.AssignedToTechnician,
+			ToTechnicianID:   receiver.ID,
+			InitiatedBy:      actor.ID,
+			InitiatorType:    string(actor.Type),
+			Status:           model.SealTransferPending,
+			Remark:           remark,
+		})
+	}
+
+	if err := s.checkSealsNotInHandover(sealIDs); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&transfers).Error; err != nil {
+			return err
+		}
+		for _, t := range transfers {
+			logEntry := model.Log{
+				UserID: actor.ID,
+				Action: fmt.Sprintf("เริ่มโอนซีล %s จากช่าง ID %d ไปยังช่าง ID %d (รายการโอน #%d) - หมายเหตุ: %s",
+					t.SealNumber, t.FromTechnicianID, t.ToTechnicianID, t.ID, remark),
+			}
+			if err := tx.Create(&logEntry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// AcceptSealTransfers ช่างผู้รับยอมรับรายการโอน -> ย้ายผู้ถือซีลมาเป็นตัวเอง
+func (s *SealService) AcceptSealTransfers(transferIDs []uint, actor Actor, remark string) ([]model.SealTransfer, error) {
+	return s.respondSealTransfers(transferIDs, actor, model.SealTransferAccepted, remark)
+}
+
+// RejectSealTransfers ช่างผู้รับปฏิเสธ ซีลยังอยู่กับผู้ส่งเหมือนเดิม
+func (s *SealService) RejectSealTransfers(transferIDs []uint, actor Actor, remark string) ([]model.SealTransfer, error) {
+	return s.respondSealTransfers(transferIDs, actor, model.SealTransferRejected, remark)
+}
+
+func (s *SealService) respondSealTransfers(transferIDs []uint, actor Actor, decision model.SealTransferStatus, remark string) ([]model.SealTransfer, error) {
+	if len(transferIDs) == 0 {
+		return nil, errors.New("กรุณาระบุรายการโอน")
+	}
+	if !actor.IsTechnician() {
+		return nil, errors.New("เฉพาะช่างผู้รับเท่านั้นที่ตอบรับการโอนได้")
+	}
+
+	var responded []model.SealTransfer
+	now := time.Now()
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range transferIDs {
+			var transfer model.SealTransfer
+			if err := tx.First(&transfer, id).Error; err != nil {
+				return fmt.Errorf("ไม่พบรายการโอน #%d", id)
+			}
+			if transfer.ToTechnicianID != actor.ID {
+				return fmt.Errorf("รายการโอน #%d ไม่ได้ส่งถึงคุณ", id)
+			}
+			if transfer.Status != model.SealTransferPending {
+				return fmt.Errorf("รายการโอน #%d ถูกดำเนินการไปแล้ว (%s)", id, transfer.Status)
+			}
+
+			action := fmt.Sprintf("ช่าง ID %d ปฏิเสธการรับโอนซีล %s จากช่าง ID %d (รายการโอน #%d)",
+				actor.ID, transfer.SealNumber, transfer.FromTechnicianID, transfer.ID)
+
+			if decision == model.SealTransferAccepted {
+				var seal model.Seal
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seal, transfer.SealID).Error; err != nil {
+					return fmt.Errorf("ไม่พบซีล %s", transfer.SealNumber)
+				}
+				// สถานะซีลอาจเปลี่ยนไประหว่างรอ (ติดตั้ง/คืน/แจ้งสูญหาย) ต้องตรวจซ้ำตอนรับ
+				if seal.Status != model.SealStatusIssued {
+					return fmt.Errorf("ซีล %s อยู่ในสถานะ '%s' ไม่สามารถรับโอนได้", seal.SealNumber, seal.Status.Label())
+				}
+				if seal.AssignedToTechnician == nil || *seal.AssignedToTechnician != transfer.FromTechnicianID {
+					return fmt.Errorf("ซีล %s ไม่ได้อยู่กับช่างผู้ส่งแล้ว", seal.SealNumber)
+				}
+				before := seal
+				seal.AssignedToTechnician = &transfer.ToTechnicianID
+				if err := saveSeal(tx, &seal); err != nil {
+					return err
+				}
+				if err := recordSealHistory(tx, &before, &seal, SealActionTransfer, actor, fmt.Sprintf("รายการโอน #%d", transfer.ID)); err != nil {
+					return err
+				}
+				action = fmt.Sprintf("ช่าง ID %d รับโอนซีล %s จากช่าง ID %d แล้ว (รายการโอน #%d เริ่ม %s)",
+					actor.ID, transfer.SealNumber, transfer.FromTechnicianID, transfer.ID, transfer.CreatedAt.Format(time.RFC3339))
+			}
+
+			transfer.Status = decision
+			transfer.RespondedAt = &now
+			transfer.ResponseRemark = remark
+			if err := tx.Save(&transfer).Error; err != nil {
+				return err
+			}
+			logEntry := model.Log{UserID: actor.ID, Action: action}
+			if err := tx.Create(&logEntry).Error; err != nil {
+				return err
+			}
+			responded = append(responded, transfer)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return responded, nil
+}
+
+// CancelSealTransfer ผู้ส่งหรือ admin ยกเลิกรายการโอนที่ผู้รับยังไม่ตอบ
+func (s *SealService) CancelSealTransfer(transferID uint, actor Actor) (*model.SealTransfer, error) {
+	transfer, err := s.transferRepo.FindByID(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != model.SealTransferPending {
+		return nil, fmt.Errorf("รายการโอน #%d ถูกดำเนินการไปแล้ว (%s)", transfer.ID, transfer.Status)
+	}
+	if !actor.IsAdmin() && !(actor.IsTechnician() && actor.ID == transfer.FromTechnicianID) {
+		return nil, errors.New("คุณไม่มีสิทธิ์ยกเลิกรายการโอนนี้")
+	}
+
+	now := time.Now()
+	transfer.Status = model.SealTransferCancelled
+	transfer.RespondedAt = &now
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(transfer).Error; err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: actor.ID,
+			Action: fmt.Sprintf("ยกเลิกการโอนซีล %s (รายการโอน #%d)", transfer.SealNumber, transfer.ID),
+		}
+		return tx.Create(&logEntry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetTechnicianSealTransfers รายการโอนของช่าง (incoming = ที่ส่งถึงตัวเอง)
+func (s *SealService) GetTechnicianSealTransfers(techID uint, incoming bool, status model.SealTransferStatus) ([]model.SealTransfer, error) {
+	return s.transferRepo.FindByTechnician(techID, incoming, status)
+}
+
+// GetSealTransfers รายการโอนทั้งหมด (ฝั่ง admin)
+func (s *SealService) GetSealTransfers(status model.SealTransferStatus) ([]model.SealTransfer, error) {
+	return s.transferRepo.FindByStatus(status)
+}
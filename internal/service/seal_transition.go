@@ -0,0 +1,302 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+)
+
+// -------------------------------------------------------------------
+// Seal lifecycle: ตารางการเปลี่ยนสถานะของซีล (ทุก flow ต้องผ่านตารางนี้)
+// -------------------------------------------------------------------
+
+// SealAction คือการกระทำที่ทำให้สถานะซีลเปลี่ยน
+type SealAction string
+
+const (
+	SealActionIssue   SealAction = "issue"   // จ่ายซีล
+	SealActionAssign  SealAction = "assign"  // มอบหมายซีลให้ช่าง
+	SealActionInstall SealAction = "install" // ติดตั้งซีล
+	SealActionReturn  SealAction = "return"  // บันทึกว่าใช้งานแล้ว
+	SealActionCancel  SealAction = "cancel"  // คืนซีลกลับคลัง
+	SealActionRemove  SealAction = "remove"  // ตัดซีลออกจากมิเตอร์ (เปลี่ยนมิเตอร์/เปิดซ่อม)
+
+	SealActionTransfer SealAction = "transfer" // โอนซีลที่จ่ายแล้วให้ช่างคนอื่น (รอผู้รับยืนยัน)
+
+	SealActionDispatch SealAction = "dispatch" // ส่งซีลไปสำนักงานอื่น
+	SealActionReceive  SealAction = "receive"  // สำนักงานปลายทางตรวจรับ
+	SealActionRecall   SealAction = "recall"   // สำนักงานต้นทางเรียกคืนก่อนปลายทางรับ
+
+	SealActionReserve SealAction = "reserve" // จองซีลไว้ให้ช่างสำหรับงานล่วงหน้า
+	SealActionRelease SealAction = "release" // ปล่อยการจอง (ผู้จอง/admin หรือหมดเวลาอัตโนมัติ)
+
+	SealActionRestock SealAction = "restock" // ตรวจนับพบซีลที่จ่ายไปแล้วอยู่ในคลัง ปรับกลับเป็นพร้อมใช้งาน
+
+	SealActionMarkLost    SealAction = "mark_lost"    // admin ยืนยันว่าซีลสูญหาย
+	SealActionMarkDamaged SealAction = "mark_damaged" // admin ยืนยันว่าซีลชำรุด
+	SealActionVoid        SealAction = "void"         // admin ยกเลิกซีลที่ยังไม่ถูกติดตั้ง
+
+	// ไม่อยู่ในตาราง transition: คืนสภาพซีลก่อนรายการล่าสุด ต้องผ่านการอนุมัติของ admin คนที่สอง
+	SealActionReverse SealAction = "reverse"
+
+	// ไม่อยู่ในตาราง transition: ซีลเข้าระบบครั้งแรก (บันทึกเฉพาะในสมุดบัญชี Transaction)
+	SealActionCreate SealAction = "create"
+
+	// ไม่อยู่ในตาราง transition: admin ปรับแถวซีลให้ตรงกับผลเล่นซ้ำสมุดบัญชี (ดู CheckSealLedger)
+	SealActionReconcile SealAction = "reconcile"
+)
+
+// ActorType แยกผู้ใช้ PEA (JWTMiddleware) กับช่าง (TechnicianJWTMiddleware)
+type ActorType string
+
+const (
+	ActorTypeUser       ActorType = "user"
+	ActorTypeTechnician ActorType = "technician"
+	ActorTypeSystem     ActorType = "system" // งานเบื้องหลัง เช่น ตัวปล่อยการจองที่หมดเวลา
+)
+
+// Actor คือผู้ที่เรียกใช้งาน ใช้ตัดสินสิทธิ์ในการเปลี่ยนสถานะ
+// Office คือรหัสกฟฟ. (pea_code) ของพนักงาน ช่างไม่มีสำนักงาน
+type Actor struct {
+	ID     uint
+	Type   ActorType
+	Role   string
+	Office string
+}
+
+// UserActor สร้าง Actor ของพนักงาน PEA
+func UserActor(userID uint, role string) Actor {
+	return Actor{ID: userID, Type: ActorTypeUser, Role: role}
+}
+
+// TechnicianActor สร้าง Actor ของช่าง
+func TechnicianActor(techID uint) Actor {
+	return Actor{ID: techID, Type: ActorTypeTechnician, Role: "technician"}
+}
+
+// SystemActor สร้าง Actor ของงานเบื้องหลังของระบบ (ID = 0)
+func SystemActor() Actor {
+	return Actor{Type: ActorTypeSystem, Role: "system"}
+}
+
+// WithOffice คืน Actor ที่ระบุสำนักงาน
+func (a Actor) WithOffice(office string) Actor {
+	a.Office = office
+	return a
+}
+
+func (a Actor) IsUser() bool       { return a.Type == ActorTypeUser }
+func (a Actor) IsTechnician() bool { return a.Type == ActorTypeTechnician }
+func (a Actor) IsAdmin() bool      { return a.IsUser() && a.Role == "admin" }
+func (a Actor) IsSystem() bool     { return a.Type == ActorTypeSystem }
+
+// sealTransition หนึ่งแถวในตาราง: action จากสถานะ From ไปสถานะ To โดยผู้ที่ allow อนุญาต
+type sealTransition struct {
+	Action SealAction
+	From   model.SealStatus
+	To     model.SealStatus
+	allow  func(seal *model.Seal, actor Actor) bool
+}
+
+var sealActionLabels = map[SealAction]string{
+	SealActionIssue:   "จ่าย",
+	SealActionAssign:  "มอบหมาย",
+	SealActionInstall: "ติดตั้ง",
+	SealActionReturn:  "บันทึกว่าใช้งานแล้ว",
+	SealActionCancel:  "คืน",
+	SealActionRemove:  "ถอด",
+
+	SealActionTransfer: "โอน",
+
+	SealActionDispatch: "ส่งไปสำนักงานอื่น",
+	SealActionReceive:  "รับจากสำนักงานอื่น",
+	SealActionRecall:   "เรียกคืนการส่ง",
+
+	SealActionReserve: "จอง",
+	SealActionRelease: "ปล่อยการจอง",
+
+	SealActionRestock: "ปรับยอดจากการตรวจนับ",
+
+	SealActionMarkLost:    "ยืนยันสูญหาย",
+	SealActionMarkDamaged: "ยืนยันชำรุด",
+	SealActionVoid:        "ยกเลิกใช้งาน",
+
+	SealActionReverse: "ย้อนกลับรายการ",
+
+	SealActionCreate:    "สร้าง",
+	SealActionReconcile: "ปรับตามสมุดบัญชี",
+}
+
+var sealTransitions = []sealTransition{
+	{SealActionIssue, model.SealStatusAvailable, model.SealStatusIssued, allowUser},
+	{SealActionAssign, model.SealStatusAvailable, model.SealStatusIssued, allowUser},
+	{SealActionAssign, model.SealStatusIssued, model.SealStatusIssued, allowUser},
+	{SealActionInstall, model.SealStatusIssued, model.SealStatusInstalled, allowUserOrAssignedTechnician},
+	{SealActionReturn, model.SealStatusInstalled, model.SealStatusUsed, allowUserOrInstallingTechnician},
+	{SealActionCancel, model.SealStatusIssued, model.SealStatusAvailable, allowUser},
+	{SealActionRemove, model.SealStatusInstalled, model.SealStatusUsed, allowUserOrTechnician},
+	{SealActionTransfer, model.SealStatusIssued, model.SealStatusIssued, allowAdminOrAssignedTechnician},
+	{SealActionDispatch, model.SealStatusAvailable, model.SealStatusInTransit, allowOfficeStaff},
+	{SealActionReceive, model.SealStatusInTransit, model.SealStatusAvailable, allowUser}, // สำนักงานปลายทางตรวจใน service
+	{SealActionRecall, model.SealStatusInTransit, model.SealStatusAvailable, allowOfficeStaff},
+	{SealActionReserve, model.SealStatusAvailable, model.SealStatusReserved, allowOfficeStaff},
+	{SealActionRelease, model.SealStatusReserved, model.SealStatusAvailable, allowReserverOrAdmin},
+	// ซีลที่จองไว้จ่าย/มอบหมายได้เฉพาะให้ช่างที่จองไว้ (ตรวจใน service ด้วย checkSealReservation)
+	{SealActionIssue, model.SealStatusReserved, model.SealStatusIssued, allowUser},
+	{SealActionAssign, model.SealStatusReserved, model.SealStatusIssued, allowUser},
+	{SealActionRestock, model.SealStatusIssued, model.SealStatusAvailable, allowAdmin},
+
+	// สูญหาย / ชำรุด / ยกเลิก เป็นสถานะสุดท้าย ไม่มีแถวใดออกจากสถานะเหล่านี้ จึงจ่ายซ้ำไม่ได้
+	{SealActionMarkLost, model.SealStatusAvailable, model.SealStatusLost, allowAdmin},
+	{SealActionMarkLost, model.SealStatusReserved, model.SealStatusLost, allowAdmin},
+	{SealActionMarkLost, model.SealStatusIssued, model.SealStatusLost, allowAdmin},
+	{SealActionMarkLost, model.SealStatusInstalled, model.SealStatusLost, allowAdmin},
+	{SealActionMarkDamaged, model.SealStatusAvailable, model.SealStatusDamaged, allowAdmin},
+	{SealActionMarkDamaged, model.SealStatusIssued, model.SealStatusDamaged, allowAdmin},
+	{SealActionMarkDamaged, model.SealStatusInstalled, model.SealStatusDamaged, allowAdmin},
+	{SealActionVoid, model.SealStatusAvailable, model.SealStatusVoid, allowAdmin},
+	{SealActionVoid, model.SealStatusIssued, model.SealStatusVoid, allowAdmin},
+	{SealActionVoid, model.SealStatusReserved, model.SealStatusVoid, allowAdmin},
+}
+
+func allowUser(_ *model.Seal, actor Actor) bool {
+	return actor.IsUser()
+}
+
+// allowUserOrTechnician ช่างคนใดก็ถอดซีลได้ (ช่างที่เปิดซ่อมอาจไม่ใช่คนที่ติดตั้ง)
+func allowUserOrTechnician(_ *model.Seal, actor Actor) bool {
+	return actor.IsUser() || actor.IsTechnician()
+}
+
+func allowAdmin(_ *model.Seal, actor Actor) bool {
+	return actor.IsAdmin()
+}
+
+func allowUserOrAssignedTechnician(seal *model.Seal, actor Actor) bool {
+	if actor.IsUser() {
+		return true
+	}
+	return seal.AssignedToTechnician != nil && *seal.AssignedToTechnician == actor.ID
+}
+
+// allowAdminOrAssignedTechnician เฉพาะช่างผู้ถือซีลอยู่ หรือ admin
+func allowAdminOrAssignedTechnician(seal *model.Seal, actor Actor) bool {
+	if actor.IsAdmin() {
+		return true
+	}
+	return actor.IsTechnician() && seal.AssignedToTechnician != nil && *seal.AssignedToTechnician == actor.ID
+}
+
+// allowOfficeStaff พนักงานของสำนักงานเจ้าของซีล หรือ admin (ซีลที่ยังไม่ระบุสำนักงานเฉพาะ admin)
+func allowOfficeStaff(seal *model.Seal, actor Actor) bool {
+	if actor.IsAdmin() {
+		return true
+	}
+	return actor.IsUser() && seal.OfficeCode != "" && seal.OfficeCode == actor.Office
+}
+
+// allowReserverOrAdmin ผู้จองเอง, admin หรือระบบ (ปล่อยการจองที่หมดเวลา)
+func allowReserverOrAdmin(seal *model.Seal, actor Actor) bool {
+	if actor.IsAdmin() || actor.IsSystem() {
+		return true
+	}
+	return actor.IsUser() && seal.ReservedBy != nil && *seal.ReservedBy == actor.ID
+}
+
+func allowUserOrInstallingTechnician(seal *model.Seal, actor Actor) bool {
+	if actor.IsUser() {
+		return true
+	}
+	return seal.UsedBy != nil && *seal.UsedBy == actor.ID
+}
+
+// Label คืนชื่อ action ภาษาไทย
+func (a SealAction) Label() string {
+	if label, ok := sealActionLabels[a]; ok {
+		return label
+	}
+	return string(a)
+}
+
+// findSealTransition หาแถวในตารางที่ตรงกับ action และสถานะปัจจุบัน
+func findSealTransition(action SealAction, from model.SealStatus) (*sealTransition, bool) {
+	for i := range sealTransitions {
+		if sealTransitions[i].Action == action && sealTransitions[i].From == from {
+			return &sealTransitions[i], true
+		}
+	}
+	return nil, false
+}
+
+// checkSealTransition ตรวจว่า actor ทำ action กับซีลนี้ได้หรือไม่ แล้วคืนสถานะปลายทาง
+func checkSealTransition(seal *model.Seal, action SealAction, actor Actor) (model.SealStatus, error) {
+	current, ok := model.ParseSealStatus(string(seal.Status))
+	if !ok {
+		return "", fmt.Errorf("ซีล %s มีสถานะไม่ถูกต้อง ('%s')", seal.SealNumber, seal.Status)
+	}
+	t, ok := findSealTransition(action, current)
+	if !ok {
+		return "", fmt.Errorf("ซีล %s อยู่ในสถานะ '%s' ไม่สามารถ%sได้", seal.SealNumber, current.Label(), action.Label())
+	}
+	if !t.allow(seal, actor) {
+		return "", fmt.Errorf("คุณไม่มีสิทธิ์%sซีลนี้", action.Label())
+	}
+	return t.To, nil
+}
+
+// SealTransitionOption คือ action ถัดไปที่ผู้เรียกทำได้ (ส่งออกทาง API)
+// RecipientID = ทำได้เฉพาะกับช่างคนนี้ (ซีลที่จองไว้ให้ช่าง หรือมอบหมายซ้ำให้ช่างผู้ถือซีลอยู่)
+type SealTransitionOption struct {
+	Action        SealAction       `json:"action"`
+	ActionLabel   string           `json:"action_label"`
+	ToStatus      model.SealStatus `json:"to_status"`
+	ToStatusLabel string           `json:"to_status_label"`
+	RecipientID   *uint            `json:"recipient_id,omitempty"`
+}
+
+// availableSealTransitions คืนรายการ action ที่ actor ทำกับซีลนี้ได้ในตอนนี้
+// ใช้การตรวจเดียวกับ flow ที่บันทึกจริง: ตาราง transition, ขอบเขตสำนักงานของพนักงาน (checkSealInScope)
+// และการจอง/ผู้ถือซีล (checkSealReservation / checkSealAssignment)
+func availableSealTransitions(seal *model.Seal, actor Actor, scope OfficeScope) []SealTransitionOption {
+	options := []SealTransitionOption{}
+	current, ok := model.ParseSealStatus(string(seal.Status))
+	if !ok {
+		return options
+	}
+	if actor.IsUser() && checkSealInScope(scope, seal) != nil {
+		return options
+	}
+	for _, t := range sealTransitions {
+		if t.From != current || !t.allow(seal, actor) {
+			continue
+		}
+		recipient, ok := sealTransitionRecipient(seal, t.Action)
+		if !ok {
+			continue
+		}
+		options = append(options, SealTransitionOption{
+			Action:        t.Action,
+			ActionLabel:   t.Action.Label(),
+			ToStatus:      t.To,
+			ToStatusLabel: t.To.Label(),
+			RecipientID:   recipient,
+		})
+	}
+	return options
+}
+
+// sealTransitionRecipient ช่างผู้รับเพียงคนเดียวที่จ่าย/มอบหมายซีลนี้ได้ (nil = ช่างคนใดก็ได้)
+// ok = false เมื่อไม่มีช่างคนใดรับได้ (จองไว้โดยไม่ระบุช่าง)
+func sealTransitionRecipient(seal *model.Seal, action SealAction) (*uint, bool) {
+	if action != SealActionIssue && action != SealActionAssign {
+		return nil, true
+	}
+	if sealReservationActive(seal) {
+		return seal.ReservedFor, seal.ReservedFor != nil
+	}
+	if action == SealActionAssign && seal.Status == model.SealStatusIssued {
+		// ซีลที่อยู่กับช่างคนอื่นต้องใช้การโอน
+		return seal.AssignedToTechnician, true
+	}
+	return nil, true
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+)
+
+// ทดสอบตารางการเปลี่ยนสถานะของซีล (ไม่ต้องใช้ฐานข้อมูล)
+
+func uintPtr(v uint) *uint { return &v }
+
+func TestCheckSealTransition(t *testing.T) {
+	staff := UserActor(1, "user").WithOffice("A01")
+	otherOffice := UserActor(2, "user").WithOffice("B02")
+	admin := UserActor(3, "admin")
+	assignedTech := TechnicianActor(10)
+	otherTech := TechnicianActor(11)
+
+	tests := []struct {
+		name    string
+		seal    model.Seal
+		action  SealAction
+		actor   Actor
+		want    model.SealStatus
+		wantErr bool
+	}{
+		{name: "issue available", seal: model.Seal{Status: model.SealStatusAvailable}, action: SealActionIssue, actor: staff,
+			want: model.SealStatusIssued},
+		{name: "technician cannot issue", seal: model.Seal{Status: model.SealStatusAvailable}, action: SealActionIssue,
+			actor: assignedTech, wantErr: true},
+		{name: "issue twice", seal: model.Seal{Status: model.SealStatusIssued}, action: SealActionIssue, actor: staff,
+			wantErr: true},
+		{name: "reassign issued", seal: model.Seal{Status: model.SealStatusIssued}, action: SealActionAssign, actor: staff,
+			want: model.SealStatusIssued},
+		{name: "assigned technician installs",
+			seal:   model.Seal{Status: model.SealStatusIssued, AssignedToTechnician: uintPtr(10)},
+			action: SealActionInstall, actor: assignedTech, want: model.SealStatusInstalled},
+		{name: "other technician cannot install",
+			seal:   model.Seal{Status: model.SealStatusIssued, AssignedToTechnician: uintPtr(10)},
+			action: SealActionInstall, actor: otherTech, wantErr: true},
+		{name: "installing technician returns", seal: model.Seal{Status: model.SealStatusInstalled, UsedBy: uintPtr(10)},
+			action: SealActionReturn, actor: assignedTech, want: model.SealStatusUsed},
+		{name: "any technician removes", seal: model.Seal{Status: model.SealStatusInstalled, UsedBy: uintPtr(10)},
+			action: SealActionRemove, actor: otherTech, want: model.SealStatusUsed},
+		{name: "cancel issued", seal: model.Seal{Status: model.SealStatusIssued}, action: SealActionCancel, actor: staff,
+			want: model.SealStatusAvailable},
+		{name: "cancel installed", seal: model.Seal{Status: model.SealStatusInstalled}, action: SealActionCancel,
+			actor: staff, wantErr: true},
+		{name: "transfer by holder", seal: model.Seal{Status: model.SealStatusIssued, AssignedToTechnician: uintPtr(10)},
+			action: SealActionTransfer, actor: assignedTech, want: model.SealStatusIssued},
+		{name: "transfer by staff", seal: model.Seal{Status: model.SealStatusIssued, AssignedToTechnician: uintPtr(10)},
+			action: SealActionTransfer, actor: staff, wantErr: true},
+		{name: "dispatch own office", seal: model.Seal{Status: model.SealStatusAvailable, OfficeCode: "A01"},
+			action: SealActionDispatch, actor: staff, want: model.SealStatusInTransit},
+		{name: "dispatch other office", seal: model.Seal{Status: model.SealStatusAvailable, OfficeCode: "A01"},
+			action: SealActionDispatch, actor: otherOffice, wantErr: true},
+		{name: "dispatch without office needs admin", seal: model.Seal{Status: model.SealStatusAvailable},
+			action: SealActionDispatch, actor: staff, wantErr: true},
+		{name: "admin dispatches without office", seal: model.Seal{Status: model.SealStatusAvailable},
+			action: SealActionDispatch, actor: admin, want: model.SealStatusInTransit},
+		{name: "receive in transit", seal: model.Seal{Status: model.SealStatusInTransit}, action: SealActionReceive,
+			actor: otherOffice, want: model.SealStatusAvailable},
+		{name: "reserve own office", seal: model.Seal{Status: model.SealStatusAvailable, OfficeCode: "A01"},
+			action: SealActionReserve, actor: staff, want: model.SealStatusReserved},
+		{name: "release by reserver", seal: model.Seal{Status: model.SealStatusReserved, ReservedBy: uintPtr(1)},
+			action: SealActionRelease, actor: staff, want: model.SealStatusAvailable},
+		{name: "release by someone else", seal: model.Seal{Status: model.SealStatusReserved, ReservedBy: uintPtr(1)},
+			action: SealActionRelease, actor: otherOffice, wantErr: true},
+		{name: "system releases expired", seal: model.Seal{Status: model.SealStatusReserved, ReservedBy: uintPtr(1)},
+			action: SealActionRelease, actor: SystemActor(), want: model.SealStatusAvailable},
+		{name: "issue reserved", seal: model.Seal{Status: model.SealStatusReserved}, action: SealActionIssue, actor: staff,
+			want: model.SealStatusIssued},
+		{name: "restock needs admin", seal: model.Seal{Status: model.SealStatusIssued}, action: SealActionRestock,
+			actor: staff, wantErr: true},
+		{name: "admin marks installed lost", seal: model.Seal{Status: model.SealStatusInstalled},
+			action: SealActionMarkLost, actor: admin, want: model.SealStatusLost},
+		{name: "void installed", seal: model.Seal{Status: model.SealStatusInstalled}, action: SealActionVoid, actor: admin,
+			wantErr: true},
+		{name: "lost is terminal", seal: model.Seal{Status: model.SealStatusLost}, action: SealActionIssue, actor: admin,
+			wantErr: true},
+		{name: "unknown status", seal: model.Seal{Status: "broken"}, action: SealActionIssue, actor: admin, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkSealTransition(&tt.seal, tt.action, tt.actor)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("checkSealTransition(%s, %s) = %s, want error", tt.seal.Status, tt.action, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkSealTransition(%s, %s): %v", tt.seal.Status, tt.action, err)
+			}
+			if got != tt.want {
+				t.Errorf("checkSealTransition(%s, %s) = %s, want %s", tt.seal.Status, tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealTransitionTable(t *testing.T) {
+	terminal := map[model.SealStatus]bool{
+		model.SealStatusUsed:    true,
+		model.SealStatusLost:    true,
+		model.SealStatusDamaged: true,
+		model.SealStatusVoid:    true,
+	}
+	seen := map[SealAction]map[model.SealStatus]bool{}
+	for _, transition := range sealTransitions {
+		if seen[transition.Action] == nil {
+			seen[transition.Action] = map[model.SealStatus]bool{}
+		}
+		if seen[transition.Action][transition.From] {
+			t.Errorf("duplicate row %s from %s: findSealTransition only sees the first", transition.Action, transition.From)
+		}
+		seen[transition.Action][transition.From] = true
+
+		if terminal[transition.From] {
+			t.Errorf("%s leaves terminal status %s", transition.Action, transition.From)
+		}
+		if _, ok := model.ParseSealStatus(string(transition.To)); !ok {
+			t.Errorf("%s targets unknown status %s", transition.Action, transition.To)
+		}
+		if transition.Action.Label() == string(transition.Action) {
+			t.Errorf("%s has no label", transition.Action)
+		}
+		if transition.allow == nil {
+			t.Errorf("%s from %s has no permission check", transition.Action, transition.From)
+		}
+	}
+	for _, action := range []SealAction{SealActionReverse, SealActionCreate, SealActionReconcile} {
+		if len(seen[action]) > 0 {
+			t.Errorf("%s must stay outside the transition table", action)
+		}
+	}
+}
+
+func TestAvailableSealTransitions(t *testing.T) {
+	staff := UserActor(1, "user").WithOffice("A01")
+	officeScope := OfficeScope{Offices: []string{"A01"}}
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	type option struct {
+		action    SealAction
+		recipient *uint
+	}
+	tests := []struct {
+		name  string
+		seal  model.Seal
+		actor Actor
+		scope OfficeScope
+		want  []option
+	}{
+		{name: "available in scope", seal: model.Seal{Status: model.SealStatusAvailable, OfficeCode: "A01"},
+			actor: staff, scope: officeScope,
+			want: []option{{SealActionIssue, nil}, {SealActionAssign, nil}, {SealActionDispatch, nil}, {SealActionReserve, nil}}},
+		{name: "outside office scope", seal: model.Seal{Status: model.SealStatusAvailable, OfficeCode: "B02"},
+			actor: staff, scope: officeScope},
+		{name: "no office needs admin scope", seal: model.Seal{Status: model.SealStatusAvailable},
+			actor: staff, scope: officeScope},
+		{name: "active reservation only for reserved technician",
+			seal: model.Seal{Status: model.SealStatusReserved, OfficeCode: "A01", ReservedBy: uintPtr(1),
+				ReservedFor: uintPtr(10), ReservedUntil: &future},
+			actor: staff, scope: officeScope,
+			want: []option{{SealActionRelease, nil}, {SealActionIssue, uintPtr(10)}, {SealActionAssign, uintPtr(10)}}},
+		{name: "reservation without technician cannot be issued",
+			seal:  model.Seal{Status: model.SealStatusReserved, OfficeCode: "A01", ReservedBy: uintPtr(1), ReservedUntil: &future},
+			actor: staff, scope: officeScope,
+			want: []option{{SealActionRelease, nil}}},
+		{name: "expired reservation open to anyone",
+			seal: model.Seal{Status: model.SealStatusReserved, OfficeCode: "A01", ReservedBy: uintPtr(2),
+				ReservedFor: uintPtr(10), ReservedUntil: &past},
+			actor: staff, scope: officeScope,
+			want: []option{{SealActionIssue, nil}, {SealActionAssign, nil}}},
+		{name: "reassign only to current holder",
+			seal:  model.Seal{Status: model.SealStatusIssued, OfficeCode: "A01", AssignedToTechnician: uintPtr(10)},
+			actor: staff, scope: officeScope,
+			want: []option{{SealActionAssign, uintPtr(10)}, {SealActionInstall, nil}, {SealActionCancel, nil}}},
+		{name: "technician ignores office scope",
+			seal:  model.Seal{Status: model.SealStatusIssued, OfficeCode: "B02", AssignedToTechnician: uintPtr(10)},
+			actor: TechnicianActor(10), scope: OfficeScope{},
+			want: []option{{SealActionInstall, nil}, {SealActionTransfer, nil}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := availableSealTransitions(&tt.seal, tt.actor, tt.scope)
+			if len(got) != len(tt.want) {
+				t.Fatalf("availableSealTransitions = %+v, want %+v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].Action != want.action {
+					t.Errorf("option %d = %s, want %s", i, got[i].Action, want.action)
+				}
+				if (got[i].RecipientID == nil) != (want.recipient == nil) ||
+					(want.recipient != nil && *got[i].RecipientID != *want.recipient) {
+					t.Errorf("%s recipient = %v, want %v", got[i].Action, got[i].RecipientID, want.recipient)
+				}
+				if _, err := checkSealTransition(&tt.seal, got[i].Action, tt.actor); err != nil {
+					t.Errorf("offered %s but checkSealTransition rejects it: %v", got[i].Action, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSealConcurrentUpdate ซีลถูกแก้ไขโดยคำขออื่นระหว่างที่คำขอนี้ตรวจสถานะ
+var ErrSealConcurrentUpdate = errors.New("ซีลถูกแก้ไขโดยผู้อื่นพร้อมกัน")
+
+// lockSealByNumber อ่านซีลภายใน tx ของ transition พร้อมล็อกแถว (SELECT ... FOR UPDATE)
+// คำขอที่ทำกับซีลเส้นเดียวกันพร้อมกันจะรอกัน แล้วตรวจสถานะจากค่าล่าสุด
+// (คำขอที่สองที่ยังทำได้จึงสำเร็จ แทนที่จะล้มด้วย ErrSealConcurrentUpdate)
+func lockSealByNumber(tx *gorm.DB, sealNumber string) (*model.Seal, error) {
+	var seal model.Seal
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("seal_number = ?", sealNumber).First(&seal).Error; err != nil {
+		return nil, err
+	}
+	return &seal, nil
+}
+
+// saveSeal บันทึกซีลแบบ optimistic lock ภายใน tx ของ transition
+// UPDATE ... WHERE id = ? AND version = <เวอร์ชันที่อ่านมา> แล้วเพิ่ม version
+// ถ้ามีคำขออื่นบันทึกซีลเส้นนี้ไปก่อน (Postgres รอ row lock แล้วตรวจ WHERE ใหม่) จะไม่มีแถวถูกแก้ไข
+// และคืน ErrSealConcurrentUpdate ให้ tx ย้อนกลับ: คำขอที่ทำพร้อมกัน N ครั้งจึงสำเร็จได้ครั้งเดียว
+// ซีลที่อ่านด้วย lockSealByNumber จะไม่ชนกันที่นี่ ใช้กันกรณีที่อ่านซีลนอก tx
+// (ห้ามใช้ tx.Save กับซีล เพราะ GORM จะ insert ทับเมื่อ update ไม่โดนแถวใด)
+func saveSeal(tx *gorm.DB, seal *model.Seal) error {
+	expected := seal.Version
+	seal.Version = expected + 1
+	result := tx.Model(seal).Select("*").Where("version = ?", expected).Updates(seal)
+	if result.Error != nil {
+		seal.Version = expected
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		seal.Version = expected
+		return fmt.Errorf("%w: %s กรุณาโหลดข้อมูลใหม่แล้วลองอีกครั้ง", ErrSealConcurrentUpdate, seal.SealNumber)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+	migration "github.com/Kev2406/PEA/internal/infrastructure/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ทดสอบกับ Postgres จริง (row lock + การตรวจ WHERE ใหม่หลังรอ lock เป็นพฤติกรรมของ Postgres)
+// ตั้งค่า TEST_DATABASE_DSN เช่น "host=localhost user=postgres password=postgres dbname=pea_test port=5432 sslmode=disable"
+// ถ้าไม่ได้ตั้งค่าจะข้ามการทดสอบ
+
+const concurrentSealWriters = 8
+
+func openSealTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set: skipping Postgres-backed test")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := migration.CreateStoreTable(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func newSealTestService(db *gorm.DB) *SealService {
+	return NewSealService(
+		repository.NewSealRepository(db),
+		repository.NewTransactionRepository(db),
+		repository.NewLogRepository(db),
+		db,
+		repository.NewTechnicianRepository(db),
+		repository.NewSealIncidentRepository(db),
+		repository.NewSealTransferRepository(db),
+		repository.NewSealStoreReturnRepository(db),
+		repository.NewWorkOrderRepository(db),
+		repository.NewOfficeTransferRepository(db),
+		NewSealStockService(repository.NewSealStockRepository(db), db),
+		repository.NewStocktakeRepository(db),
+		NewSealNumberFormatService(repository.NewSealNumberFormatRepository(db)),
+	)
+}
+
+func createTestSeals(t *testing.T, db *gorm.DB, count int) []model.Seal {
+	t.Helper()
+	prefix := fmt.Sprintf("TV%d-", time.Now().UnixNano())
+	seals := make([]model.Seal, count)
+	for i := range seals {
+		seals[i] = model.Seal{SealNumber: fmt.Sprintf("%s%d", prefix, i), Status: model.SealStatusAvailable}
+	}
+	if err := db.Create(&seals).Error; err != nil {
+		t.Fatalf("create seals: %v", err)
+	}
+	// โหลดใหม่เพื่อให้ได้ version จากค่า default ของฐานข้อมูล
+	if err := db.Where("seal_number LIKE ?", prefix+"%").Order("id").Find(&seals).Error; err != nil {
+		t.Fatalf("reload seals: %v", err)
+	}
+	return seals
+}
+
+// raceOnLockedSeals ล็อกแถวซีลไว้ แล้วให้ writer ทั้ง N ตัวไปรอ lock ที่ SELECT ... FOR UPDATE (lockSealByNumber)
+// เมื่อรอครบ N ตัวจึงปล่อย lock: ตัวแรกบันทึกได้ ตัวที่เหลืออ่านซีลที่เปลี่ยนสถานะแล้วและถูกตาราง transition ปฏิเสธ
+func raceOnLockedSeals(t *testing.T, db *gorm.DB, seals []model.Seal, write func() error) []error {
+	t.Helper()
+	ids := make([]uint, len(seals))
+	for i := range seals {
+		ids[i] = seals[i].ID
+	}
+	lock := db.Begin()
+	var locked []model.Seal
+	if err := lock.Raw("SELECT * FROM seals WHERE id IN ? FOR UPDATE", ids).Scan(&locked).Error; err != nil {
+		lock.Rollback()
+		t.Fatalf("lock seals: %v", err)
+	}
+
+	errs := make([]error, concurrentSealWriters)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrentSealWriters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = write()
+		}(i)
+	}
+
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		var waiting int64
+		if err := db.Raw(`SELECT count(*) FROM pg_stat_activity
+			WHERE datname = current_database() AND wait_event_type = 'Lock' AND query LIKE 'SELECT%FROM "seals"%FOR UPDATE%'`).
+			Scan(&waiting).Error; err != nil {
+			lock.Rollback()
+			t.Fatalf("count waiting writers: %v", err)
+		}
+		if waiting >= concurrentSealWriters {
+			break
+		}
+		if time.Now().After(deadline) {
+			lock.Rollback()
+			wg.Wait()
+			t.Fatalf("only %d of %d writers reached the row lock", waiting, concurrentSealWriters)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err := lock.Commit().Error; err != nil {
+		t.Fatalf("release lock: %v", err)
+	}
+	wg.Wait()
+	return errs
+}
+
+func assertOneWriterWon(t *testing.T, db *gorm.DB, seals []model.Seal, errs []error) {
+	t.Helper()
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrSealConcurrentUpdate):
+			// แถวถูกล็อกตอนอ่าน ตัวที่รอต้องเห็นค่าล่าสุด ไม่ใช่ชนกันที่ version
+			t.Errorf("expected a transition error after waiting for the row lock, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("expected exactly 1 successful write, got %d (of %d)", succeeded, len(errs))
+	}
+
+	for _, seal := range seals {
+		var current model.Seal
+		if err := db.First(&current, seal.ID).Error; err != nil {
+			t.Fatalf("reload %s: %v", seal.SealNumber, err)
+		}
+		if current.Version != seal.Version+1 {
+			t.Errorf("%s: version %d -> %d, expected +1", seal.SealNumber, seal.Version, current.Version)
+		}
+		if current.Status != model.SealStatusIssued {
+			t.Errorf("%s: status %s, expected %s", seal.SealNumber, current.Status, model.SealStatusIssued)
+		}
+		var histories int64
+		db.Model(&model.Transaction{}).Where("seal_id = ? AND action = ?", seal.ID, SealActionIssue).Count(&histories)
+		if histories != 1 {
+			t.Errorf("%s: %d history rows, expected 1", seal.SealNumber, histories)
+		}
+	}
+}
+
+func TestIssueSealWithDetailsConcurrentWritesOnlyOneSucceeds(t *testing.T) {
+	db := openSealTestDB(t)
+	svc := newSealTestService(db)
+	seals := createTestSeals(t, db, 1)
+	actor := UserActor(1, "admin")
+
+	errs := raceOnLockedSeals(t, db, seals, func() error {
+		return svc.IssueSealWithDetails(seals[0].SealNumber, 1, "E001", "concurrency test", actor)
+	})
+	assertOneWriterWon(t, db, seals, errs)
+}
+
+func TestIssueMultipleSealsConcurrentWritesOnlyOneSucceeds(t *testing.T) {
+	db := openSealTestDB(t)
+	svc := newSealTestService(db)
+	seals := createTestSeals(t, db, 3)
+	sealNumbers := make([]string, len(seals))
+	for i := range seals {
+		sealNumbers[i] = seals[i].SealNumber
+	}
+	actor := UserActor(1, "admin")
+
+	errs := raceOnLockedSeals(t, db, seals, func() error {
+		_, err := svc.IssueMultipleSeals(sealNumbers, 1, "E001", "concurrency test", actor, OfficeScope{All: true})
+		return err
+	})
+	assertOneWriterWon(t, db, seals, errs)
+}
+
+// fakeSealConnPool ConnPool จำลองตาราง seals หนึ่งแถว สำหรับทดสอบ saveSeal โดยไม่ต้องใช้ Postgres
+// UPDATE จะโดนแถวก็ต่อเมื่อ version ใน WHERE ตรงกับเวอร์ชันปัจจุบัน (เหมือน Postgres ตรวจ WHERE ใหม่หลังได้ lock)
+type fakeSealConnPool struct {
+	version uint
+	updates int
+}
+
+var fakeVersionCondition = regexp.MustCompile(`version = \$(\d+)`)
+
+func (p *fakeSealConnPool) ExecContext(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+	match := fakeVersionCondition.FindStringSubmatch(query)
+	if match == nil {
+		return nil, fmt.Errorf("unexpected statement without version check: %s", query)
+	}
+	index, _ := strconv.Atoi(match[1])
+	if expected, ok := args[index-1].(uint); !ok || expected != p.version {
+		return driver.RowsAffected(0), nil
+	}
+	p.version++
+	p.updates++
+	return driver.RowsAffected(1), nil
+}
+
+func (p *fakeSealConnPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New("fakeSealConnPool: prepare not supported")
+}
+
+func (p *fakeSealConnPool) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("fakeSealConnPool: query not supported")
+}
+
+func (p *fakeSealConnPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func TestSaveSealRejectsStaleVersion(t *testing.T) {
+	pool := &fakeSealConnPool{version: 3}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool, WithoutReturning: true}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	// สองคำขออ่านซีลเวอร์ชันเดียวกัน
+	first := model.Seal{ID: 7, SealNumber: "F0001", Status: model.SealStatusAvailable, Version: 3}
+	second := first
+
+	first.Status = model.SealStatusIssued
+	if err := saveSeal(db, &first); err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+	if first.Version != 4 {
+		t.Errorf("first save: version %d, expected 4", first.Version)
+	}
+
+	second.Status = model.SealStatusVoid
+	err = saveSeal(db, &second)
+	if !errors.Is(err, ErrSealConcurrentUpdate) {
+		t.Fatalf("second save: expected ErrSealConcurrentUpdate, got %v", err)
+	}
+	if second.Version != 3 {
+		t.Errorf("second save: version %d, expected the read version 3 to be restored", second.Version)
+	}
+	if pool.updates != 1 || pool.version != 4 {
+		t.Errorf("expected exactly one applied update (version 4), got %d updates (version %d)", pool.updates, pool.version)
+	}
+
+	// อ่านใหม่แล้วบันทึกได้
+	second.Version = pool.version
+	if err := saveSeal(db, &second); err != nil {
+		t.Fatalf("save after reload: %v", err)
+	}
+	if pool.version != 5 {
+		t.Errorf("expected version 5 after reload, got %d", pool.version)
+	}
+}
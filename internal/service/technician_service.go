@@ -14,6 +14,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 var technicianSecretKey = []byte("your-technician-secret-key")
@@ -68,44 +69,6 @@ func (s *TechnicianService) Login(username, password string) (string, error) {
 	}
 	return signedToken, nil
 }
-func (s *TechnicianService) InstallSeal(sealNumber string, techID uint, serialNumber string) error {
-	log.Printf("🔧 [InstallSeal] sealNumber=%s, techID=%d\n", sealNumber, techID)
-
-	// 🔎 **1) ตรวจสอบว่าซีลมีอยู่ในระบบ**
-	seal, err := s.repo.FindSealByNumber(sealNumber)
-	if err != nil {
-		return errors.New("ไม่พบซีลในระบบ")
-	}
-
-	// 🔍 **2) ตรวจสอบว่าซีลถูก assign ให้ technician คนนี้**
-	if seal.AssignedToTechnician == nil || *seal.AssignedToTechnician != techID {
-		return errors.New("คุณไม่มีสิทธิ์ติดตั้งซีลนี้")
-	}
-
-	// 🚦 **3) ตรวจสอบว่าสถานะของซีลเป็น 'จ่าย'**
-	if seal.Status != "จ่าย" {
-		return errors.New("ซีลต้องอยู่ในสถานะ 'จ่าย' เท่านั้นจึงจะติดตั้งได้")
-	}
-
-	// 🛠 **4) อัปเดตสถานะเป็น 'ติดตั้งแล้ว'**
-	now := time.Now()
-	seal.Status = "ติดตั้งแล้ว"
-	seal.UsedBy = &techID
-	seal.UsedAt = &now
-	seal.InstalledSerial = serialNumber
-
-	if err := s.repo.UpdateSeal(seal); err != nil {
-		return err
-	}
-
-	// 📝 **5) บันทึก Log ว่ามีการติดตั้ง**
-	logEntry := model.Log{
-		UserID: techID,
-		Action: fmt.Sprintf("ติดตั้งซีล %s (Serial: %s)", sealNumber, serialNumber),
-	}
-	return s.repo.CreateLog(&logEntry)
-}
-
 func (s *TechnicianService) ReturnSeal(sealNumber string, techID uint, remarks string) error {
 	// ✅ ค้นหาซิลจากฐานข้อมูล
 	seal, err := s.repo.FindSealByNumber(sealNumber)
@@ -113,33 +76,33 @@ func (s *TechnicianService) ReturnSeal(sealNumber string, techID uint, remarks s
 		return errors.New("ไม่พบซีลในระบบ")
 	}
 
-	// ✅ ตรวจสอบว่าซิลถูกใช้โดยช่างคนนี้หรือไม่
-	if seal.UsedBy == nil || *seal.UsedBy != techID {
-		return errors.New("คุณไม่มีสิทธิ์คืนซีลนี้")
-	}
-
-	// ✅ ตรวจสอบว่าสถานะของซีลเป็น "ติดตั้งแล้ว" เท่านั้น
-	if seal.Status != "ติดตั้งแล้ว" {
-		return errors.New("ซิลต้องอยู่ในสถานะ 'ติดตั้งแล้ว' เท่านั้นจึงจะคืนได้")
+	// ✅ ตรวจสอบสถานะ และว่าซิลถูกใช้โดยช่างคนนี้หรือไม่ (ตาราง transition)
+	to, err := checkSealTransition(seal, SealActionReturn, TechnicianActor(techID))
+	if err != nil {
+		return err
 	}
 
 	now := time.Now()
-	seal.Status = "ใช้งานแล้ว"
+	before := *seal
+	seal.Status = to
 	seal.ReturnedBy = &techID
 	seal.ReturnedAt = &now
 	seal.ReturnRemarks = remarks // ✅ บันทึกหมายเหตุ
 
-	// ✅ บันทึกข้อมูลลงฐานข้อมูล
-	if err := s.repo.UpdateSeal(seal); err != nil {
-		return err
-	}
-
-	// ✅ บันทึก Log
-	logEntry := model.Log{
-		UserID: techID,
-		Action: fmt.Sprintf("คืนซีล %s (หมายเหตุ: %s)", sealNumber, remarks),
-	}
-	return s.repo.CreateLog(&logEntry)
+	// ✅ บันทึกซีล ประวัติ และ Log ในธุรกรรมเดียว
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := saveSeal(tx, seal); err != nil {
+			return err
+		}
+		if err := recordSealHistory(tx, &before, seal, SealActionReturn, TechnicianActor(techID), remarks); err != nil {
+			return err
+		}
+		logEntry := model.Log{
+			UserID: techID,
+			Action: fmt.Sprintf("คืนซีล %s (หมายเหตุ: %s)", sealNumber, remarks),
+		}
+		return tx.Create(&logEntry).Error
+	})
 }
 func (s *TechnicianService) UpdateTechnician(techID uint, req struct {
 	FirstName   string
@@ -217,7 +180,7 @@ func (s *TechnicianService) UploadSealImages(sealNumber string, techID uint, ima
 		return errors.New("ไม่พบซีลในระบบ")
 	}
 
-	if seal.Status != "ติดตั้งแล้ว" {
+	if seal.Status != model.SealStatusInstalled {
 		return errors.New("ซีลต้องอยู่ในสถานะ 'ติดตั้งแล้ว' เท่านั้นจึงจะอัปโหลดรูปได้")
 	}
 
@@ -232,5 +195,12 @@ func (s *TechnicianService) UploadSealImages(sealNumber string, techID uint, ima
 		seal.Image2 = image2
 	}
 
-	return s.repo.UpdateSeal(seal)
+	updated, err := s.repo.UpdateSealImages(seal)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("%w: %s กรุณาโหลดข้อมูลใหม่แล้วลองอีกครั้ง", ErrSealConcurrentUpdate, seal.SealNumber)
+	}
+	return nil
 }
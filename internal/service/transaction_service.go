@@ -1,12 +1,13 @@
 package service
 
 import (
-	"errors"
-
 	"github.com/Kev2406/PEA/internal/domain/model"
 	"github.com/Kev2406/PEA/internal/domain/repository"
 )
 
+// MaxTransactionPageSize จำนวนรายการสูงสุดต่อหน้าของสมุดบัญชี
+const MaxTransactionPageSize = 500
+
 type TransactionService struct {
 	repo *repository.TransactionRepository
 }
@@ -15,17 +16,18 @@ func NewTransactionService(repo *repository.TransactionRepository) *TransactionS
 	return &TransactionService{repo: repo}
 }
 
-// บันทึก Transaction
-func (s *TransactionService) CreateTransaction(transaction *model.Transaction) error {
-	if transaction.SealID == 0 || transaction.UserID == 0 || transaction.Action == "" {
-		return errors.New("missing required fields")
-	}
-	return s.repo.Create(transaction)
-}
+// สมุดบัญชีเขียนโดยการเปลี่ยนสถานะซีลเท่านั้น (recordSealHistory / recordSealCreations) ที่นี่อ่านอย่างเดียว
 
-// ดึง Transaction ทั้งหมด
-func (s *TransactionService) GetAllTransactions() ([]model.Transaction, error) {
-	return s.repo.GetAll()
+// ค้นหา Transaction ตามซีล ผู้ทำ action และช่วงเวลา (จำกัดตามขอบเขตสำนักงานของผู้เรียก)
+func (s *TransactionService) FindTransactions(filter repository.TransactionFilter, scope OfficeScope) ([]model.Transaction, int64, error) {
+	if filter.Limit <= 0 || filter.Limit > MaxTransactionPageSize {
+		filter.Limit = MaxTransactionPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.Offices = scope.Codes()
+	return s.repo.Find(filter)
 }
 
 // ดึง Transaction ตาม ID
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kev2406/PEA/internal/domain/model"
+	"github.com/Kev2406/PEA/internal/domain/repository"
+)
+
+// WorkOrderService จัดการใบงานและซีลที่ใช้ไปในแต่ละใบงาน
+type WorkOrderService struct {
+	repo *repository.WorkOrderRepository
+}
+
+func NewWorkOrderService(repo *repository.WorkOrderRepository) *WorkOrderService {
+	return &WorkOrderService{repo: repo}
+}
+
+// WorkOrderSeals ซีลของใบงาน แยกเป็นที่ใช้ไปแล้ว (ติดตั้ง/ใช้งานแล้ว) และที่จ่ายไว้แต่ยังไม่ติดตั้ง
+type WorkOrderSeals struct {
+	WorkOrder *model.WorkOrder `json:"work_order"`
+	Consumed  []model.Seal     `json:"consumed"`
+	Pending   []model.Seal     `json:"pending"`
+}
+
+// CreateWorkOrder สร้างใบงานใหม่ (เลขที่ใบงานห้ามซ้ำ)
+func (s *WorkOrderService) CreateWorkOrder(input *model.WorkOrder, createdBy uint) (*model.WorkOrder, error) {
+	input.JobNumber = strings.TrimSpace(input.JobNumber)
+	if input.JobNumber == "" {
+		return nil, errors.New("job_number is required")
+	}
+	if !input.JobType.Valid() {
+		return nil, errors.New("ประเภทใบงานไม่ถูกต้อง (new_connection, meter_change หรือ inspection)")
+	}
+	if _, err := s.repo.FindByJobNumber(input.JobNumber); err == nil {
+		return nil, fmt.Errorf("ใบงาน %s มีอยู่แล้ว", input.JobNumber)
+	}
+
+	input.CreatedBy = createdBy
+	if err := s.repo.Create(input); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+// UpdateWorkOrder แก้ไขรายละเอียดใบงาน (เลขที่ใบงานแก้ไม่ได้)
+func (s *WorkOrderService) UpdateWorkOrder(jobNumber string, input *model.WorkOrder) (*model.WorkOrder, error) {
+	workOrder, err := s.repo.FindByJobNumber(jobNumber)
+	if err != nil {
+		return nil, err
+	}
+	if input.JobType != "" {
+		if !input.JobType.Valid() {
+			return nil, errors.New("ประเภทใบงานไม่ถูกต้อง (new_connection, meter_change หรือ inspection)")
+		}
+		workOrder.JobType = input.JobType
+	}
+	workOrder.CustomerAccount = input.CustomerAccount
+	workOrder.Address = input.Address
+	workOrder.AssignedTechnicianID = input.AssignedTechnicianID
+	workOrder.DueDate = input.DueDate
+	if err := s.repo.Update(workOrder); err != nil {
+		return nil, err
+	}
+	return workOrder, nil
+}
+
+func (s *WorkOrderService) GetWorkOrders(technicianID uint, jobType model.WorkOrderType) ([]model.WorkOrder, error) {
+	return s.repo.Search(technicianID, jobType)
+}
+
+func (s *WorkOrderService) GetWorkOrder(jobNumber string) (*model.WorkOrder, error) {
+	return s.repo.FindByJobNumber(jobNumber)
+}
+
+// GetWorkOrderSeals รายการซีลที่ใช้ไปกับใบงาน
+func (s *WorkOrderService) GetWorkOrderSeals(jobNumber string) (*WorkOrderSeals, error) {
+	workOrder, err := s.repo.FindByJobNumber(jobNumber)
+	if err != nil {
+		return nil, err
+	}
+	seals, err := s.repo.FindSeals(workOrder.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WorkOrderSeals{
+		WorkOrder: workOrder,
+		Consumed:  []model.Seal{},
+		Pending:   []model.Seal{},
+	}
+	for _, seal := range seals {
+		switch seal.Status {
+		case model.SealStatusInstalled, model.SealStatusUsed:
+			result.Consumed = append(result.Consumed, seal)
+		case model.SealStatusIssued:
+			result.Pending = append(result.Pending, seal)
+		}
+	}
+	return result, nil
+}
+
+// resolveWorkOrder หาใบงานจากเลขที่ (ว่าง = ไม่ผูกใบงาน) และตรวจว่าใบงานไม่ได้มอบให้ช่างคนอื่น
+func (s *SealService) resolveWorkOrder(jobNumber string, techID uint) (*model.WorkOrder, error) {
+	jobNumber = strings.TrimSpace(jobNumber)
+	if jobNumber == "" {
+		return nil, nil
+	}
+	workOrder, err := s.workOrderRepo.FindByJobNumber(jobNumber)
+	if err != nil {
+		return nil, fmt.Errorf("ไม่พบใบงาน %s", jobNumber)
+	}
+	if workOrder.AssignedTechnicianID != nil && *workOrder.AssignedTechnicianID != techID {
+		return nil, fmt.Errorf("ใบงาน %s มอบหมายให้ช่าง ID %d", jobNumber, *workOrder.AssignedTechnicianID)
+	}
+	return workOrder, nil
+}